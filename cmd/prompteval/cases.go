@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"rio-go-model/configs/catalog"
+	"rio-go-model/internal/helpers"
+	"rio-go-model/internal/helpers/scoring"
+	"rio-go-model/internal/util"
+)
+
+// Fixed location used by every case, so prompts only change when templates do
+const (
+	evalCountry = "India"
+	evalCity    = "Hyderabad"
+)
+
+// Case kinds: a topic list prompt from DynamicPrompting or a story prompt from GenerateFormattedPrompt
+const (
+	kindTopics = "topics"
+	kindStory  = "story"
+)
+
+// themeIDs maps a catalog theme to the theme id used by story prompts
+var themeIDs = map[string]string{
+	catalog.PlanetProtector: "1",
+	catalog.Mindful:         "2",
+	catalog.Chill:           "3",
+}
+
+// evalCase is one rendered prompt and, once run, the provider output and its scores
+type evalCase struct {
+	Language string
+	Theme    string
+	Kind     string
+	// Variant is the preference, or the religion for mindful stories
+	Variant string
+
+	System string
+	Prompt string
+	Output string
+	Scores []scoring.Result
+
+	topic  string
+	kwargs map[string]interface{}
+}
+
+// Name identifies the case and is its golden file path without extension
+func (c *evalCase) Name() string {
+	variant := strings.ToLower(strings.ReplaceAll(c.Variant, " ", "_"))
+	if variant == "" {
+		variant = "default"
+	}
+	return fmt.Sprintf("%s/%s/%s-%s", strings.ToLower(c.Language), c.Theme, c.Kind, variant)
+}
+
+// Failed reports whether any scorer failed
+func (c *evalCase) Failed() bool {
+	for _, score := range c.Scores {
+		if !score.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCases renders the topic and story prompts of every language, theme and preference in the catalog.
+// Mindful cases vary by religion instead of preference, as their prompts ignore preferences.
+func buildCases(cat *catalog.Catalog, opts options) ([]*evalCase, error) {
+	languages := make([]string, 0, len(cat.Languages))
+	for name := range cat.Languages {
+		if opts.language == "" || strings.EqualFold(opts.language, name) {
+			languages = append(languages, name)
+		}
+	}
+	sort.Strings(languages)
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("catalog has no language %q", opts.language)
+	}
+
+	var cases []*evalCase
+	for _, language := range languages {
+		lang := cat.Languages[language]
+		for _, theme := range catalog.Themes {
+			if opts.theme != "" && opts.theme != theme {
+				continue
+			}
+			variants := sortedKeys(lang.Preferences)
+			if theme == catalog.Mindful {
+				variants = sortedKeys(lang.Topics.Mindful)
+			}
+			for _, variant := range variants {
+				for _, kind := range []string{kindTopics, kindStory} {
+					c := &evalCase{Language: language, Theme: theme, Kind: kind, Variant: variant}
+					if err := renderCase(c, lang, opts); err != nil {
+						return nil, fmt.Errorf("%s: %v", c.Name(), err)
+					}
+					cases = append(cases, c)
+				}
+			}
+		}
+	}
+	return cases, nil
+}
+
+// renderCase fills in the prompt of a case the way story generation would
+func renderCase(c *evalCase, lang *catalog.Language, opts options) error {
+	preference, religion := c.Variant, ""
+	if c.Theme == catalog.Mindful {
+		preference, religion = "", c.Variant
+	}
+
+	if c.Kind == kindTopics {
+		// A fresh seeded instance per case keeps the picked topics independent of the filters
+		dp := helpers.NewSeededDynamicPrompting(opts.seed)
+		var err error
+		switch c.Theme {
+		case catalog.PlanetProtector:
+			c.Prompt, err = dp.GetPlanetProtectorsStories(evalCountry, evalCity, preference, c.Language, opts.count)
+		case catalog.Mindful:
+			c.Prompt, err = dp.GetMindfulStories(evalCountry, religion, nil, c.Language, opts.count)
+		case catalog.Chill:
+			c.Prompt, err = dp.GetChillStories(preference, c.Language, opts.count)
+		}
+		return err
+	}
+
+	topics := lang.TopicList(c.Theme, religion)
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics")
+	}
+	c.topic = topics[0]
+	c.kwargs = map[string]interface{}{
+		"country":     evalCountry,
+		"city":        evalCity,
+		"religions":   religion,
+		"preferences": preference,
+		"language":    c.Language,
+	}
+	var err error
+	c.Prompt, c.System, err = util.GenerateFormattedPrompt(themeIDs[c.Theme], c.topic, c.kwargs)
+	return err
+}
+
+// runCase sends the prompt of a case to the provider and scores the output
+func runCase(c *evalCase, provider helpers.StoryTextProvider, count int) error {
+	if c.Kind == kindTopics {
+		response, err := provider.CreateTopics(c.Prompt)
+		if err != nil {
+			return err
+		}
+		c.Output = strings.Join(response.Title, "\n")
+		c.Scores = []scoring.Result{
+			scoring.TopicsQuality(response.Title, count, c.Language),
+			scoring.Safety(c.Output, c.Language),
+		}
+		return nil
+	}
+
+	response, err := provider.CreateStory(themeIDs[c.Theme], c.topic, c.kwargs)
+	if err != nil {
+		return err
+	}
+	c.Output = response.Story
+	c.Scores = []scoring.Result{
+		scoring.StoryQuality(c.Output, c.Language),
+		scoring.Safety(c.Output, c.Language),
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
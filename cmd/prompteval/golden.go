@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// goldenExt is the extension of golden files
+const goldenExt = ".golden"
+
+// Golden file statuses
+const (
+	statusSame    = "unchanged"
+	statusChanged = "changed"
+	statusNew     = "new"
+)
+
+// goldenResult compares one case with its golden file
+type goldenResult struct {
+	Case   *evalCase
+	Status string
+	Diff   []string
+}
+
+// render writes a case as it is stored in its golden file
+func (c *evalCase) render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# case: %s\n", c.Name())
+	section := func(title, body string) {
+		fmt.Fprintf(&b, "\n## %s\n%s\n", title, strings.TrimRight(body, "\n"))
+	}
+	if c.System != "" {
+		section("system", c.System)
+	}
+	section("prompt", c.Prompt)
+	section("output", c.Output)
+	scores := make([]string, len(c.Scores))
+	for i, score := range c.Scores {
+		scores[i] = score.String()
+	}
+	section("scores", strings.Join(scores, "\n"))
+	return b.String()
+}
+
+// compareGolden diffs a case with its golden file in dir, rewriting the file when update is set
+func compareGolden(dir string, c *evalCase, update bool) (goldenResult, error) {
+	path := filepath.Join(dir, filepath.FromSlash(c.Name())+goldenExt)
+	got := c.render()
+	result := goldenResult{Case: c, Status: statusSame}
+
+	want, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		result.Status = statusNew
+	case err != nil:
+		return result, fmt.Errorf("error reading golden file: %v", err)
+	case string(want) != got:
+		result.Status = statusChanged
+		result.Diff = diffLines(string(want), got)
+	}
+
+	if update && result.Status != statusSame {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return result, fmt.Errorf("error creating golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			return result, fmt.Errorf("error writing golden file: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// diffLines returns the lines removed from want ("-") and added in got ("+"), with unchanged
+// lines next to a change kept for context ("  ")
+func diffLines(want, got string) []string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			lines = append(lines, "+ "+b[j])
+			j++
+		default:
+			lines = append(lines, "- "+a[i])
+			i++
+		}
+	}
+	return trimContext(lines, 2)
+}
+
+// trimContext drops unchanged lines further than context lines from a change
+func trimContext(lines []string, context int) []string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+			keep[k] = true
+		}
+	}
+	var trimmed []string
+	skipped := false
+	for i, line := range lines {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+		if skipped && len(trimmed) > 0 {
+			trimmed = append(trimmed, "  ...")
+		}
+		skipped = false
+		trimmed = append(trimmed, line)
+	}
+	return trimmed
+}
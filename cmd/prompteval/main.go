@@ -0,0 +1,178 @@
+// Command prompteval renders every theme, language and preference combination of the topic and
+// story prompts, runs them against a text provider, scores the outputs for quality and safety and
+// diffs the results against golden files, so prompt regressions are caught before deploying.
+//
+//	go run ./cmd/prompteval                          # compare with the fake provider
+//	go run ./cmd/prompteval -update                  # accept the current outputs as golden
+//	go run ./cmd/prompteval -provider live -record   # call Gemini and record its responses
+//	go run ./cmd/prompteval -provider recorded       # replay the recorded responses
+//
+// It exits with status 1 when a golden file differs or is missing, or with -strict when a scorer fails.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"rio-go-model/configs"
+	"rio-go-model/configs/catalog"
+)
+
+// options are the command line flags
+type options struct {
+	provider   string
+	recordings string
+	record     bool
+	golden     string
+	update     bool
+	report     string
+	language   string
+	theme      string
+	count      int
+	seed       int64
+	catalogDir string
+	strict     bool
+	verbose    bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.provider, "provider", providerFake, "text provider: fake, live or recorded")
+	flag.StringVar(&opts.recordings, "recordings", "cmd/prompteval/testdata/recordings", "directory of recorded provider responses")
+	flag.BoolVar(&opts.record, "record", false, "record live responses into -recordings")
+	flag.StringVar(&opts.golden, "golden", "cmd/prompteval/testdata/golden", "directory of golden files")
+	flag.BoolVar(&opts.update, "update", false, "write changed and new outputs to the golden files")
+	flag.StringVar(&opts.report, "report", "", "write the report to this file instead of stdout")
+	flag.StringVar(&opts.language, "language", "", "only evaluate this language")
+	flag.StringVar(&opts.theme, "theme", "", "only evaluate this theme: planet_protector, mindful or chill")
+	flag.IntVar(&opts.count, "count", 3, "topics per topic prompt")
+	flag.Int64Var(&opts.seed, "seed", 1, "seed for picking topics into topic prompts")
+	flag.StringVar(&opts.catalogDir, "catalog", os.Getenv("CATALOG_DIR"), "catalog directory (default built-in catalog)")
+	flag.BoolVar(&opts.strict, "strict", false, "also fail when a quality or safety scorer fails")
+	flag.BoolVar(&opts.verbose, "v", false, "show the logs of the prompt builders and providers")
+	flag.Parse()
+
+	ok, err := run(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prompteval: %v\n", err)
+		os.Exit(2)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run evaluates every case and writes the report. ok is false when the run should fail.
+func run(opts options) (ok bool, err error) {
+	if !opts.verbose {
+		// The prompt builders log every prompt they render
+		log.SetOutput(io.Discard)
+	}
+	if opts.count <= 0 {
+		return false, fmt.Errorf("-count must be positive")
+	}
+	// Providers return DEFAULT_STORY_TO_GENERATE topics
+	configs.GetSettings().DefaultStoryToGenerate = opts.count
+	if err := catalog.Init(opts.catalogDir); err != nil {
+		return false, err
+	}
+	provider, err := newProvider(opts.provider, opts.recordings, opts.record)
+	if err != nil {
+		return false, err
+	}
+	cases, err := buildCases(catalog.Current(), opts)
+	if err != nil {
+		return false, err
+	}
+
+	var results []goldenResult
+	errors := make(map[string]error)
+	for _, c := range cases {
+		if err := runCase(c, provider, opts.count); err != nil {
+			errors[c.Name()] = err
+			continue
+		}
+		result, err := compareGolden(opts.golden, c, opts.update)
+		if err != nil {
+			errors[c.Name()] = err
+			continue
+		}
+		results = append(results, result)
+	}
+
+	out := os.Stdout
+	if opts.report != "" {
+		file, err := os.Create(opts.report)
+		if err != nil {
+			return false, fmt.Errorf("error creating report: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	failed := writeReport(out, opts, cases, results, errors)
+	return !failed, nil
+}
+
+// writeReport writes a markdown report and returns whether the run failed
+func writeReport(w io.Writer, opts options, cases []*evalCase, results []goldenResult, errors map[string]error) bool {
+	counts := map[string]int{}
+	var failing []*evalCase
+	for _, result := range results {
+		counts[result.Status]++
+		if result.Case.Failed() {
+			failing = append(failing, result.Case)
+		}
+	}
+
+	fmt.Fprintf(w, "# Prompt evaluation\n\n")
+	fmt.Fprintf(w, "Provider: %s, catalog: %s, cases: %d, unchanged: %d, changed: %d, new: %d, errors: %d, failing scores: %d\n",
+		opts.provider, catalog.Current().Source, len(cases), counts[statusSame], counts[statusChanged], counts[statusNew], len(errors), len(failing))
+	if opts.update {
+		fmt.Fprintf(w, "\nGolden files were updated.\n")
+	}
+
+	if counts[statusChanged] > 0 {
+		fmt.Fprintf(w, "\n## Changed\n")
+		for _, result := range results {
+			if result.Status == statusChanged {
+				fmt.Fprintf(w, "\n### %s\n\n```diff\n%s\n```\n", result.Case.Name(), strings.Join(result.Diff, "\n"))
+			}
+		}
+	}
+	if counts[statusNew] > 0 {
+		fmt.Fprintf(w, "\n## New\n\n")
+		for _, result := range results {
+			if result.Status == statusNew {
+				fmt.Fprintf(w, "- %s\n", result.Case.Name())
+			}
+		}
+	}
+	if len(errors) > 0 {
+		fmt.Fprintf(w, "\n## Errors\n\n")
+		for _, c := range cases {
+			if err, ok := errors[c.Name()]; ok {
+				fmt.Fprintf(w, "- %s: %v\n", c.Name(), err)
+			}
+		}
+	}
+	if len(failing) > 0 {
+		fmt.Fprintf(w, "\n## Failing scores\n\n")
+		for _, c := range failing {
+			for _, score := range c.Scores {
+				if !score.Passed {
+					fmt.Fprintf(w, "- %s: %s\n", c.Name(), score)
+				}
+			}
+		}
+	}
+
+	failed := len(errors) > 0 || (opts.strict && len(failing) > 0)
+	if !opts.update && counts[statusChanged]+counts[statusNew] > 0 {
+		failed = true
+	}
+	return failed
+}
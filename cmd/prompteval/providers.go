@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rio-go-model/internal/helpers"
+	"rio-go-model/internal/helpers/fake"
+	"rio-go-model/internal/helpers/google/gemini"
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/util"
+)
+
+// Provider names accepted by -provider
+const (
+	providerFake     = "fake"
+	providerLive     = "live"
+	providerRecorded = "recorded"
+)
+
+// newProvider returns the text provider to evaluate against. With dir set, live responses are recorded there.
+func newProvider(name, dir string, record bool) (helpers.StoryTextProvider, error) {
+	switch name {
+	case providerFake:
+		return fake.NewText(), nil
+	case providerLive:
+		// Gemini writes stories in every language, as in production
+		var provider helpers.StoryTextProvider = gemini.NewGeminiStoryGenerationHelper()
+		if record {
+			provider = &recorder{provider: provider, dir: dir}
+		}
+		return provider, nil
+	case providerRecorded:
+		return &recording{dir: dir}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q, want %s, %s or %s", name, providerFake, providerLive, providerRecorded)
+}
+
+// recordingPath names the recording of a prompt by its hash, so recordings follow prompt changes
+func recordingPath(dir, prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".txt")
+}
+
+// storyPrompt renders the prompt a provider would send for a story
+func storyPrompt(theme, topic string, kwargs map[string]interface{}) (string, error) {
+	prompt, system, err := util.GenerateFormattedPrompt(theme, topic, kwargs)
+	if err != nil {
+		return "", err
+	}
+	return system + "\n" + prompt, nil
+}
+
+// recording answers prompts from responses saved by a live run with -record
+type recording struct {
+	dir string
+}
+
+func (r *recording) read(prompt string) (string, error) {
+	data, err := os.ReadFile(recordingPath(r.dir, prompt))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no recording for this prompt; run with -provider live -record to record it")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading recording: %v", err)
+	}
+	return string(data), nil
+}
+
+// CreateTopics returns the recorded topics, one per line
+func (r *recording) CreateTopics(prompt string) (*model.TopicResponse, error) {
+	data, err := r.read(prompt)
+	if err != nil {
+		return nil, err
+	}
+	var titles []string
+	for _, line := range strings.Split(data, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			titles = append(titles, line)
+		}
+	}
+	return &model.TopicResponse{Title: titles, Model: providerRecorded}, nil
+}
+
+// CreateStory returns the recorded story
+func (r *recording) CreateStory(theme, topic string, kwargs map[string]interface{}) (*model.StoryResponse, error) {
+	prompt, err := storyPrompt(theme, topic, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	story, err := r.read(prompt)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StoryResponse{Story: story, Model: providerRecorded}, nil
+}
+
+// recorder saves the responses of a provider for later runs with -provider recorded
+type recorder struct {
+	provider helpers.StoryTextProvider
+	dir      string
+}
+
+func (r *recorder) write(prompt, response string) error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating recordings directory: %v", err)
+	}
+	if err := os.WriteFile(recordingPath(r.dir, prompt), []byte(response), 0o644); err != nil {
+		return fmt.Errorf("error writing recording: %v", err)
+	}
+	return nil
+}
+
+// CreateTopics asks the provider for topics and records them
+func (r *recorder) CreateTopics(prompt string) (*model.TopicResponse, error) {
+	response, err := r.provider.CreateTopics(prompt)
+	if err != nil {
+		return nil, err
+	}
+	return response, r.write(prompt, strings.Join(response.Title, "\n"))
+}
+
+// CreateStory asks the provider for a story and records it
+func (r *recorder) CreateStory(theme, topic string, kwargs map[string]interface{}) (*model.StoryResponse, error) {
+	response, err := r.provider.CreateStory(theme, topic, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := storyPrompt(theme, topic, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return response, r.write(prompt, response.Story)
+}
@@ -25,12 +25,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"rio-go-model/configs"
+	"rio-go-model/configs/catalog"
 	"rio-go-model/docs"
 	"rio-go-model/internal/handlers"
+	"rio-go-model/internal/helpers"
 	"rio-go-model/internal/services"
+	"rio-go-model/internal/services/scheduler"
 	"rio-go-model/internal/util"
 
 	"github.com/gorilla/mux"
@@ -46,6 +51,8 @@ var emailHandler *handlers.Email
 var tcHandler *handlers.TcHandler
 var storyFeedbackHandler *handlers.StoryFeedbackHandler
 var pubSubHandler *handlers.PubSubHandler
+var adminHandler *handlers.AdminHandler
+var jobScheduler *scheduler.Scheduler
 
 // init initializes services and handlers
 func init() {
@@ -57,6 +64,13 @@ func init() {
 	// Initialize global settings first
 	configs.InitializeSettings()
 
+	// Load the topic and prompt catalogs; invalid files stop startup
+	settings := configs.GetSettings()
+	if err := catalog.Init(settings.CatalogDir); err != nil {
+		log.Fatalf("❌ Failed to load catalog: %v", err)
+	}
+	catalog.Watch(settings.CatalogReloadInterval)
+
 	// Create and initialize AppService
 	config := configs.LoadConfig()
 	appService := services.NewAppService(config)
@@ -81,6 +95,15 @@ func init() {
 	storyFeedbackHandler = handlers.NewStoryFeedbackHandler(appService.GetFirestore())
 	pubSubHandler = handlers.NewPubSubHandler(appService.GetFirestore())
 
+	// Register the scheduled jobs; they start with the server
+	jobScheduler = scheduler.NewScheduler(appService.GetFirestore())
+	for _, job := range helpers.MaintenanceJobs(appService.GetFirestore(), appService.GetStorage()) {
+		if err := jobScheduler.Register(job); err != nil {
+			log.Fatalf("❌ Failed to register job %s: %v", job.Name, err)
+		}
+	}
+	adminHandler = handlers.NewAdminHandler(appService.GetFirestore(), jobScheduler)
+
 	log.Println("✅ All handlers initialized successfully!")
 }
 
@@ -214,6 +237,7 @@ func main() {
 	api.Use(util.HTTPPanicRecoveryMiddleware)
 	api.HandleFunc("/story", storyTopicsHandler.CreateStory).Methods("POST")
 	api.HandleFunc("/stories", storyTopicsHandler.ListStories).Methods("GET")
+	api.HandleFunc("/stories/{id}/translations/{lang}", storyTopicsHandler.TranslateStory).Methods("POST")
 	api.HandleFunc("/reset-audio-by-theme-id", storyTopicsHandler.ResetAudioByThemeID).Methods("GET")
 	api.HandleFunc("/user-profile", storyTopicsHandler.UserProfile).Methods("GET")
 	api.HandleFunc("/user-profile", storyTopicsHandler.UpdateUserProfile).Methods("PUT")
@@ -224,6 +248,26 @@ func main() {
 	api.HandleFunc("/triggers/gemini/pubsub", pubSubHandler.PubSubPushGeminiHandler).Methods("POST")
 	api.HandleFunc("/triggers/audio/pubsub", pubSubHandler.PubSubPushAudioHandler).Methods("POST")
 
+	// Admin routes, restricted to ADMIN_EMAILS
+	adminRouter := api.PathPrefix("/admin").Subrouter()
+	adminRouter.HandleFunc("/costs", adminHandler.CostReport).Methods("GET")
+	adminRouter.HandleFunc("/quotas/{email}", adminHandler.GetUserQuota).Methods("GET")
+	adminRouter.HandleFunc("/quotas/{email}", adminHandler.SetUserQuota).Methods("PUT")
+	adminRouter.HandleFunc("/quotas/{email}", adminHandler.DeleteUserQuota).Methods("DELETE")
+	adminRouter.HandleFunc("/budget/policy", adminHandler.GetBudgetPolicy).Methods("GET")
+	adminRouter.HandleFunc("/budget/policy", adminHandler.SetBudgetPolicy).Methods("PUT")
+	adminRouter.HandleFunc("/budget/policy", adminHandler.DeleteBudgetPolicy).Methods("DELETE")
+	adminRouter.HandleFunc("/budget/evaluate", adminHandler.EvaluateBudget).Methods("GET")
+	adminRouter.HandleFunc("/prompts/registry", adminHandler.GetPromptRegistry).Methods("GET")
+	adminRouter.HandleFunc("/prompts/registry", adminHandler.SetPromptRegistry).Methods("PUT")
+	adminRouter.HandleFunc("/prompts/registry", adminHandler.DeletePromptRegistry).Methods("DELETE")
+	adminRouter.HandleFunc("/prompts/stats", adminHandler.PromptStats).Methods("GET")
+	adminRouter.HandleFunc("/catalog", adminHandler.GetCatalog).Methods("GET")
+	adminRouter.HandleFunc("/catalog/reload", adminHandler.ReloadCatalog).Methods("POST")
+	adminRouter.HandleFunc("/jobs", adminHandler.ListJobs).Methods("GET")
+	adminRouter.HandleFunc("/jobs/{name}/run", adminHandler.RunJob).Methods("POST")
+	adminRouter.HandleFunc("/tts/metrics", adminHandler.TTSMetrics).Methods("GET")
+
 	// Add the new authentication routes
 	authRouter := api.PathPrefix("/auth").Subrouter()
 	authRouter.HandleFunc("/google", authHandler.GoogleLogin).Methods("POST")
@@ -253,6 +297,9 @@ func main() {
 		Handler: c.Handler(r),
 	}
 
+	// Run scheduled jobs (no-op unless SCHEDULER_ENABLED is set)
+	jobScheduler.Start()
+
 	// Start server in a goroutine
 	go func() {
 		log.Printf("🌐 Starting server on :%s", port)
@@ -264,10 +311,17 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	// This would require adding signal handling, but for now we'll leave it simple
-	// In production, you'd use os.Signal and context for graceful shutdown
+	// Wait for an interrupt or termination signal, then stop scheduled jobs and drain requests
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	log.Printf("🛑 Received %s, shutting down...", sig)
 
-	// For now, just block
-	select {}
+	jobScheduler.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("❌ Server shutdown error: %v", err)
+	}
+	log.Println("✅ Server stopped")
 }
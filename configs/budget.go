@@ -0,0 +1,217 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Budget actions a tier can take
+const (
+	BudgetActionAllow     = "allow"     // use the provider as configured
+	BudgetActionDowngrade = "downgrade" // keep the provider with a cheaper voice or model
+	BudgetActionFallback  = "fallback"  // switch to the fallback provider
+)
+
+// Budget metrics a provider policy can be evaluated on
+const (
+	BudgetMetricCostRatio = "cost_ratio" // costAmount / budgetAmount of the last budget alert
+	BudgetMetricThreshold = "threshold"  // alertThresholdExceeded of the last budget alert
+)
+
+// BudgetTier is one step of a provider's budget policy
+type BudgetTier struct {
+	Name string `json:"name"`
+	// Until is the metric value the tier ends at. It is ignored on the last tier.
+	Until float64 `json:"until,omitempty"`
+	// Inclusive keeps the tier when the metric equals Until
+	Inclusive bool   `json:"inclusive,omitempty"`
+	Action    string `json:"action"`
+	// Voice is the voice type used in the tier. A tier without one keeps the voice of the tier before it,
+	// since languages without a fallback narrator still read with Google TTS past the fallback point.
+	Voice string `json:"voice,omitempty"`
+}
+
+// ProviderBudgetPolicy is the tier ladder of one provider
+type ProviderBudgetPolicy struct {
+	Metric string       `json:"metric"`
+	Tiers  []BudgetTier `json:"tiers"`
+	// Fallback names the provider used by the fallback action
+	Fallback string `json:"fallback,omitempty"`
+	// IgnoreAlertsBelow is the cost ratio under which budget alerts are not recorded
+	IgnoreAlertsBelow float64 `json:"ignore_alerts_below,omitempty"`
+}
+
+// BudgetPolicy maps each provider to its tiers. Usage is reset every month on ResetDay (UTC).
+type BudgetPolicy struct {
+	ResetDay  int                             `json:"reset_day"`
+	Providers map[string]ProviderBudgetPolicy `json:"providers"`
+}
+
+// BudgetUsage is the spend recorded from the last budget alert of a provider
+type BudgetUsage struct {
+	CostAmount   float64   `json:"cost_amount"`
+	BudgetAmount float64   `json:"budget_amount"`
+	Threshold    float64   `json:"threshold"`
+	ResetAt      time.Time `json:"reset_at"`
+}
+
+// BudgetDecision is the tier a provider is in and what callers should do
+type BudgetDecision struct {
+	Provider string    `json:"provider"`
+	Metric   string    `json:"metric"`
+	Value    float64   `json:"value"`
+	Tier     string    `json:"tier"`
+	Action   string    `json:"action"`
+	Voice    string    `json:"voice,omitempty"`
+	Fallback string    `json:"fallback,omitempty"`
+	ResetAt  time.Time `json:"reset_at"`
+	// Expired is set when the usage belongs to a past period and was evaluated as zero
+	Expired bool `json:"expired"`
+}
+
+// defaultBudgetPolicy keeps the limits the service has always applied:
+// Gemini falls back to Hugging Face at 60% of its budget, Google TTS uses
+// Chirp3 voices until the 25% alert, Standard voices until 88%, then fal.ai,
+// or Standard voices for languages fal.ai cannot read.
+func defaultBudgetPolicy() *BudgetPolicy {
+	return &BudgetPolicy{
+		ResetDay: 2,
+		Providers: map[string]ProviderBudgetPolicy{
+			"gemini": {
+				Metric:            BudgetMetricCostRatio,
+				Fallback:          "huggingface",
+				IgnoreAlertsBelow: 0.9,
+				Tiers: []BudgetTier{
+					{Name: "normal", Until: 0.6, Action: BudgetActionAllow},
+					{Name: "fallback", Action: BudgetActionFallback},
+				},
+			},
+			"audio": {
+				Metric:   BudgetMetricThreshold,
+				Fallback: "falai",
+				Tiers: []BudgetTier{
+					{Name: "premium", Until: 0.25, Inclusive: true, Action: BudgetActionAllow, Voice: "Chirp3"},
+					{Name: "standard", Until: 0.88, Action: BudgetActionDowngrade, Voice: "Standard"},
+					{Name: "fallback", Action: BudgetActionFallback, Voice: "Standard"},
+				},
+			},
+		},
+	}
+}
+
+// initBudgetPolicy returns the built-in budget policy, or BUDGET_POLICY when it holds a valid policy as JSON
+func initBudgetPolicy() *BudgetPolicy {
+	raw := os.Getenv("BUDGET_POLICY")
+	if raw == "" {
+		return defaultBudgetPolicy()
+	}
+	policy, err := ParseBudgetPolicy([]byte(raw))
+	if err != nil {
+		log.Printf("Warning: Ignoring invalid BUDGET_POLICY: %v", err)
+		return defaultBudgetPolicy()
+	}
+	return policy
+}
+
+// ParseBudgetPolicy decodes and validates a JSON budget policy
+func ParseBudgetPolicy(data []byte) (*BudgetPolicy, error) {
+	var policy BudgetPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("error decoding budget policy: %v", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks the reset day, metrics, actions and that tier bounds increase
+func (p *BudgetPolicy) Validate() error {
+	if p.ResetDay < 1 || p.ResetDay > 28 {
+		return fmt.Errorf("reset_day must be between 1 and 28, got %d", p.ResetDay)
+	}
+	for provider, pp := range p.Providers {
+		if pp.Metric != BudgetMetricCostRatio && pp.Metric != BudgetMetricThreshold {
+			return fmt.Errorf("provider %s: unknown metric %q", provider, pp.Metric)
+		}
+		if len(pp.Tiers) == 0 {
+			return fmt.Errorf("provider %s: at least one tier is required", provider)
+		}
+		for i, tier := range pp.Tiers {
+			if tier.Name == "" {
+				return fmt.Errorf("provider %s: tier %d has no name", provider, i)
+			}
+			switch tier.Action {
+			case BudgetActionAllow, BudgetActionDowngrade, BudgetActionFallback:
+			default:
+				return fmt.Errorf("provider %s: tier %s has unknown action %q", provider, tier.Name, tier.Action)
+			}
+			if i == len(pp.Tiers)-1 {
+				continue
+			}
+			if tier.Until <= 0 || (i > 0 && tier.Until <= pp.Tiers[i-1].Until) {
+				return fmt.Errorf("provider %s: tier %s must end above the previous tier", provider, tier.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// NextReset returns the reset day of the month after now, at 00:00:00 UTC
+func (p *BudgetPolicy) NextReset(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()+1, p.ResetDay, 0, 0, 0, 0, time.UTC)
+}
+
+// Evaluate returns the tier a provider is in for the given usage.
+// Usage whose reset time has passed counts as zero. Providers without a policy are always allowed.
+func (p *BudgetPolicy) Evaluate(provider string, usage BudgetUsage, now time.Time) BudgetDecision {
+	decision := BudgetDecision{Provider: provider, ResetAt: usage.ResetAt, Action: BudgetActionAllow, Tier: "unconfigured"}
+	pp, ok := p.Providers[provider]
+	if !ok {
+		return decision
+	}
+	decision.Metric = pp.Metric
+	decision.Fallback = pp.Fallback
+
+	if !usage.ResetAt.IsZero() && now.After(usage.ResetAt) {
+		decision.Expired = true
+	} else {
+		decision.Value = pp.metricValue(usage)
+	}
+	for i, tier := range pp.Tiers {
+		if tier.Voice != "" {
+			decision.Voice = tier.Voice
+		}
+		last := i == len(pp.Tiers)-1
+		if last || decision.Value < tier.Until || (tier.Inclusive && decision.Value == tier.Until) {
+			decision.Tier = tier.Name
+			decision.Action = tier.Action
+			break
+		}
+	}
+	return decision
+}
+
+// IgnoreAlert reports whether a budget alert is below the level the provider records
+func (p *BudgetPolicy) IgnoreAlert(provider string, cost, budget float64) bool {
+	pp, ok := p.Providers[provider]
+	if !ok || budget <= 0 {
+		return false
+	}
+	return cost < pp.IgnoreAlertsBelow*budget
+}
+
+// metricValue reads the provider's metric from usage
+func (pp ProviderBudgetPolicy) metricValue(usage BudgetUsage) float64 {
+	if pp.Metric == BudgetMetricThreshold {
+		return usage.Threshold
+	}
+	if usage.BudgetAmount <= 0 {
+		return 0
+	}
+	return usage.CostAmount / usage.BudgetAmount
+}
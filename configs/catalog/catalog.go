@@ -0,0 +1,327 @@
+// Package catalog holds the story topics, preferences and prompt texts of each language.
+// They are compiled in from the language packs, or loaded from a directory of YAML/JSON files
+// that can be edited and reloaded without a deploy.
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"rio-go-model/configs/languages"
+)
+
+// Theme names shared by topic lists, story prompts and topic prompts
+const (
+	PlanetProtector = languages.PlanetProtector
+	Mindful         = languages.Mindful
+	Chill           = languages.Chill
+)
+
+// DefaultLanguage is used for languages without a catalog
+const DefaultLanguage = languages.Default
+
+// Themes lists every theme a catalog must cover
+var Themes = languages.Themes
+
+// storyPlaceholders are the fields each story prompt must use
+var storyPlaceholders = map[string][]string{
+	PlanetProtector: {"{{.Topic}}", "{{.Country}}", "{{.City}}"},
+	Mindful:         {"{{.Topic}}", "{{.Religion}}"},
+	Chill:           {"{{.Topic}}"},
+}
+
+// topicPlaceholders are the fields each topic prompt must use
+var topicPlaceholders = map[string][]string{
+	PlanetProtector: {"{{.Items}}", "{{.Count}}"},
+	Mindful:         {"{{.Items}}", "{{.Religion}}", "{{.Count}}"},
+	Chill:           {"{{.Items}}", "{{.Count}}"},
+}
+
+// templateFuncs are available in every prompt
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Topics are the subjects topic prompts pick from, per theme
+type Topics = languages.Topics
+
+// Prompt is the system message and prompt of a story
+type Prompt struct {
+	System string `json:"system" yaml:"system"`
+	Prompt string `json:"prompt" yaml:"prompt"`
+}
+
+// Language is the catalog of one language
+type Language struct {
+	Language string `json:"language" yaml:"language"`
+	Topics   Topics `json:"topics" yaml:"topics"`
+	// Preferences maps an upper-case preference to the text appended to story prompts
+	Preferences map[string]string `json:"preferences" yaml:"preferences"`
+	// Prompts are the story prompts per theme, rendered with StoryVars
+	Prompts map[string]Prompt `json:"prompts,omitempty" yaml:"prompts,omitempty"`
+	// TopicPrompts ask for a list of topics per theme, rendered with TopicVars
+	TopicPrompts map[string]string `json:"topic_prompts,omitempty" yaml:"topic_prompts,omitempty"`
+}
+
+// StoryVars are the values a story prompt can refer to
+type StoryVars = languages.StoryVars
+
+// TopicVars are the values a topic prompt can refer to
+type TopicVars = languages.TopicVars
+
+// Catalog is the content of every language
+type Catalog struct {
+	Languages map[string]*Language `json:"languages"`
+	// Source is the directory the catalog was loaded from, or "builtin"
+	Source   string    `json:"source"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// Builtin returns the catalog compiled into the language packs. Its prompts are left to the
+// prompt functions of the packs.
+func Builtin() *Catalog {
+	c := &Catalog{Languages: make(map[string]*Language), Source: "builtin", LoadedAt: time.Now()}
+	for _, pack := range languages.All() {
+		c.Languages[pack.Name()] = &Language{
+			Language:    pack.Name(),
+			Topics:      pack.Topics(),
+			Preferences: pack.Preferences(),
+		}
+	}
+	return c
+}
+
+// Language returns the catalog of a language, or of DefaultLanguage when it has none
+func (c *Catalog) Language(language string) *Language {
+	if l, ok := c.Languages[language]; ok {
+		return l
+	}
+	return c.Languages[DefaultLanguage]
+}
+
+// TopicList returns the topics of a theme; for mindful stories, those of the religion
+func (l *Language) TopicList(theme, religion string) []string {
+	switch theme {
+	case PlanetProtector:
+		return l.Topics.PlanetProtector
+	case Mindful:
+		return l.Topics.Mindful[religion]
+	case Chill:
+		return l.Topics.Chill
+	}
+	return nil
+}
+
+// Preference returns the text appended to story prompts for a preference
+func (l *Language) Preference(preference string) string {
+	return l.Preferences[strings.ToUpper(preference)]
+}
+
+// RenderPrompt renders the story prompt of a theme. ok is false when the catalog has no
+// prompt texts, so the compiled-in prompt should be used.
+func (l *Language) RenderPrompt(theme string, vars StoryVars) (system, prompt string, ok bool, err error) {
+	p, ok := l.Prompts[theme]
+	if !ok {
+		return "", "", false, nil
+	}
+	if system, err = render(p.System, vars); err != nil {
+		return "", "", true, fmt.Errorf("error rendering %s system of %s: %v", theme, l.Language, err)
+	}
+	if prompt, err = render(p.Prompt, vars); err != nil {
+		return "", "", true, fmt.Errorf("error rendering %s prompt of %s: %v", theme, l.Language, err)
+	}
+	return system, prompt, true, nil
+}
+
+// RenderTopicPrompt renders the topic prompt of a theme. ok is false when the catalog has
+// no topic prompts, so the compiled-in prompt should be used.
+func (l *Language) RenderTopicPrompt(theme string, vars TopicVars) (prompt string, ok bool, err error) {
+	text, ok := l.TopicPrompts[theme]
+	if !ok {
+		return "", false, nil
+	}
+	if prompt, err = render(text, vars); err != nil {
+		return "", true, fmt.Errorf("error rendering %s topic prompt of %s: %v", theme, l.Language, err)
+	}
+	return prompt, true, nil
+}
+
+// Validate checks every language on its own and that languages agree with each other:
+// same religions, same preferences and the same prompts
+func (c *Catalog) Validate() error {
+	if len(c.Languages) == 0 {
+		return fmt.Errorf("catalog has no languages")
+	}
+	if _, ok := c.Languages[DefaultLanguage]; !ok {
+		return fmt.Errorf("catalog has no %s language", DefaultLanguage)
+	}
+	names := make([]string, 0, len(c.Languages))
+	for name := range c.Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reference := c.Languages[DefaultLanguage]
+	var problems []string
+	for _, name := range names {
+		l := c.Languages[name]
+		if err := languages.Validate(name); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+		problems = append(problems, l.validate()...)
+		if name == DefaultLanguage {
+			continue
+		}
+		if diff := keyDiff(reference.Topics.Mindful, l.Topics.Mindful); diff != "" {
+			problems = append(problems, fmt.Sprintf("%s: mindful religions differ from %s: %s", name, DefaultLanguage, diff))
+		}
+		if diff := keyDiff(reference.Preferences, l.Preferences); diff != "" {
+			problems = append(problems, fmt.Sprintf("%s: preferences differ from %s: %s", name, DefaultLanguage, diff))
+		}
+		if (len(reference.Prompts) == 0) != (len(l.Prompts) == 0) || (len(reference.TopicPrompts) == 0) != (len(l.TopicPrompts) == 0) {
+			problems = append(problems, fmt.Sprintf("%s: prompts must be given for every language or none", name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid catalog: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validate checks that a language has topics for every theme and that its prompts
+// parse and use the placeholders their theme needs
+func (l *Language) validate() []string {
+	var problems []string
+	if len(l.Topics.PlanetProtector) == 0 {
+		problems = append(problems, l.Language+": no planet_protector topics")
+	}
+	if len(l.Topics.Chill) == 0 {
+		problems = append(problems, l.Language+": no chill topics")
+	}
+	if len(l.Topics.Mindful) == 0 {
+		problems = append(problems, l.Language+": no mindful religions")
+	}
+	for religion, topics := range l.Topics.Mindful {
+		if len(topics) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: no mindful topics for %s", l.Language, religion))
+		}
+	}
+	for key := range l.Preferences {
+		if key != strings.ToUpper(key) {
+			problems = append(problems, fmt.Sprintf("%s: preference %q must be upper case", l.Language, key))
+		}
+	}
+	if len(l.Prompts) > 0 {
+		for _, theme := range Themes {
+			p, ok := l.Prompts[theme]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: no %s prompt", l.Language, theme))
+				continue
+			}
+			problems = append(problems, checkText(l.Language, theme+" system", p.System, nil, StoryVars{})...)
+			problems = append(problems, checkText(l.Language, theme+" prompt", p.Prompt, storyPlaceholders[theme], StoryVars{})...)
+		}
+	}
+	if len(l.TopicPrompts) > 0 {
+		for _, theme := range Themes {
+			text, ok := l.TopicPrompts[theme]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: no %s topic prompt", l.Language, theme))
+				continue
+			}
+			problems = append(problems, checkText(l.Language, theme+" topic prompt", text, topicPlaceholders[theme], TopicVars{})...)
+		}
+	}
+	return problems
+}
+
+// checkText reports a prompt that is empty, does not render or misses a required placeholder
+func checkText(language, what, text string, required []string, vars interface{}) []string {
+	if strings.TrimSpace(text) == "" {
+		return []string{fmt.Sprintf("%s: %s is empty", language, what)}
+	}
+	var problems []string
+	if _, err := render(text, vars); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %s: %v", language, what, err))
+	}
+	for _, placeholder := range required {
+		if !strings.Contains(text, placeholder) {
+			problems = append(problems, fmt.Sprintf("%s: %s must use %s", language, what, placeholder))
+		}
+	}
+	return problems
+}
+
+func render(text string, vars interface{}) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// keyDiff describes the keys present in only one of two maps
+func keyDiff[V any](want, got map[string]V) string {
+	var missing, extra []string
+	for key := range want {
+		if _, ok := got[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			extra = append(extra, key)
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return ""
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	return fmt.Sprintf("missing %v, extra %v", missing, extra)
+}
+
+// LanguageSummary counts the content of one language
+type LanguageSummary struct {
+	// Topics counts the topics per theme; mindful topics are counted per religion as mindful/<religion>
+	Topics      map[string]int `json:"topics"`
+	Preferences int            `json:"preferences"`
+	// Prompts is set when the catalog carries its own prompt texts
+	Prompts bool `json:"prompts"`
+}
+
+// Summary describes a catalog without its texts
+type Summary struct {
+	Source    string                     `json:"source"`
+	LoadedAt  time.Time                  `json:"loaded_at"`
+	Languages map[string]LanguageSummary `json:"languages"`
+}
+
+// Summary counts the content of every language
+func (c *Catalog) Summary() Summary {
+	summary := Summary{Source: c.Source, LoadedAt: c.LoadedAt, Languages: make(map[string]LanguageSummary, len(c.Languages))}
+	for name, l := range c.Languages {
+		topics := map[string]int{
+			PlanetProtector: len(l.Topics.PlanetProtector),
+			Chill:           len(l.Topics.Chill),
+		}
+		for religion, list := range l.Topics.Mindful {
+			topics[Mindful+"/"+religion] = len(list)
+		}
+		summary.Languages[name] = LanguageSummary{
+			Topics:      topics,
+			Preferences: len(l.Preferences),
+			Prompts:     len(l.Prompts) > 0,
+		}
+	}
+	return summary
+}
@@ -0,0 +1,193 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	mu      sync.RWMutex
+	current = Builtin()
+	dir     string
+	// modTimes are the modification times of the files the current catalog was loaded from
+	modTimes map[string]time.Time
+)
+
+// Current returns the catalog in use
+func Current() *Catalog {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Init loads the catalog from a directory, or keeps the built-in one when directory is empty.
+// It fails when the files are invalid, so a broken catalog stops startup.
+func Init(directory string) error {
+	mu.Lock()
+	dir = directory
+	mu.Unlock()
+	if directory == "" {
+		return nil
+	}
+	_, err := Reload()
+	return err
+}
+
+// Reload reads the catalog directory again. An invalid catalog is rejected and the current one kept.
+func Reload() (*Catalog, error) {
+	mu.RLock()
+	directory := dir
+	mu.RUnlock()
+	if directory == "" {
+		return Current(), nil
+	}
+
+	loaded, times, err := LoadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	current = loaded
+	modTimes = times
+	mu.Unlock()
+	log.Printf("Loaded catalog from %s with languages %s", directory, strings.Join(loaded.languageNames(), ", "))
+	return loaded, nil
+}
+
+// Watch reloads the catalog whenever a file in its directory changes, checking every interval
+func Watch(interval time.Duration) {
+	mu.RLock()
+	directory := dir
+	mu.RUnlock()
+	if directory == "" || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			times, err := catalogFiles(directory)
+			if err != nil {
+				log.Printf("Warning: cannot read catalog directory %s: %v", directory, err)
+				continue
+			}
+			mu.RLock()
+			changed := !sameModTimes(times, modTimes)
+			mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if _, err := Reload(); err != nil {
+				log.Printf("Warning: keeping current catalog, reload failed: %v", err)
+				// Remember the broken files so the same error is not logged every tick
+				mu.Lock()
+				modTimes = times
+				mu.Unlock()
+			}
+		}
+	}()
+}
+
+// LoadDir reads every .yaml, .yml and .json file of a directory, one language per file,
+// and validates the result. It also returns the modification time of each file.
+func LoadDir(directory string) (*Catalog, map[string]time.Time, error) {
+	times, err := catalogFiles(directory)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(times) == 0 {
+		return nil, nil, fmt.Errorf("no catalog files in %s", directory)
+	}
+	c := &Catalog{Languages: make(map[string]*Language), Source: directory, LoadedAt: time.Now()}
+	paths := make([]string, 0, len(times))
+	for path := range times {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		l, err := loadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := c.Languages[l.Language]; ok {
+			return nil, nil, fmt.Errorf("%s: language %s is defined twice", path, l.Language)
+		}
+		c.Languages[l.Language] = l
+	}
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, times, nil
+}
+
+func loadFile(path string) (*Language, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	var l Language
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &l)
+	} else {
+		err = yaml.Unmarshal(data, &l)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", path, err)
+	}
+	if l.Language == "" {
+		return nil, fmt.Errorf("%s: language is required", path)
+	}
+	return &l, nil
+}
+
+// catalogFiles returns the catalog files of a directory with their modification times
+func catalogFiles(directory string) (map[string]time.Time, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("error reading catalog directory: %v", err)
+	}
+	times := make(map[string]time.Time)
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		times[filepath.Join(directory, entry.Name())] = info.ModTime()
+	}
+	return times, nil
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, t := range a {
+		if !b[path].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Catalog) languageNames() []string {
+	names := make([]string, 0, len(c.Languages))
+	for name := range c.Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
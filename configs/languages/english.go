@@ -0,0 +1,71 @@
+package languages
+
+import (
+	"unicode"
+
+	"rio-go-model/configs/english"
+)
+
+func init() {
+	Register(englishPack{})
+}
+
+// englishPack writes stories with either text provider and narrates mindful stories in Indian English
+type englishPack struct{}
+
+func (englishPack) Name() string                { return "English" }
+func (englishPack) Code() string                { return "en" }
+func (englishPack) Script() *unicode.RangeTable { return unicode.Latin }
+
+func (englishPack) Topics() Topics {
+	return Topics{
+		PlanetProtector: english.GetPlanetProtectorList(),
+		Mindful:         english.MindfulStoriesList(),
+		Chill:           english.ChillStoriesList(),
+	}
+}
+
+func (englishPack) Preferences() map[string]string { return english.Preferences() }
+
+func (englishPack) StoryPrompt(theme string, vars StoryVars) (string, string) {
+	var cfg english.PromptEngineConfig
+	switch theme {
+	case PlanetProtector:
+		cfg = english.PlanetProtectorPromptConfig(vars.Topic, vars.Country, vars.City)
+	case Mindful:
+		cfg = english.MindfulStoriesPromptConfig(vars.Topic, vars.Religion)
+	case Chill:
+		cfg = english.ChillStoriesPromptConfig(vars.Topic)
+	}
+	return cfg.System, cfg.Prompt
+}
+
+func (englishPack) TopicPrompt(theme string, vars TopicVars) string {
+	switch theme {
+	case PlanetProtector:
+		return english.SuperPlanetProtectorPrompt(vars.Items, vars.Preference, vars.Count)
+	case Mindful:
+		return english.SuperMindfulStoriesPrompt(vars.Items, vars.Religion, vars.Count)
+	case Chill:
+		return english.SuperChillStoriesPrompt(vars.Items, vars.Preference, vars.Count)
+	}
+	return ""
+}
+
+// TTSLocale narrates mindful stories (theme 2) with Indian voices
+func (englishPack) TTSLocale(theme string) string {
+	if theme == "2" {
+		return "en-IN"
+	}
+	return "en-US"
+}
+
+func (englishPack) Voices(voiceType string) []string { return nil }
+func (englishPack) SSMLBuilder() string              { return SSMLBuilderEnglish }
+func (englishPack) FallbackAudio() bool              { return true }
+func (englishPack) Romanization() string             { return "" }
+func (englishPack) TranslateForImages() bool         { return false }
+
+func (englishPack) TextProviders() []string {
+	return []string{ProviderGemini, ProviderHuggingFace}
+}
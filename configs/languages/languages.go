@@ -0,0 +1,173 @@
+// Package languages defines the language packs the service can write and narrate stories in.
+// Each pack bundles the built-in prompts and topic catalog of a language with how its stories are
+// narrated and illustrated, so adding a language means adding one pack and registering it.
+package languages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Theme names shared by topic lists, story prompts and topic prompts
+const (
+	PlanetProtector = "planet_protector"
+	Mindful         = "mindful"
+	Chill           = "chill"
+)
+
+// Themes lists every theme a pack must cover
+var Themes = []string{PlanetProtector, Mindful, Chill}
+
+// Default is the language used when a request does not name one
+const Default = "English"
+
+// Text providers a pack can allow
+const (
+	ProviderGemini      = "gemini"
+	ProviderHuggingFace = "huggingface"
+)
+
+// Romanization schemes a pack can name
+const (
+	// RomanizationTeluguISO15919 transliterates Telugu script to ISO 15919
+	RomanizationTeluguISO15919 = "telugu-iso15919"
+)
+
+// SSML builders a pack can name
+const (
+	// SSMLBuilderEnglish adds sentence and paragraph breaks, dialogue prosody, emphasis and a bedtime slow-down
+	SSMLBuilderEnglish = "english-expressive"
+	// SSMLBuilderTelugu adds emotion prosody, pauses and stress to Telugu script
+	SSMLBuilderTelugu = "telugu-expressive"
+)
+
+// Topics are the subjects topic prompts pick from, per theme
+type Topics struct {
+	PlanetProtector []string `json:"planet_protector" yaml:"planet_protector"`
+	// Mindful maps a religion to its scriptures
+	Mindful map[string][]string `json:"mindful" yaml:"mindful"`
+	Chill   []string            `json:"chill" yaml:"chill"`
+}
+
+// StoryVars are the values a story prompt can refer to
+type StoryVars struct {
+	Topic    string
+	Country  string
+	City     string
+	Religion string
+}
+
+// TopicVars are the values a topic prompt can refer to
+type TopicVars struct {
+	Items      string
+	Religion   string
+	Preference string
+	Count      int
+}
+
+// LanguagePack is everything needed to write, narrate and illustrate stories in one language
+type LanguagePack interface {
+	// Name is the language as clients send it, e.g. "Telugu"
+	Name() string
+	// Code is the ISO 639-1 code of the language, as the translator expects it
+	Code() string
+	// Script is the Unicode script stories are written in
+	Script() *unicode.RangeTable
+
+	// Topics and Preferences are the built-in topic catalog; a loaded catalog file replaces them
+	Topics() Topics
+	// Preferences maps an upper-case preference to the text appended to story prompts
+	Preferences() map[string]string
+	// StoryPrompt returns the built-in system message and prompt of a theme
+	StoryPrompt(theme string, vars StoryVars) (system, prompt string)
+	// TopicPrompt returns the built-in prompt asking for a list of topics of a theme
+	TopicPrompt(theme string, vars TopicVars) string
+
+	// TTSLocale is the Google TTS language code for stories of a theme id ("1", "2" or "3")
+	TTSLocale(theme string) string
+	// Voices returns the voice name suffixes of a voice type, or nil to use the lists in Settings
+	Voices(voiceType string) []string
+	// SSMLBuilder names the SSML builder for narration; empty sends plain text
+	SSMLBuilder() string
+	// FallbackAudio reports whether the English-only fallback narrator may read the language
+	FallbackAudio() bool
+	// Romanization names the scheme stories are transliterated to Roman script with; empty for Roman-script languages
+	Romanization() string
+
+	// TranslateForImages reports whether topics and scenes are translated to English before drawing
+	TranslateForImages() bool
+	// TextProviders lists the text providers that can write the language, most preferred first
+	TextProviders() []string
+}
+
+var (
+	mu    sync.RWMutex
+	packs = make(map[string]LanguagePack)
+)
+
+// Register adds a pack, replacing any pack of the same name
+func Register(pack LanguagePack) {
+	mu.Lock()
+	defer mu.Unlock()
+	packs[pack.Name()] = pack
+}
+
+// Get returns the pack of a language
+func Get(language string) (LanguagePack, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	pack, ok := packs[language]
+	return pack, ok
+}
+
+// Lookup returns the pack of a language, or the Default pack for unsupported languages
+func Lookup(language string) LanguagePack {
+	if pack, ok := Get(language); ok {
+		return pack
+	}
+	pack, _ := Get(Default)
+	return pack
+}
+
+// All returns every registered pack, sorted by name
+func All() []LanguagePack {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make([]LanguagePack, 0, len(packs))
+	for _, pack := range packs {
+		all = append(all, pack)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// Names returns the names of every registered pack, sorted
+func Names() []string {
+	all := All()
+	names := make([]string, len(all))
+	for i, pack := range all {
+		names[i] = pack.Name()
+	}
+	return names
+}
+
+// Validate returns an error naming the supported languages when language has no pack
+func Validate(language string) error {
+	if _, ok := Get(language); !ok {
+		return fmt.Errorf("unsupported language %q, supported languages are %s", language, strings.Join(Names(), ", "))
+	}
+	return nil
+}
+
+// AllowsProvider reports whether a pack's language can be written by a text provider
+func AllowsProvider(pack LanguagePack, provider string) bool {
+	for _, p := range pack.TextProviders() {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package languages
+
+import (
+	"unicode"
+
+	"rio-go-model/configs/telugu"
+)
+
+func init() {
+	Register(teluguPack{})
+}
+
+// teluguPack is written by Gemini only, narrated by Google TTS only and illustrated from English translations
+type teluguPack struct{}
+
+func (teluguPack) Name() string                { return "Telugu" }
+func (teluguPack) Code() string                { return "te" }
+func (teluguPack) Script() *unicode.RangeTable { return unicode.Telugu }
+
+func (teluguPack) Topics() Topics {
+	return Topics{
+		PlanetProtector: telugu.GetPlanetProtectorList(),
+		Mindful:         telugu.MindfulStoriesList(),
+		Chill:           telugu.ChillStoriesList(),
+	}
+}
+
+func (teluguPack) Preferences() map[string]string { return telugu.Preferences() }
+
+func (teluguPack) StoryPrompt(theme string, vars StoryVars) (string, string) {
+	var cfg telugu.PromptEngineConfig
+	switch theme {
+	case PlanetProtector:
+		cfg = telugu.PlanetProtectorPromptConfig(vars.Topic, vars.Country, vars.City)
+	case Mindful:
+		cfg = telugu.MindfulStoriesPromptConfig(vars.Topic, vars.Religion)
+	case Chill:
+		cfg = telugu.ChillStoriesPromptConfig(vars.Topic)
+	}
+	return cfg.System, cfg.Prompt
+}
+
+func (teluguPack) TopicPrompt(theme string, vars TopicVars) string {
+	switch theme {
+	case PlanetProtector:
+		return telugu.SuperPlanetProtectorPrompt(vars.Items, vars.Preference, vars.Count)
+	case Mindful:
+		return telugu.SuperMindfulStoriesPrompt(vars.Items, vars.Religion, vars.Count)
+	case Chill:
+		return telugu.SuperChillStoriesPrompt(vars.Items, vars.Preference, vars.Count)
+	}
+	return ""
+}
+
+func (teluguPack) TTSLocale(theme string) string    { return "te-IN" }
+func (teluguPack) Voices(voiceType string) []string { return nil }
+
+// SSMLBuilder is empty while the Telugu stress rules slow down nearly every word;
+// SSMLBuilderTelugu turns the Telugu builder on once they are tuned
+func (teluguPack) SSMLBuilder() string      { return "" }
+func (teluguPack) FallbackAudio() bool      { return false }
+func (teluguPack) Romanization() string     { return RomanizationTeluguISO15919 }
+func (teluguPack) TranslateForImages() bool { return true }
+
+func (teluguPack) TextProviders() []string {
+	return []string{ProviderGemini}
+}
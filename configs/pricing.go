@@ -0,0 +1,58 @@
+package configs
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+// defaultPriceTable holds the estimated USD cost of one unit per "provider/model".
+// Units are tokens for LLMs, characters for TTS and images for image models.
+// A "provider/*" entry prices every model of a provider without its own entry.
+var defaultPriceTable = map[string]float64{
+	"gemini/gemini-2.5-flash-lite":                  0.40 / 1e6,
+	"gemini/gemini-2.0-flash-lite":                  0.30 / 1e6,
+	"gemini/gemini-2.5-flash-image":                 0.039,
+	"gemini/*":                                      0.40 / 1e6,
+	"huggingface/Qwen/Qwen2.5-7B-Instruct:together": 0.30 / 1e6,
+	"huggingface/openai/gpt-oss-120b:together":      0.60 / 1e6,
+	"huggingface/*":                                 0.60 / 1e6,
+	"flux/black-forest-labs/FLUX.1-dev":             0.025,
+	"google-tts/Chirp3":                             30.0 / 1e6,
+	"google-tts/Standard":                           4.0 / 1e6,
+	"google-tts/*":                                  16.0 / 1e6,
+	"falai/kokoro/american-english":                 20.0 / 1e6,
+	"falai/*":                                       20.0 / 1e6,
+	"google-translate/*":                            20.0 / 1e6,
+}
+
+// initPriceTable returns the default price table with overrides from COST_PRICE_TABLE,
+// a JSON object of "provider/model" to USD per unit
+func initPriceTable() map[string]float64 {
+	table := make(map[string]float64, len(defaultPriceTable))
+	for key, price := range defaultPriceTable {
+		table[key] = price
+	}
+	raw := os.Getenv("COST_PRICE_TABLE")
+	if raw == "" {
+		return table
+	}
+	var overrides map[string]float64
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		log.Printf("Warning: Ignoring invalid COST_PRICE_TABLE: %v", err)
+		return table
+	}
+	for key, price := range overrides {
+		table[key] = price
+	}
+	return table
+}
+
+// EstimateCost returns the estimated USD cost of units of a provider model
+func (s *Settings) EstimateCost(provider, model string, units int64) float64 {
+	price, ok := s.PriceTable[provider+"/"+model]
+	if !ok {
+		price = s.PriceTable[provider+"/*"]
+	}
+	return price * float64(units)
+}
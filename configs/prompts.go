@@ -0,0 +1,337 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"text/template"
+
+	"rio-go-model/configs/catalog"
+	"rio-go-model/configs/languages"
+)
+
+// Story prompt template names, one per theme
+const (
+	PromptPlanetProtector = catalog.PlanetProtector
+	PromptMindful         = catalog.Mindful
+	PromptChill           = catalog.Chill
+)
+
+// BuiltinPromptVersion is the version of the prompts compiled into the service
+const BuiltinPromptVersion = "v1"
+
+// Experiment units: a user sees the same variant for every story, or each story is assigned on its own
+const (
+	ExperimentUnitUser  = "user"
+	ExperimentUnitStory = "story"
+)
+
+// themePrompts maps a story theme to its prompt template name
+var themePrompts = map[string]string{
+	"1": PromptPlanetProtector,
+	"2": PromptMindful,
+	"3": PromptChill,
+}
+
+// PromptTemplateName returns the template used for stories of a theme
+func PromptTemplateName(theme string) string {
+	return themePrompts[theme]
+}
+
+// PromptVars are the values a story prompt can refer to, e.g. {{.Topic}}
+type PromptVars struct {
+	Topic    string
+	Country  string
+	City     string
+	Religion string
+	Language string
+}
+
+// PromptTemplate is one version of a named story prompt in one language.
+// System and Prompt are text/template strings rendered with PromptVars.
+type PromptTemplate struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Version  string `json:"version"`
+	System   string `json:"system"`
+	Prompt   string `json:"prompt"`
+
+	builtin func(PromptVars) (system, prompt string)
+}
+
+// Builtin reports whether the template is compiled into the service
+func (t *PromptTemplate) Builtin() bool {
+	return t.builtin != nil
+}
+
+// Render fills the template with vars and returns the system message and prompt
+func (t *PromptTemplate) Render(vars PromptVars) (string, string, error) {
+	if t.builtin != nil {
+		// A loaded catalog replaces the compiled-in text of the built-in version
+		if l, ok := catalog.Current().Languages[t.Language]; ok {
+			system, prompt, ok, err := l.RenderPrompt(t.Name, catalog.StoryVars{
+				Topic:    vars.Topic,
+				Country:  vars.Country,
+				City:     vars.City,
+				Religion: vars.Religion,
+			})
+			if ok {
+				return system, prompt, err
+			}
+		}
+		system, prompt := t.builtin(vars)
+		return system, prompt, nil
+	}
+	system, err := renderPromptText(t.System, vars)
+	if err != nil {
+		return "", "", fmt.Errorf("error rendering system of %s: %v", t.key(), err)
+	}
+	prompt, err := renderPromptText(t.Prompt, vars)
+	if err != nil {
+		return "", "", fmt.Errorf("error rendering prompt of %s: %v", t.key(), err)
+	}
+	return system, prompt, nil
+}
+
+func (t *PromptTemplate) key() string {
+	return promptKey(t.Name, t.Language, t.Version)
+}
+
+// PromptVariant is one arm of an experiment, chosen with probability Weight / sum of weights
+type PromptVariant struct {
+	Version string `json:"version"`
+	Weight  int    `json:"weight"`
+}
+
+// PromptExperiment splits the stories of one template between versions
+type PromptExperiment struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+	// Language limits the experiment to one language; empty applies it to all
+	Language string          `json:"language,omitempty"`
+	Unit     string          `json:"unit"`
+	Variants []PromptVariant `json:"variants"`
+}
+
+// PromptRegistry holds the prompt versions added on top of the built-in ones, the default
+// version of each template and the running experiments
+type PromptRegistry struct {
+	Templates []PromptTemplate `json:"templates,omitempty"`
+	// Defaults maps a template name to the version used outside experiments
+	Defaults    map[string]string  `json:"defaults,omitempty"`
+	Experiments []PromptExperiment `json:"experiments,omitempty"`
+}
+
+// PromptChoice is the template picked for a story and the experiment that picked it
+type PromptChoice struct {
+	Template   *PromptTemplate
+	Experiment string
+}
+
+// ToMap returns the fields recorded on the story
+func (c PromptChoice) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"prompt_template":   c.Template.Name,
+		"prompt_version":    c.Template.Version,
+		"prompt_experiment": c.Experiment,
+	}
+}
+
+// builtinPrompts are the prompt functions of the language packs as version v1
+var builtinPrompts = func() map[string]*PromptTemplate {
+	byKey := make(map[string]*PromptTemplate)
+	for _, pack := range languages.All() {
+		for _, theme := range languages.Themes {
+			t := &PromptTemplate{Name: theme, Language: pack.Name(), Version: BuiltinPromptVersion, builtin: func(v PromptVars) (string, string) {
+				return pack.StoryPrompt(theme, languages.StoryVars{Topic: v.Topic, Country: v.Country, City: v.City, Religion: v.Religion})
+			}}
+			byKey[t.key()] = t
+		}
+	}
+	return byKey
+}()
+
+// initPromptRegistry loads PROMPT_REGISTRY, falling back to the built-in prompts only
+func initPromptRegistry() *PromptRegistry {
+	raw := os.Getenv("PROMPT_REGISTRY")
+	if raw == "" {
+		return &PromptRegistry{}
+	}
+	registry, err := ParsePromptRegistry([]byte(raw))
+	if err != nil {
+		log.Printf("Warning: Ignoring invalid PROMPT_REGISTRY: %v", err)
+		return &PromptRegistry{}
+	}
+	return registry
+}
+
+// ParsePromptRegistry decodes and validates a JSON prompt registry
+func ParsePromptRegistry(data []byte) (*PromptRegistry, error) {
+	var registry PromptRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("error decoding prompt registry: %v", err)
+	}
+	if err := registry.Validate(); err != nil {
+		return nil, err
+	}
+	return &registry, nil
+}
+
+// Validate checks that templates parse and are unique, and that defaults and experiment
+// variants point to versions that exist
+func (r *PromptRegistry) Validate() error {
+	seen := make(map[string]bool)
+	for _, t := range r.Templates {
+		if !validPromptName(t.Name) {
+			return fmt.Errorf("template %q: unknown template name", t.Name)
+		}
+		if t.Language == "" || t.Version == "" {
+			return fmt.Errorf("template %s: language and version are required", t.Name)
+		}
+		if err := languages.Validate(t.Language); err != nil {
+			return fmt.Errorf("template %s: %v", t.Name, err)
+		}
+		key := t.key()
+		if seen[key] || builtinPrompts[key] != nil {
+			return fmt.Errorf("template %s is defined twice", key)
+		}
+		seen[key] = true
+		for _, text := range []string{t.System, t.Prompt} {
+			if _, err := renderPromptText(text, PromptVars{}); err != nil {
+				return fmt.Errorf("template %s: %v", key, err)
+			}
+		}
+	}
+	for name, version := range r.Defaults {
+		if !validPromptName(name) {
+			return fmt.Errorf("default for unknown template %q", name)
+		}
+		if !r.hasVersion(name, "", version) {
+			return fmt.Errorf("default %s/%s does not exist", name, version)
+		}
+	}
+	experiments := make(map[string]bool)
+	for _, e := range r.Experiments {
+		if e.Name == "" || experiments[e.Name] {
+			return fmt.Errorf("experiment %q: name is empty or used twice", e.Name)
+		}
+		experiments[e.Name] = true
+		if !validPromptName(e.Template) {
+			return fmt.Errorf("experiment %s: unknown template %q", e.Name, e.Template)
+		}
+		if e.Language != "" {
+			if err := languages.Validate(e.Language); err != nil {
+				return fmt.Errorf("experiment %s: %v", e.Name, err)
+			}
+		}
+		if e.Unit != ExperimentUnitUser && e.Unit != ExperimentUnitStory {
+			return fmt.Errorf("experiment %s: unit must be %q or %q", e.Name, ExperimentUnitUser, ExperimentUnitStory)
+		}
+		if len(e.Variants) < 2 {
+			return fmt.Errorf("experiment %s: needs at least two variants", e.Name)
+		}
+		for _, v := range e.Variants {
+			if v.Weight <= 0 {
+				return fmt.Errorf("experiment %s: weight of %s must be positive", e.Name, v.Version)
+			}
+			if !r.hasVersion(e.Template, e.Language, v.Version) {
+				return fmt.Errorf("experiment %s: version %s of %s does not exist", e.Name, v.Version, e.Template)
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve returns a version of a template in a language, or nil when it does not exist
+func (r *PromptRegistry) Resolve(name, language, version string) *PromptTemplate {
+	for i := range r.Templates {
+		if t := &r.Templates[i]; t.Name == name && t.Language == language && t.Version == version {
+			return t
+		}
+	}
+	return builtinPrompts[promptKey(name, language, version)]
+}
+
+// Choose picks the template for a story. The first experiment on the template assigns the
+// variant from a hash of the user's email or the story ID, so assignment is stable.
+// Outside experiments, or when the variant has no version in the language, the default version is used.
+func (r *PromptRegistry) Choose(name, language, email, storyID string) PromptChoice {
+	for _, e := range r.Experiments {
+		if e.Template != name || (e.Language != "" && e.Language != language) {
+			continue
+		}
+		unitKey := storyID
+		if e.Unit == ExperimentUnitUser && email != "" {
+			unitKey = email
+		}
+		if t := r.Resolve(name, language, e.pick(unitKey)); t != nil {
+			return PromptChoice{Template: t, Experiment: e.Name}
+		}
+		break
+	}
+	return PromptChoice{Template: r.Default(name, language)}
+}
+
+// Default returns the version of a template used outside experiments
+func (r *PromptRegistry) Default(name, language string) *PromptTemplate {
+	if version, ok := r.Defaults[name]; ok {
+		if t := r.Resolve(name, language, version); t != nil {
+			return t
+		}
+	}
+	if t := builtinPrompts[promptKey(name, language, BuiltinPromptVersion)]; t != nil {
+		return t
+	}
+	return builtinPrompts[promptKey(name, languages.Default, BuiltinPromptVersion)]
+}
+
+// pick returns the variant version a unit falls into
+func (e PromptExperiment) pick(unitKey string) string {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + ":" + unitKey))
+	bucket := int(h.Sum32() % uint32(total))
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			return v.Version
+		}
+		bucket -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1].Version
+}
+
+// hasVersion reports whether a version of a template exists, in language or in any language when it is empty
+func (r *PromptRegistry) hasVersion(name, language, version string) bool {
+	for _, t := range r.Templates {
+		if t.Name == name && t.Version == version && (language == "" || t.Language == language) {
+			return true
+		}
+	}
+	return version == BuiltinPromptVersion
+}
+
+func validPromptName(name string) bool {
+	return name == PromptPlanetProtector || name == PromptMindful || name == PromptChill
+}
+
+func promptKey(name, language, version string) string {
+	return name + "/" + language + "/" + version
+}
+
+func renderPromptText(text string, vars PromptVars) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
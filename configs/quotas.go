@@ -0,0 +1,54 @@
+package configs
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+// PlanLimits are the generation quotas of a plan. A negative limit means unlimited.
+// Story requests count POST /story calls, each of which generates a batch of stories for every theme.
+type PlanLimits struct {
+	StoryRequestsPerDay   int `json:"story_requests_per_day"`
+	StoryRequestsPerMonth int `json:"story_requests_per_month"`
+	RegenerationsPerDay   int `json:"regenerations_per_day"`
+}
+
+// DefaultPlan is the plan of users whose profile has no plan set
+const DefaultPlan = "free"
+
+// defaultPlanLimits are the built-in quotas per plan
+var defaultPlanLimits = map[string]PlanLimits{
+	"free":    {StoryRequestsPerDay: 2, StoryRequestsPerMonth: 20, RegenerationsPerDay: 2},
+	"premium": {StoryRequestsPerDay: 10, StoryRequestsPerMonth: 150, RegenerationsPerDay: 10},
+}
+
+// initPlanLimits returns the built-in plan limits with overrides from QUOTA_PLANS,
+// a JSON object of plan name to PlanLimits
+func initPlanLimits() map[string]PlanLimits {
+	plans := make(map[string]PlanLimits, len(defaultPlanLimits))
+	for plan, limits := range defaultPlanLimits {
+		plans[plan] = limits
+	}
+	raw := os.Getenv("QUOTA_PLANS")
+	if raw == "" {
+		return plans
+	}
+	var overrides map[string]PlanLimits
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		log.Printf("Warning: Ignoring invalid QUOTA_PLANS: %v", err)
+		return plans
+	}
+	for plan, limits := range overrides {
+		plans[plan] = limits
+	}
+	return plans
+}
+
+// GetPlanLimits returns the limits of a plan, falling back to the default plan
+func (s *Settings) GetPlanLimits(plan string) PlanLimits {
+	if limits, ok := s.PlanLimits[plan]; ok {
+		return limits
+	}
+	return s.PlanLimits[DefaultPlan]
+}
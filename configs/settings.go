@@ -12,6 +12,27 @@ import (
 // Global settings instance
 var GlobalSettings *Settings
 
+// Provider modes: live calls the real AI services, fake uses offline deterministic providers
+const (
+	ProviderModeLive = "live"
+	ProviderModeFake = "fake"
+)
+
+// Cassette modes: off calls providers directly, record saves their HTTP traffic and replay serves it back
+const (
+	CassetteModeOff    = "off"
+	CassetteModeRecord = "record"
+	CassetteModeReplay = "replay"
+)
+
+// Media cache modes: off synthesizes every time, memory indexes cached media on this instance only
+// and firestore shares the index between instances
+const (
+	MediaCacheOff       = "off"
+	MediaCacheMemory    = "memory"
+	MediaCacheFirestore = "firestore"
+)
+
 // Settings represents the application settings
 type Settings struct {
 	// API Keys and Authentication
@@ -56,6 +77,59 @@ type Settings struct {
 	HuggingFaceTimeout time.Duration
 	TogetherAITimeout  time.Duration
 	TTSTimeout         time.Duration
+	// OptionalMediaTimeout bounds storybook pages and bilingual paragraphs; a story is saved without them when they run late
+	OptionalMediaTimeout time.Duration
+
+	// Storybook Settings
+	StorybookMaxScenes    int
+	StorybookImageWorkers int
+
+	// Translation Settings
+	TranslationCacheSize  int
+	BilingualAudioWorkers int
+
+	// Read-along Settings
+	ReadAlongEnabled bool
+
+	// Chunked TTS Settings
+	TTSConcurrency   int
+	TTSChunkAttempts int
+
+	// Media Cache Settings
+	MediaCache string
+	// MediaCacheTTLDays is how long a cache entry lives without a hit
+	MediaCacheTTLDays int
+
+	// Soundscape Settings
+	SoundscapeVolume int
+
+	// Cost Ledger Settings
+	PriceTable map[string]float64
+
+	// Quota Settings
+	PlanLimits map[string]PlanLimits
+
+	// Budget Settings
+	BudgetPolicy *BudgetPolicy
+
+	// Prompt Settings
+	PromptRegistry        *PromptRegistry
+	CatalogDir            string
+	CatalogReloadInterval time.Duration
+
+	// Scheduler Settings
+	SchedulerEnabled       bool
+	PregenerateProfileKeys int
+	BackfillStoriesPerRun  int
+	OrphanBlobMinAgeHours  int
+
+	// Provider Settings
+	ProviderMode string
+	CassetteMode string
+	CassetteDir  string
+
+	// Admin Settings
+	AdminEmails []string
 
 	// Story Configuration
 	StoryConfig StoryConfig
@@ -126,6 +200,58 @@ func NewSettings() *Settings {
 		TogetherAITimeout:  time.Duration(getEnvInt("TOGETHER_AI_TIMEOUT", 180)) * time.Second,
 		TTSTimeout:         time.Duration(getEnvInt("TTS_TIMEOUT", 90)) * time.Second,
 
+		OptionalMediaTimeout: time.Duration(getEnvInt("OPTIONAL_MEDIA_TIMEOUT", 180)) * time.Second,
+
+		// Storybook
+		StorybookMaxScenes:    getEnvInt("STORYBOOK_MAX_SCENES", 4),
+		StorybookImageWorkers: getEnvInt("STORYBOOK_IMAGE_WORKERS", 2),
+
+		// Translation
+		TranslationCacheSize:  getEnvInt("TRANSLATION_CACHE_SIZE", 5000),
+		BilingualAudioWorkers: getEnvInt("BILINGUAL_AUDIO_WORKERS", 2),
+
+		// Read-along
+		ReadAlongEnabled: getEnvBool("READ_ALONG_ENABLED", false),
+
+		// Chunked TTS
+		TTSConcurrency:   getEnvInt("TTS_CONCURRENCY", 4),
+		TTSChunkAttempts: getEnvInt("TTS_CHUNK_ATTEMPTS", 3),
+
+		// Media cache
+		MediaCache:        getEnvString("MEDIA_CACHE", MediaCacheFirestore),
+		MediaCacheTTLDays: getEnvInt("MEDIA_CACHE_TTL_DAYS", 90),
+
+		// Soundscape
+		SoundscapeVolume: getEnvInt("SOUNDSCAPE_VOLUME", 15),
+
+		// Cost ledger
+		PriceTable: initPriceTable(),
+
+		// Quotas
+		PlanLimits: initPlanLimits(),
+
+		// Budget
+		BudgetPolicy: initBudgetPolicy(),
+
+		// Prompts
+		PromptRegistry:        initPromptRegistry(),
+		CatalogDir:            getEnvString("CATALOG_DIR", ""),
+		CatalogReloadInterval: time.Duration(getEnvInt("CATALOG_RELOAD_SECONDS", 30)) * time.Second,
+
+		// Scheduler
+		SchedulerEnabled:       getEnvBool("SCHEDULER_ENABLED", false),
+		PregenerateProfileKeys: getEnvInt("PREGENERATE_PROFILE_KEYS", 5),
+		BackfillStoriesPerRun:  getEnvInt("BACKFILL_STORIES_PER_RUN", 10),
+		OrphanBlobMinAgeHours:  getEnvInt("ORPHAN_BLOB_MIN_AGE_HOURS", 48),
+
+		// Providers
+		ProviderMode: getEnvString("PROVIDER_MODE", ProviderModeLive),
+		CassetteMode: getEnvString("CASSETTE_MODE", CassetteModeOff),
+		CassetteDir:  getEnvString("CASSETTE_DIR", "testdata/cassettes"),
+
+		// Admin
+		AdminEmails: getEnvList("ADMIN_EMAILS"),
+
 		// Initialize complex configurations
 		StoryConfig:    initStoryConfig(),
 		StoryThemes:    initStoryThemes(),
@@ -135,9 +261,25 @@ func NewSettings() *Settings {
 	}
 }
 
-// LoadSettings loads settings from environment variables
-func LoadSettings() *Settings {
+// ReadSettings reads settings from environment variables and validates them
+func ReadSettings() (*Settings, error) {
 	settings := NewSettings()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if settings.HuggingFaceToken == "" && settings.ProviderMode == ProviderModeLive {
+		log.Println("Warning: HUGGINGFACE_TOKEN not set")
+	}
+	return settings, nil
+}
+
+// LoadSettings loads settings from environment variables, exiting when they are invalid so a
+// mistyped value does not silently run with a different mode
+func LoadSettings() *Settings {
+	settings, err := ReadSettings()
+	if err != nil {
+		log.Fatalf("Invalid settings: %v", err)
+	}
 
 	// Log initialization
 	log.Println("Settings loaded")
@@ -166,6 +308,27 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvBool reads a boolean environment variable such as true, 1 or false
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma-separated environment variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func maskToken(token string) string {
 	if len(token) > 10 {
 		return token[:6] + "..." + token[len(token)-4:]
@@ -255,20 +418,58 @@ func (s *Settings) FormatPrompt(template string, params map[string]string) strin
 	return result
 }
 
-// Validate validates the settings configuration
-func (s *Settings) Validate() error {
-	if s.HuggingFaceToken == "" {
-		return fmt.Errorf("HUGGINGFACE_TOKEN is required")
+// IsAdmin reports whether the email belongs to a configured admin
+func (s *Settings) IsAdmin(email string) bool {
+	for _, admin := range s.AdminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
 	}
+	return false
+}
 
-	if s.DefaultStoryToGenerate <= 0 {
-		return fmt.Errorf("DEFAULT_STORY_TO_GENERATE must be positive")
+// Validate validates the settings configuration
+func (s *Settings) Validate() error {
+	// 0 turns off pre-generating stories
+	if s.DefaultStoryToGenerate < 0 {
+		return fmt.Errorf("DEFAULT_STORY_TO_GENERATE must not be negative")
 	}
 
 	if s.MaxWorkers <= 0 {
 		return fmt.Errorf("MAX_WORKERS must be positive")
 	}
 
+	if s.ProviderMode != ProviderModeLive && s.ProviderMode != ProviderModeFake {
+		return fmt.Errorf("PROVIDER_MODE must be %q or %q", ProviderModeLive, ProviderModeFake)
+	}
+
+	if s.TTSConcurrency <= 0 || s.TTSChunkAttempts <= 0 {
+		return fmt.Errorf("TTS_CONCURRENCY and TTS_CHUNK_ATTEMPTS must be positive")
+	}
+
+	if s.TranslationCacheSize < 0 {
+		return fmt.Errorf("TRANSLATION_CACHE_SIZE must not be negative")
+	}
+
+	switch s.CassetteMode {
+	case CassetteModeOff, CassetteModeRecord, CassetteModeReplay:
+	default:
+		return fmt.Errorf("CASSETTE_MODE must be %q, %q or %q", CassetteModeOff, CassetteModeRecord, CassetteModeReplay)
+	}
+
+	if s.SoundscapeVolume < 0 || s.SoundscapeVolume > 100 {
+		return fmt.Errorf("SOUNDSCAPE_VOLUME must be between 0 and 100")
+	}
+
+	switch s.MediaCache {
+	case MediaCacheOff, MediaCacheMemory, MediaCacheFirestore:
+	default:
+		return fmt.Errorf("MEDIA_CACHE must be %q, %q or %q", MediaCacheOff, MediaCacheMemory, MediaCacheFirestore)
+	}
+	if s.MediaCacheTTLDays < 1 {
+		return fmt.Errorf("MEDIA_CACHE_TTL_DAYS must be at least 1")
+	}
+
 	return nil
 }
 
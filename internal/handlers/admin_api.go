@@ -0,0 +1,647 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/configs/catalog"
+	"rio-go-model/internal/helpers/chunked"
+	"rio-go-model/internal/services/database"
+	"rio-go-model/internal/services/scheduler"
+	"rio-go-model/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler serves operational endpoints restricted to ADMIN_EMAILS
+type AdminHandler struct {
+	storyDB   *database.StoryDatabase
+	scheduler *scheduler.Scheduler
+	logger    *log.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(storyDB *database.StoryDatabase, jobScheduler *scheduler.Scheduler) *AdminHandler {
+	return &AdminHandler{
+		storyDB:   storyDB,
+		scheduler: jobScheduler,
+		logger:    log.New(log.Writer(), "[Admin] ", log.LstdFlags|log.Lshortfile),
+	}
+}
+
+// authorizeAdmin verifies the token and that its user is an admin.
+// It writes the error response itself and returns false when the request must stop.
+func (h *AdminHandler) authorizeAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	_, email, tokenVersion, err := util.VerifyAuth(r)
+	if err != nil {
+		h.logger.Printf("WARNING: Invalid token: %v", err)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	userTokenVersion, err := h.storyDB.GetTokenVersion(ctx, email)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to get token version: %v", err)
+		http.Error(w, "Failed to get token version", http.StatusInternalServerError)
+		return "", false
+	}
+	if err := util.VerifyUserTokenVersion(tokenVersion, userTokenVersion); err != nil {
+		h.logger.Printf("WARNING: Token version mismatch: %v", err)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return "", false
+	}
+	if !configs.GetSettings().IsAdmin(email) {
+		h.logger.Printf("WARNING: Non-admin %s called an admin endpoint", email)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return email, true
+}
+
+// writeJSON sends a JSON response
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// CostReport aggregates the cost ledger
+// @Summary      Cost report
+// @Description  Aggregates the estimated cost of provider calls per user, theme, day or provider. Dates are UTC, "to" is inclusive and the default range is the last 30 days.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        group_by query string false "user, theme, day or provider (default: day)"
+// @Param        from query string false "Start date, YYYY-MM-DD"
+// @Param        to query string false "End date, YYYY-MM-DD"
+// @Success      200 {array} model.CostReportRow
+// @Failure      400 {object} util.HttpError "Invalid query parameters"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/costs [get]
+func (h *AdminHandler) CostReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = "day"
+	case "user", "theme", "day", "provider":
+	default:
+		http.Error(w, "Invalid group_by, expected user, theme, day or provider", http.StatusBadRequest)
+		return
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, err := parseReportDate(r.URL.Query().Get("from"), today.AddDate(0, 0, -29))
+	if err != nil {
+		http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	to, err := parseReportDate(r.URL.Query().Get("to"), today)
+	if err != nil {
+		http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	report, err := h.storyDB.CostReport(ctx, groupBy, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to build cost report: %v", err)
+		http.Error(w, "Failed to build cost report", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, report)
+}
+
+// parseReportDate parses a YYYY-MM-DD query value, using fallback when it is empty
+func parseReportDate(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// QuotaOverrideRequest sets a user's plan and per-user limits; omitted fields keep the plan value
+type QuotaOverrideRequest struct {
+	Plan                  string `json:"plan,omitempty"`
+	StoryRequestsPerDay   *int   `json:"story_requests_per_day,omitempty"`
+	StoryRequestsPerMonth *int   `json:"story_requests_per_month,omitempty"`
+	RegenerationsPerDay   *int   `json:"regenerations_per_day,omitempty"`
+}
+
+// GetUserQuota returns a user's usage and effective limits
+// @Summary      Get user quota
+// @Description  Returns the user's current usage counters and the limits that apply after plan and admin overrides.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        email path string true "User email"
+// @Success      200 {object} model.QuotaUsage
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/quotas/{email} [get]
+func (h *AdminHandler) GetUserQuota(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	email := mux.Vars(r)["email"]
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	usage, err := h.storyDB.GetQuotaUsage(ctx, email)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to read quota of %s: %v", email, err)
+		http.Error(w, "Failed to read quota", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, usage)
+}
+
+// SetUserQuota overrides a user's plan or limits
+// @Summary      Override user quota
+// @Description  Overrides the user's plan and/or individual limits. A negative limit means unlimited.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        email path string true "User email"
+// @Param        override body QuotaOverrideRequest true "Quota override"
+// @Success      200 {object} model.QuotaUsage
+// @Failure      400 {object} util.HttpError "Invalid request body"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/quotas/{email} [put]
+func (h *AdminHandler) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	email := mux.Vars(r)["email"]
+	var req QuotaOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	override := map[string]interface{}{"updated_by": admin}
+	if req.Plan != "" {
+		override["plan"] = req.Plan
+	}
+	if req.StoryRequestsPerDay != nil {
+		override["story_requests_per_day"] = *req.StoryRequestsPerDay
+	}
+	if req.StoryRequestsPerMonth != nil {
+		override["story_requests_per_month"] = *req.StoryRequestsPerMonth
+	}
+	if req.RegenerationsPerDay != nil {
+		override["regenerations_per_day"] = *req.RegenerationsPerDay
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.storyDB.SetQuotaOverride(ctx, email, override); err != nil {
+		h.logger.Printf("ERROR: Failed to override quota of %s: %v", email, err)
+		http.Error(w, "Failed to override quota", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Printf("INFO: %s overrode quota of %s", admin, email)
+	usage, err := h.storyDB.GetQuotaUsage(ctx, email)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to read quota of %s: %v", email, err)
+		http.Error(w, "Failed to read quota", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, usage)
+}
+
+// DeleteUserQuota removes a user's quota overrides
+// @Summary      Remove user quota override
+// @Description  Removes the admin overrides so the user's plan limits apply again.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        email path string true "User email"
+// @Success      200 {object} map[string]string "Quota override removed"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/quotas/{email} [delete]
+func (h *AdminHandler) DeleteUserQuota(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	email := mux.Vars(r)["email"]
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.storyDB.DeleteQuotaOverride(ctx, email); err != nil {
+		h.logger.Printf("ERROR: Failed to remove quota override of %s: %v", email, err)
+		http.Error(w, "Failed to remove quota override", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"message": "Quota override removed"})
+}
+
+// GetBudgetPolicy returns the budget policy in effect
+// @Summary      Get budget policy
+// @Description  Returns the budget policy in effect: the one stored by an admin, or the configured default.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} configs.BudgetPolicy
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Router       /admin/budget/policy [get]
+func (h *AdminHandler) GetBudgetPolicy(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	h.writeJSON(w, http.StatusOK, h.storyDB.GetBudgetPolicy(ctx))
+}
+
+// SetBudgetPolicy stores a budget policy that overrides the configured one
+// @Summary      Set budget policy
+// @Description  Validates and stores a budget policy. It applies to all instances within a minute.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        policy body configs.BudgetPolicy true "Budget policy"
+// @Success      200 {object} configs.BudgetPolicy
+// @Failure      400 {object} util.HttpError "Invalid budget policy"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/budget/policy [put]
+func (h *AdminHandler) SetBudgetPolicy(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	var policy configs.BudgetPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		http.Error(w, "Invalid budget policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.storyDB.SetBudgetPolicy(ctx, &policy, admin); err != nil {
+		h.logger.Printf("ERROR: Failed to store budget policy: %v", err)
+		http.Error(w, "Failed to store budget policy", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Printf("INFO: %s updated the budget policy", admin)
+	h.writeJSON(w, http.StatusOK, &policy)
+}
+
+// DeleteBudgetPolicy removes the stored budget policy
+// @Summary      Remove budget policy
+// @Description  Removes the stored budget policy so the configured default applies again.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]string "Budget policy removed"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/budget/policy [delete]
+func (h *AdminHandler) DeleteBudgetPolicy(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.storyDB.DeleteBudgetPolicy(ctx); err != nil {
+		h.logger.Printf("ERROR: Failed to remove budget policy: %v", err)
+		http.Error(w, "Failed to remove budget policy", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Printf("INFO: %s removed the budget policy", admin)
+	h.writeJSON(w, http.StatusOK, map[string]string{"message": "Budget policy removed"})
+}
+
+// EvaluateBudget is a dry run of the budget policy
+// @Summary      Evaluate budget policy
+// @Description  Shows the tier each provider is currently in, without changing anything. With provider and any of cost, budget or threshold, evaluates that usage for the provider instead.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        provider query string false "Provider to evaluate, e.g. gemini or audio"
+// @Param        cost query number false "Hypothetical cost amount"
+// @Param        budget query number false "Hypothetical budget amount"
+// @Param        threshold query number false "Hypothetical alert threshold, 0-1"
+// @Success      200 {array} configs.BudgetDecision
+// @Failure      400 {object} util.HttpError "Invalid query parameters"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Router       /admin/budget/evaluate [get]
+func (h *AdminHandler) EvaluateBudget(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	policy := h.storyDB.GetBudgetPolicy(ctx)
+	query := r.URL.Query()
+	provider := query.Get("provider")
+
+	if query.Has("cost") || query.Has("budget") || query.Has("threshold") {
+		if provider == "" {
+			http.Error(w, "provider is required with cost, budget or threshold", http.StatusBadRequest)
+			return
+		}
+		var usage configs.BudgetUsage
+		for key, target := range map[string]*float64{"cost": &usage.CostAmount, "budget": &usage.BudgetAmount, "threshold": &usage.Threshold} {
+			if !query.Has(key) {
+				continue
+			}
+			value, err := strconv.ParseFloat(query.Get(key), 64)
+			if err != nil {
+				http.Error(w, "Invalid "+key+", expected a number", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+		now := time.Now().UTC()
+		usage.ResetAt = policy.NextReset(now)
+		h.writeJSON(w, http.StatusOK, []configs.BudgetDecision{policy.Evaluate(provider, usage, now)})
+		return
+	}
+
+	providers := make([]string, 0, len(policy.Providers))
+	if provider != "" {
+		providers = append(providers, provider)
+	} else {
+		for name := range policy.Providers {
+			providers = append(providers, name)
+		}
+		sort.Strings(providers)
+	}
+	decisions := make([]configs.BudgetDecision, 0, len(providers))
+	for _, name := range providers {
+		usage, err := h.storyDB.GetBudgetUsage(ctx, name)
+		if err != nil {
+			// No budget alert recorded yet
+			h.logger.Printf("WARNING: No budget usage for %s: %v", name, err)
+			usage = &configs.BudgetUsage{}
+		}
+		decisions = append(decisions, policy.Evaluate(name, *usage, time.Now().UTC()))
+	}
+	h.writeJSON(w, http.StatusOK, decisions)
+}
+
+// GetPromptRegistry returns the prompt registry in effect
+// @Summary      Get prompt registry
+// @Description  Returns the prompt versions, defaults and experiments in effect: the registry stored by an admin, or the configured one. Built-in v1 prompts are always available and not listed.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} configs.PromptRegistry
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Router       /admin/prompts/registry [get]
+func (h *AdminHandler) GetPromptRegistry(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	h.writeJSON(w, http.StatusOK, h.storyDB.GetPromptRegistry(ctx))
+}
+
+// SetPromptRegistry stores a prompt registry that overrides the configured one
+// @Summary      Set prompt registry
+// @Description  Validates and stores prompt versions, defaults and experiments. They apply to all instances within a minute.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        registry body configs.PromptRegistry true "Prompt registry"
+// @Success      200 {object} configs.PromptRegistry
+// @Failure      400 {object} util.HttpError "Invalid prompt registry"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/prompts/registry [put]
+func (h *AdminHandler) SetPromptRegistry(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	var registry configs.PromptRegistry
+	if err := json.NewDecoder(r.Body).Decode(&registry); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := registry.Validate(); err != nil {
+		http.Error(w, "Invalid prompt registry: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.storyDB.SetPromptRegistry(ctx, &registry, admin); err != nil {
+		h.logger.Printf("ERROR: Failed to store prompt registry: %v", err)
+		http.Error(w, "Failed to store prompt registry", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Printf("INFO: %s updated the prompt registry", admin)
+	h.writeJSON(w, http.StatusOK, &registry)
+}
+
+// DeletePromptRegistry removes the stored prompt registry
+// @Summary      Remove prompt registry
+// @Description  Removes the stored prompt registry so the configured one applies again.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]string "Prompt registry removed"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/prompts/registry [delete]
+func (h *AdminHandler) DeletePromptRegistry(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.storyDB.DeletePromptRegistry(ctx); err != nil {
+		h.logger.Printf("ERROR: Failed to remove prompt registry: %v", err)
+		http.Error(w, "Failed to remove prompt registry", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Printf("INFO: %s removed the prompt registry", admin)
+	h.writeJSON(w, http.StatusOK, map[string]string{"message": "Prompt registry removed"})
+}
+
+// PromptStats compares the feedback of prompt versions
+// @Summary      Prompt version feedback
+// @Description  Counts story feedback and likes per prompt template and version, optionally for one experiment.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        experiment query string false "Experiment name"
+// @Success      200 {array} database.PromptVersionStats
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/prompts/stats [get]
+func (h *AdminHandler) PromptStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	stats, err := h.storyDB.GetPromptVersionStats(ctx, r.URL.Query().Get("experiment"))
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to read prompt stats: %v", err)
+		http.Error(w, "Failed to read prompt stats", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, stats)
+}
+
+// GetCatalog describes the topic and prompt catalog in use
+// @Summary      Get catalog
+// @Description  Returns where the topic and prompt catalog was loaded from, when, and how many topics and preferences each language has.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} catalog.Summary
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Router       /admin/catalog [get]
+func (h *AdminHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, catalog.Current().Summary())
+}
+
+// ReloadCatalog reads the catalog files again
+// @Summary      Reload catalog
+// @Description  Reloads the topic and prompt catalog from CATALOG_DIR on this instance. Other instances pick up file changes on their own. An invalid catalog is rejected and the current one kept.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} catalog.Summary
+// @Failure      400 {object} util.HttpError "Invalid catalog"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Router       /admin/catalog/reload [post]
+func (h *AdminHandler) ReloadCatalog(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	loaded, err := catalog.Reload()
+	if err != nil {
+		h.logger.Printf("WARNING: Catalog reload by %s failed: %v", admin, err)
+		http.Error(w, "Invalid catalog: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logger.Printf("INFO: %s reloaded the catalog from %s", admin, loaded.Source)
+	h.writeJSON(w, http.StatusOK, loaded.Summary())
+}
+
+// ListJobs returns the scheduled jobs and their last runs
+// @Summary      List scheduled jobs
+// @Description  Returns every scheduled job with its schedule, timeout, concurrency policy and last run on any instance.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} scheduler.JobStatus
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/jobs [get]
+func (h *AdminHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	statuses, err := h.scheduler.Status(ctx)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to read job status: %v", err)
+		http.Error(w, "Failed to read job status", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, statuses)
+}
+
+// RunJob starts a scheduled job now
+// @Summary      Run scheduled job
+// @Description  Starts a job outside its schedule. The job runs in the background; poll the job list for its status.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name path string true "Job name"
+// @Success      202 {object} map[string]string "Job started"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Failure      404 {object} util.HttpError "Unknown job"
+// @Failure      409 {object} util.HttpError "Job already running"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /admin/jobs/{name}/run [post]
+func (h *AdminHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+	name := mux.Vars(r)["name"]
+	err := h.scheduler.RunNow(name)
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		http.Error(w, "Unknown job", http.StatusNotFound)
+		return
+	case errors.Is(err, scheduler.ErrJobRunning), errors.Is(err, scheduler.ErrLeaseHeld):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: Failed to start job %s: %v", name, err)
+		http.Error(w, "Failed to start job", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Printf("INFO: %s started job %s", admin, name)
+	h.writeJSON(w, http.StatusAccepted, map[string]string{"message": "Job started"})
+}
+
+// TTSMetrics returns the chunked speech synthesis metrics of this instance
+// @Summary      Get TTS chunk metrics
+// @Description  Returns, per TTS provider, how many chunked narrations this instance ran and how many failed, the chunk calls and retries made, the most chunks in one narration, and the average and maximum chunk and narration latency in milliseconds. Counters start at zero when the instance starts.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} chunked.Stats
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      403 {object} util.HttpError "Not an admin"
+// @Router       /admin/tts/metrics [get]
+func (h *AdminHandler) TTSMetrics(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorizeAdmin(w, r); !ok {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, chunked.Snapshot())
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,12 +15,16 @@ import (
 
 	// "runtime/debug"
 
+	"rio-go-model/configs/languages"
 	"rio-go-model/internal/helpers"
+	"rio-go-model/internal/helpers/audiofile"
 	"rio-go-model/internal/services/database"
 	"rio-go-model/internal/util"
 
 	// "rio-go-model/configs"
 	"rio-go-model/internal/model"
+
+	"github.com/gorilla/mux"
 )
 
 // StoryTopics represents the story topics handler
@@ -50,6 +55,13 @@ type CreateStoryRequest struct {
 	Religions   []string `json:"religions"`
 	Preferences []string `json:"preferences"`
 	Language    string   `json:"language"`
+	Storybook   bool     `json:"storybook,omitempty"`
+	// BilingualLanguage, when set, stores every paragraph next to its translation into this language
+	BilingualLanguage string `json:"bilingual_language,omitempty"`
+	// Dialogue reads quoted speech in a voice for each character
+	Dialogue bool `json:"dialogue,omitempty"`
+	// Soundscape adds a rendition of the audio with ambient sound matching the theme underneath
+	Soundscape bool `json:"soundscape,omitempty"`
 }
 
 // MetadataUploadRequest represents metadata upload request
@@ -77,14 +89,57 @@ type ListStoriesRequest struct {
 
 // StoryData represents a single story in the response
 type StoryData struct {
-	StoryID   string `json:"story_id"`
-	Title     string `json:"title"`
-	StoryText string `json:"story_text"`
-	Image     string `json:"image"`
-	Audio     string `json:"audio"`
-	AudioType string `json:"audio_type"`
-	Theme     string `json:"theme"`
-	Language  string `json:"language"`
+	StoryID     string          `json:"story_id"`
+	Title       string          `json:"title"`
+	StoryText   string          `json:"story_text"`
+	Image       string          `json:"image"`
+	ImageThumb  string          `json:"image_thumb,omitempty"`
+	ImageMedium string          `json:"image_medium,omitempty"`
+	Audio       string          `json:"audio"`
+	AudioType   string          `json:"audio_type"`
+	Theme       string          `json:"theme"`
+	Language    string          `json:"language"`
+	Pages       []StoryPageData `json:"pages,omitempty"`
+	// Paragraphs are the aligned paragraph pairs of a bilingual story
+	Paragraphs []StoryParagraphData `json:"paragraphs,omitempty"`
+	// TranslationOf is the ID of the original story when this story is a translation
+	TranslationOf string `json:"translation_of,omitempty"`
+	// TitleRomanized and StoryTextRomanized are the title and text in Roman script; ListStories fills them when romanize is set
+	TitleRomanized     string `json:"title_romanized,omitempty"`
+	StoryTextRomanized string `json:"story_text_romanized,omitempty"`
+	// AudioDuration is the length of the audio in seconds, when it was measured
+	AudioDuration float64 `json:"audio_duration,omitempty"`
+	// Timing and Captions are signed URLs of the read-along word timing, as JSON and as WebVTT
+	Timing   string `json:"timing,omitempty"`
+	Captions string `json:"captions,omitempty"`
+	// Cast maps each speaker of a dialogue-narrated story, the narrator included, to its voice
+	Cast map[string]string `json:"cast,omitempty"`
+	// Soundscape names the ambient loop mixed under SoundscapeAudio, a signed URL of the mixed WAV
+	Soundscape      string `json:"soundscape,omitempty"`
+	SoundscapeAudio string `json:"soundscape_audio,omitempty"`
+}
+
+// StoryPageData represents one illustrated page of a storybook-mode story
+type StoryPageData struct {
+	Index       int    `json:"index"`
+	Text        string `json:"text"`
+	Image       string `json:"image"`
+	ImageThumb  string `json:"image_thumb,omitempty"`
+	ImageMedium string `json:"image_medium,omitempty"`
+}
+
+// StoryParagraphData represents one paragraph of a bilingual story next to its translation
+type StoryParagraphData struct {
+	Index       int                  `json:"index"`
+	Original    ParagraphSegmentData `json:"original"`
+	Translation ParagraphSegmentData `json:"translation"`
+}
+
+// ParagraphSegmentData represents one language of a bilingual paragraph with a signed audio URL
+type ParagraphSegmentData struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+	Audio    string `json:"audio,omitempty"`
 }
 
 // GetStoryTopics handles GET request for story topics
@@ -140,7 +195,8 @@ type StoryData struct {
 // @Param        story body CreateStoryRequest true "Story creation request"
 // @Success      201 {object} model.StoryResponse "Story created successfully with both text and SSML"
 // @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
-// @Failure      400 {object} util.HttpError "Invalid request body"
+// @Failure      400 {object} util.HttpError "Invalid request body or unsupported language"
+// @Failure      429 {object} QuotaExceededResponse "Story quota exceeded"
 // @Failure      500 {object} util.HttpError "Internal server error"
 // @Router       /story [post]
 // CreateStory handles the creation of a new story
@@ -246,16 +302,33 @@ func (h *Story) CreateStory(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("✅ DEBUG: Request body parsed successfully: %+v", req)
 
-	err = h.storyGenerator.UploadMetadata(ctx, "", username, email, &helpers.MetadataRequest{
-		Country:     req.Country,
-		City:        req.City,
-		Religions:   req.Religions,
-		Preferences: req.Preferences,
-		Language:    req.Language,
-	})
+	metadata := &helpers.MetadataRequest{
+		Country:           req.Country,
+		City:              req.City,
+		Religions:         req.Religions,
+		Preferences:       req.Preferences,
+		Language:          req.Language,
+		Storybook:         req.Storybook,
+		BilingualLanguage: req.BilingualLanguage,
+		Dialogue:          req.Dialogue,
+		Soundscape:        req.Soundscape,
+	}
+	if err := metadata.Validate(); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.consumeQuota(w, ctx, email, model.QuotaStoryRequests) {
+		return
+	}
+
+	err = h.storyGenerator.UploadMetadata(ctx, "", username, email, metadata)
 
 	if err != nil {
 		log.Printf("❌ DEBUG: UploadMetadata failed: %v", err)
+		if refundErr := h.storyDB.RefundQuota(ctx, email, model.QuotaStoryRequests); refundErr != nil {
+			h.logger.Printf("ERROR: Failed to refund quota of %s: %v", email, refundErr)
+		}
 		h.sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -277,6 +350,38 @@ func (h *Story) sendJSONResponse(w http.ResponseWriter, statusCode int, data int
 	json.NewEncoder(w).Encode(data)
 }
 
+// QuotaExceededResponse is the body of a 429 response
+type QuotaExceededResponse struct {
+	Error   string    `json:"error"`
+	Limit   string    `json:"limit"`
+	Max     int       `json:"max"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// consumeQuota counts one use of the user's quota of kind.
+// It writes a 429 (or 500) response itself and returns false when the request must stop.
+func (h *Story) consumeQuota(w http.ResponseWriter, ctx context.Context, email, kind string) bool {
+	_, err := h.storyDB.ConsumeQuota(ctx, email, kind)
+	if err == nil {
+		return true
+	}
+	var exceeded *model.QuotaExceededError
+	if errors.As(err, &exceeded) {
+		h.logger.Printf("INFO: %s for %s", exceeded.Error(), email)
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(exceeded.ResetAt).Seconds())+1))
+		h.sendJSONResponse(w, http.StatusTooManyRequests, QuotaExceededResponse{
+			Error:   "Quota exceeded",
+			Limit:   exceeded.Limit,
+			Max:     exceeded.Max,
+			ResetAt: exceeded.ResetAt,
+		})
+		return false
+	}
+	h.logger.Printf("ERROR: Failed to check quota: %v", err)
+	h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to check quota")
+	return false
+}
+
 // sendErrorResponse sends an error response
 func (h *Story) sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
 	response := StoryResponse{
@@ -294,6 +399,7 @@ func (h *Story) sendErrorResponse(w http.ResponseWriter, statusCode int, message
 // @Param        Authorization header string true "Bearer token"
 // @Param        theme query string false "Theme filter (1, 2, or 3)"
 // @Param        limit query int false "Number of stories to return (default: 10)"
+// @Param        romanize query bool false "Also return titles and texts in Roman script, e.g. ISO 15919 for Telugu"
 // @Success      200 {array} StoryData
 // @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
 // @Failure      500 {object} util.HttpError "Internal server error"
@@ -392,6 +498,8 @@ func (h *Story) ListStories(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	romanize, _ := strconv.ParseBool(r.URL.Query().Get("romanize"))
+
 	logger.Printf("INFO: Requested theme: %s, limit: %d", theme, limit)
 
 	// Fetch theme data exactly like Python
@@ -529,11 +637,13 @@ func (h *Story) ListStories(w http.ResponseWriter, r *http.Request) {
 			audioSignedURL = audioURL
 		}
 
+		imageThumbSignedURL := h.signedStoryURL(story, "image_thumb_url")
+		imageMediumSignedURL := h.signedStoryURL(story, "image_medium_url")
+
 		// Extract story data exactly like Python
 		storyID := ""
 		title := ""
 		storyText := ""
-		audioType := "audio/wav"
 		storyTheme := ""
 
 		if idVal, ok := story["id"].(string); ok {
@@ -547,24 +657,38 @@ func (h *Story) ListStories(w http.ResponseWriter, r *http.Request) {
 		if textVal, ok := story["story_text"].(string); ok {
 			storyText = textVal
 		}
-		if audioTypeVal, ok := story["audio_type"].(string); ok {
-			audioType = audioTypeVal
-		}
 		if themeVal, ok := story["theme"].(string); ok {
 			storyTheme = themeVal
 		}
 
-		// Exactly like Python: stories_data.append({...})
-		storiesData = append(storiesData, StoryData{
-			StoryID:   storyID,
-			Title:     title,
-			StoryText: storyText,
-			Image:     imageSignedURL,
-			Audio:     audioSignedURL,
-			AudioType: audioType,
-			Theme:     storyTheme,
-			Language:  language,
-		})
+		storyData := StoryData{
+			StoryID:     storyID,
+			Title:       title,
+			StoryText:   storyText,
+			Image:       imageSignedURL,
+			ImageThumb:  imageThumbSignedURL,
+			ImageMedium: imageMediumSignedURL,
+			Audio:       audioSignedURL,
+			AudioType:   storyAudioType(story),
+			Theme:       storyTheme,
+			Language:    language,
+			Pages:       h.storyPages(story),
+			Paragraphs:  h.storyParagraphs(story),
+		}
+		storyData.AudioDuration, _ = story["audio_duration"].(float64)
+		storyData.Timing = h.signedStoryURL(story, "timing_url")
+		storyData.Captions = h.signedStoryURL(story, "captions_url")
+		storyData.Cast = storyCast(story)
+		storyData.Soundscape, _ = story["soundscape"].(string)
+		storyData.SoundscapeAudio = h.signedStoryURL(story, "soundscape_audio_url")
+		if romanize {
+			storyLanguage := language
+			if languageVal, ok := story["language"].(string); ok && languageVal != "" {
+				storyLanguage = languageVal
+			}
+			storyData.TitleRomanized, storyData.StoryTextRomanized = romanizedStory(story, storyLanguage)
+		}
+		storiesData = append(storiesData, storyData)
 	}
 
 	logger.Printf("INFO: Returning %d stories", len(storiesData))
@@ -573,6 +697,252 @@ func (h *Story) ListStories(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(storiesData)
 }
 
+// romanizedStory returns the title and text of a story in Roman script, transliterating stories
+// stored before romanized text was kept. Both are empty for languages written in Roman script.
+func romanizedStory(story map[string]interface{}, language string) (title, storyText string) {
+	title, _ = story["title_romanized"].(string)
+	if title == "" {
+		original, _ := story["title"].(string)
+		title, _ = util.Romanize(original, language)
+	}
+	storyText, _ = story["story_text_romanized"].(string)
+	if storyText == "" {
+		original, _ := story["story_text"].(string)
+		storyText, _ = util.Romanize(original, language)
+	}
+	return title, storyText
+}
+
+// signedStoryURL signs the blob path stored under key, returning "" when it is missing
+func (h *Story) signedStoryURL(story map[string]interface{}, key string) string {
+	blobPath, ok := story[key].(string)
+	if !ok || blobPath == "" {
+		return ""
+	}
+	signedURL, err := h.storageService.GenerateSignedURL(blobPath, 3600)
+	if err != nil {
+		h.logger.Printf("WARNING: Failed to sign %s: %v", key, err)
+		return ""
+	}
+	return signedURL
+}
+
+// storyPages reads the storybook pages of a story and signs each page image
+func (h *Story) storyPages(story map[string]interface{}) []StoryPageData {
+	rawPages, ok := story["pages"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var pages []StoryPageData
+	for _, rawPage := range rawPages {
+		page, ok := rawPage.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pageData := StoryPageData{}
+		if indexVal, ok := page["index"].(int64); ok {
+			pageData.Index = int(indexVal)
+		}
+		if textVal, ok := page["text"].(string); ok {
+			pageData.Text = textVal
+		}
+		if imageVal, ok := page["image_url"].(string); ok && imageVal != "" {
+			if signedURL, err := h.storageService.GenerateSignedURL(imageVal, 3600); err == nil {
+				pageData.Image = signedURL
+			}
+		}
+		pageData.ImageThumb = h.signedStoryURL(page, "image_thumb_url")
+		pageData.ImageMedium = h.signedStoryURL(page, "image_medium_url")
+		pages = append(pages, pageData)
+	}
+	return pages
+}
+
+// translationTimeout bounds translating a story and narrating the translation
+const translationTimeout = 3 * time.Minute
+
+// @Summary      Translate a story
+// @Description  Translates the title and text of a story into another language and narrates it in that language, reusing the story's illustrations. The translation is stored and later requests are served from it.
+// @Tags         Stories
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Story ID"
+// @Param        lang path string true "Target language, e.g. Telugu"
+// @Success      200 {object} StoryData "Cached translation"
+// @Success      201 {object} StoryData "Translation created"
+// @Failure      400 {object} util.HttpError "Unsupported language or story already in this language"
+// @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      404 {object} util.HttpError "Story not found"
+// @Failure      429 {object} QuotaExceededResponse "Regeneration quota exceeded"
+// @Failure      500 {object} util.HttpError "Internal server error"
+// @Router       /stories/{id}/translations/{lang} [post]
+// TranslateStory handles translating a story into another language
+func (h *Story) TranslateStory(w http.ResponseWriter, r *http.Request) {
+	_, email, tokenVersion, err := util.VerifyAuth(r)
+	if err != nil {
+		h.logger.Printf("WARNING: Invalid token: %v", err)
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout)
+	defer cancel()
+	userTokenVersion, err := h.storyDB.GetTokenVersion(ctx, email)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to get token version: %v", err)
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to get token version")
+		return
+	}
+	if err := util.VerifyUserTokenVersion(tokenVersion, userTokenVersion); err != nil {
+		h.logger.Printf("WARNING: Token version mismatch: %v", err)
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	vars := mux.Vars(r)
+	storyID, target := vars["id"], vars["lang"]
+	if err := languages.Validate(target); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Cached translations are free, so only a new translation counts against the quota
+	cached, err := h.storyDB.GetStoryTranslation(ctx, storyID, target)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to read translation: %v", err)
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to read translation")
+		return
+	}
+	if cached != nil {
+		h.sendJSONResponse(w, http.StatusOK, h.translationData(cached))
+		return
+	}
+	// The story is checked before the quota is used, so a bad request costs nothing
+	storyGenerator := helpers.NewStoryGenerationHelper(h.storyDB, h.storageService)
+	if _, _, err := storyGenerator.TranslationSource(ctx, storyID, target); err != nil {
+		h.sendTranslationError(w, storyID, target, err)
+		return
+	}
+	if !h.consumeQuota(w, ctx, email, model.QuotaRegenerations) {
+		return
+	}
+
+	translation, created, err := storyGenerator.TranslateStory(ctx, email, storyID, target)
+	// Only a translation this request created counts: a failed one, or one another request stored
+	// first, gives the use back
+	if err != nil || !created {
+		if refundErr := h.storyDB.RefundQuota(ctx, email, model.QuotaRegenerations); refundErr != nil {
+			h.logger.Printf("ERROR: Failed to refund quota of %s: %v", email, refundErr)
+		}
+	}
+	if err != nil {
+		h.sendTranslationError(w, storyID, target, err)
+		return
+	}
+	h.logger.Printf("INFO: %s translated story %s to %s", email, storyID, target)
+	statusCode := http.StatusOK
+	if created {
+		statusCode = http.StatusCreated
+	}
+	h.sendJSONResponse(w, statusCode, h.translationData(translation))
+}
+
+// sendTranslationError sends the response for an error of a story translation
+func (h *Story) sendTranslationError(w http.ResponseWriter, storyID, target string, err error) {
+	switch {
+	case errors.Is(err, helpers.ErrStoryNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, helpers.ErrSameLanguage):
+		h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Printf("ERROR: Failed to translate story %s to %s: %v", storyID, target, err)
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to translate story")
+	}
+}
+
+// translationData converts a stored translation to a StoryData with signed image and audio URLs
+func (h *Story) translationData(translation map[string]interface{}) StoryData {
+	data := StoryData{
+		StoryID:     fmt.Sprint(translation["id"]),
+		Image:       h.signedStoryURL(translation, "image_url"),
+		ImageThumb:  h.signedStoryURL(translation, "image_thumb_url"),
+		ImageMedium: h.signedStoryURL(translation, "image_medium_url"),
+		Audio:       h.signedStoryURL(translation, "audio_url"),
+		AudioType:   storyAudioType(translation),
+	}
+	data.Title, _ = translation["title"].(string)
+	data.StoryText, _ = translation["story_text"].(string)
+	data.Theme, _ = translation["theme"].(string)
+	data.Language, _ = translation["language"].(string)
+	data.TranslationOf, _ = translation["source_story_id"].(string)
+	data.TitleRomanized, data.StoryTextRomanized = romanizedStory(translation, data.Language)
+	data.AudioDuration, _ = translation["audio_duration"].(float64)
+	return data
+}
+
+// storyCast reads the voice of each speaker of a dialogue-narrated story
+func storyCast(story map[string]interface{}) map[string]string {
+	rawCast, ok := story["cast"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	cast := make(map[string]string, len(rawCast))
+	for speaker, voice := range rawCast {
+		if voiceName, ok := voice.(string); ok {
+			cast[speaker] = voiceName
+		}
+	}
+	return cast
+}
+
+// storyAudioType returns the MIME type of a story's audio. Stories stored before the audio was
+// sniffed record a bare format such as "wav", or nothing at all.
+func storyAudioType(story map[string]interface{}) string {
+	audioType, _ := story["audio_type"].(string)
+	if mimeType := audiofile.MIMEType(audioType); mimeType != "" {
+		return mimeType
+	}
+	if audioType != "" {
+		return audioType
+	}
+	return audiofile.MIMEType(audiofile.FormatWAV)
+}
+
+// storyParagraphs reads the paragraph pairs of a bilingual story and signs the audio of each segment
+func (h *Story) storyParagraphs(story map[string]interface{}) []StoryParagraphData {
+	rawParagraphs, ok := story["paragraphs"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var paragraphs []StoryParagraphData
+	for _, rawParagraph := range rawParagraphs {
+		paragraph, ok := rawParagraph.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paragraphData := StoryParagraphData{
+			Original:    h.paragraphSegment(paragraph["original"]),
+			Translation: h.paragraphSegment(paragraph["translation"]),
+		}
+		if indexVal, ok := paragraph["index"].(int64); ok {
+			paragraphData.Index = int(indexVal)
+		}
+		paragraphs = append(paragraphs, paragraphData)
+	}
+	return paragraphs
+}
+
+// paragraphSegment reads one side of a bilingual paragraph
+func (h *Story) paragraphSegment(raw interface{}) ParagraphSegmentData {
+	segment, ok := raw.(map[string]interface{})
+	if !ok {
+		return ParagraphSegmentData{}
+	}
+	segmentData := ParagraphSegmentData{Audio: h.signedStoryURL(segment, "audio_url")}
+	segmentData.Language, _ = segment["language"].(string)
+	segmentData.Text, _ = segment["text"].(string)
+	return segmentData
+}
+
 // UserProfile gets the user profile information for the authenticated user.
 // @Summary      Get User Profile
 // @Description  Gets the user profile information for the authenticated user.
@@ -745,9 +1115,30 @@ func (h *Story) DeleteUserProfile(w http.ResponseWriter, r *http.Request) {
 // @Param        theme_id query string true "Theme ID"
 // @Success      200 {object} map[string]bool "Audio reset successfully"
 // @Failure      401 {object} util.HttpError "Invalid or missing authorization token"
+// @Failure      429 {object} QuotaExceededResponse "Regeneration quota exceeded"
 // @Failure      500 {object} util.HttpError "Internal server error"
 // @Router       /reset-audio-by-theme-id [get]
 func (h *Story) ResetAudioByThemeID(w http.ResponseWriter, r *http.Request) {
+	_, email, tokenVersion, err := util.VerifyAuth(r)
+	if err != nil {
+		h.logger.Printf("WARNING: Invalid token: %v", err)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	userTokenVersion, err := h.storyDB.GetTokenVersion(ctx, email)
+	if err != nil {
+		h.logger.Printf("ERROR: Failed to get token version: %v", err)
+		http.Error(w, "Failed to get token version", http.StatusInternalServerError)
+		return
+	}
+	if err := util.VerifyUserTokenVersion(tokenVersion, userTokenVersion); err != nil {
+		h.logger.Printf("WARNING: Token version mismatch: %v", err)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
 	storyGenerator := helpers.NewStoryGenerationHelper(h.storyDB, h.storageService)
 	storyAudioCrud := helpers.NewStoryAudioCrud(h.storyDB, h.storageService, storyGenerator)
 	themeID := r.URL.Query().Get("theme_id")
@@ -755,6 +1146,9 @@ func (h *Story) ResetAudioByThemeID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Theme ID is required", http.StatusBadRequest)
 		return
 	}
+	if !h.consumeQuota(w, ctx, email, model.QuotaRegenerations) {
+		return
+	}
 	storyAudioCrud.ResetAudioByThemeID(r.Context(), themeID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Audio reset successfully"})
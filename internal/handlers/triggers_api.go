@@ -78,8 +78,8 @@ func (h *PubSubHandler) PubSubPushGeminiHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// If this is a Cloud Billing budget message, ignore until 90% threshold
-	if shouldIgnoreByBudget(data.CostAmount, data.BudgetAmount) {
+	// Ignore Cloud Billing budget messages below the level the budget policy records
+	if h.db.GetBudgetPolicy(r.Context()).IgnoreAlert("gemini", data.CostAmount, data.BudgetAmount) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -132,6 +132,10 @@ func (h *PubSubHandler) PubSubPushAudioHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if h.db.GetBudgetPolicy(r.Context()).IgnoreAlert("audio", data.CostAmount, data.BudgetAmount) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 	_, err = h.db.CreateAPIAudioTrigger(r.Context(), "audio", &database.APITriggerOptions{BudgetAmount: data.BudgetAmount, CostAmount: data.CostAmount, Threshold: data.Threshold, DisplayName: data.DisplayName, Currency: data.Currency})
 	if err != nil {
 		http.Error(w, "failed to create api audio trigger", http.StatusInternalServerError)
@@ -151,11 +155,3 @@ func extractBudgetNumbers(s []byte) (*PubSubData, error) {
 	log.Printf("DEBUG: Parsed data - costAmount: %f, budgetAmount: %f", data.CostAmount, data.BudgetAmount)
 	return &data, nil
 }
-
-func shouldIgnoreByBudget(cost float64, budget float64) bool {
-	// Guard against negative or zero budget
-	if budget <= 0 {
-		return false
-	}
-	return cost < 0.9*budget
-}
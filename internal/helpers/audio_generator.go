@@ -11,6 +11,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"rio-go-model/internal/helpers/cassette"
 )
 
 // AudioGenerator represents a service for generating audio from text
@@ -48,9 +50,7 @@ func NewAudioGenerator() *AudioGenerator {
 		logger:  log.New(log.Writer(), "[audio.generator] ", log.LstdFlags),
 		apiKey:  apiKey,
 		baseURL: "https://router.huggingface.co/fal-ai/fal-ai/kokoro/american-english", // fal.ai endpoint
-		client: &http.Client{
-			Timeout: 120 * time.Second, // Longer timeout for audio generation
-		},
+		client:  cassette.Open("fal").Client(120 * time.Second),                        // Longer timeout for audio generation
 	}
 }
 
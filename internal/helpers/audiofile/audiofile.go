@@ -0,0 +1,223 @@
+// Package audiofile recognizes the container of generated audio, measures it and joins chunks of it.
+// MP3 chunks are joined frame by frame without their ID3 tags and Xing/Info headers, and WAV
+// chunks are merged under a single header, so players see the real duration and can seek.
+package audiofile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Formats an audio file can be in
+const (
+	FormatMP3 = "mp3"
+	FormatWAV = "wav"
+	FormatOGG = "ogg"
+)
+
+// ErrUnknownFormat is returned for data that is not in a recognized audio format
+var ErrUnknownFormat = errors.New("unrecognized audio format")
+
+// Info describes an audio file
+type Info struct {
+	Format    string
+	MIMEType  string
+	Extension string
+	// Duration is zero when it cannot be measured, as for OGG
+	Duration time.Duration
+}
+
+// ToMap converts the info into the fields stored on a story document next to audio_url
+func (i Info) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"audio_type":      i.MIMEType,
+		"audio_extension": i.Extension,
+		"audio_duration":  i.Duration.Seconds(),
+	}
+}
+
+var mimeTypes = map[string]string{
+	FormatMP3: "audio/mpeg",
+	FormatWAV: "audio/wav",
+	FormatOGG: "audio/ogg",
+}
+
+// MIMEType returns the MIME type of a format, or "" if the format is not known
+func MIMEType(format string) string {
+	return mimeTypes[format]
+}
+
+// Sniff returns the format of data from its leading bytes, or "" if it is not recognized
+func Sniff(data []byte) string {
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return FormatWAV
+	case len(data) >= 4 && string(data[:4]) == "OggS":
+		return FormatOGG
+	case len(data) >= 10 && string(data[:3]) == "ID3":
+		return FormatMP3
+	case len(data) >= 4:
+		if _, ok := parseFrameHeader(data); ok {
+			return FormatMP3
+		}
+	}
+	return ""
+}
+
+// Probe sniffs the format of data and measures its duration
+func Probe(data []byte) (Info, error) {
+	format := Sniff(data)
+	info := Info{Format: format, MIMEType: mimeTypes[format], Extension: format}
+	switch format {
+	case FormatMP3:
+		frames, _ := mp3Frames(data)
+		if len(frames) == 0 {
+			return Info{}, fmt.Errorf("mp3 has no audio frames")
+		}
+		for _, frame := range frames {
+			info.Duration += frame.duration()
+		}
+	case FormatWAV:
+		wav, err := parseWAV(data)
+		if err != nil {
+			return Info{}, err
+		}
+		info.Duration = wav.duration()
+	case FormatOGG:
+	default:
+		return Info{}, ErrUnknownFormat
+	}
+	return info, nil
+}
+
+// Join combines audio chunks of one format into a single file of that format
+func Join(chunks [][]byte) ([]byte, error) {
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no audio chunks to join")
+	}
+	if len(chunks) == 1 {
+		return chunks[0], nil
+	}
+	format := Sniff(chunks[0])
+	for i, chunk := range chunks[1:] {
+		if f := Sniff(chunk); f != format {
+			return nil, fmt.Errorf("chunk %d is %q, want %q like the first chunk", i+2, f, format)
+		}
+	}
+	switch format {
+	case FormatMP3:
+		return joinMP3(chunks)
+	case FormatWAV:
+		return joinWAV(chunks)
+	}
+	return nil, fmt.Errorf("cannot join %q audio: %w", format, ErrUnknownFormat)
+}
+
+// joinMP3 concatenates the audio frames of every chunk, leaving out tags and VBR header frames
+func joinMP3(chunks [][]byte) ([]byte, error) {
+	var joined bytes.Buffer
+	for i, chunk := range chunks {
+		frames, _ := mp3Frames(chunk)
+		if len(frames) == 0 {
+			return nil, fmt.Errorf("mp3 chunk %d has no audio frames", i+1)
+		}
+		for _, frame := range frames {
+			joined.Write(frame.data)
+		}
+	}
+	return joined.Bytes(), nil
+}
+
+// joinWAV merges the PCM data of every chunk under one header; all chunks must share a sample format
+func joinWAV(chunks [][]byte) ([]byte, error) {
+	var first *wavFile
+	var pcm bytes.Buffer
+	for i, chunk := range chunks {
+		wav, err := parseWAV(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("wav chunk %d: %v", i+1, err)
+		}
+		if first == nil {
+			first = wav
+		} else if !bytes.Equal(wav.format, first.format) {
+			return nil, fmt.Errorf("wav chunk %d has a different sample format", i+1)
+		}
+		pcm.Write(wav.data)
+	}
+	return buildWAV(first.format, pcm.Bytes()), nil
+}
+
+// wavFile is the sample format and PCM data of a WAV file
+type wavFile struct {
+	// format is the body of the fmt chunk
+	format []byte
+	data   []byte
+}
+
+// byteRate is the number of data bytes per second of audio
+func (w *wavFile) byteRate() uint32 {
+	return binary.LittleEndian.Uint32(w.format[8:12])
+}
+
+func (w *wavFile) duration() time.Duration {
+	rate := w.byteRate()
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(int64(len(w.data)) * int64(time.Second) / int64(rate))
+}
+
+// parseWAV reads the fmt and data chunks of a RIFF WAVE file, skipping any other chunks.
+// A data chunk whose size runs past the end of the file, as streamed WAVs have, is cut to the file.
+func parseWAV(data []byte) (*wavFile, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a wav file")
+	}
+	wav := &wavFile{}
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := offset + 8
+		end := body + size
+		if end > len(data) || end < body {
+			end = len(data)
+		}
+		switch id {
+		case "fmt ":
+			wav.format = data[body:end]
+		case "data":
+			wav.data = data[body:end]
+		}
+		// Chunks are padded to an even size
+		offset = end + size%2
+	}
+	if len(wav.format) < 16 {
+		return nil, fmt.Errorf("wav file has no fmt chunk")
+	}
+	if wav.data == nil {
+		return nil, fmt.Errorf("wav file has no data chunk")
+	}
+	return wav, nil
+}
+
+// buildWAV writes a RIFF WAVE file with one fmt and one data chunk
+func buildWAV(format, pcm []byte) []byte {
+	var buf bytes.Buffer
+	pad := len(pcm) % 2
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+len(format)+8+len(pcm)+pad))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(format)))
+	buf.Write(format)
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	if pad == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,161 @@
+package audiofile
+
+import (
+	"bytes"
+	"time"
+)
+
+// MPEG audio versions, from bits 19-20 of the frame header
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// bitrates in kbps, indexed by [MPEG-1][layer][bitrate index]; layer 1 is index 3
+var bitrates = map[bool][4][16]int{
+	true: {
+		1: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		2: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		3: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+	},
+	false: {
+		1: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		2: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		3: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+	},
+}
+
+// sampleRates in Hz, indexed by [version][sample rate index]
+var sampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// frameHeader is the decoded 4-byte header of an MPEG audio frame
+type frameHeader struct {
+	version    int
+	layer      int // 1 is Layer III, 2 is Layer II, 3 is Layer I, as in the header bits
+	protected  bool
+	sampleRate int
+	mono       bool
+	length     int
+	samples    int
+}
+
+// parseFrameHeader decodes the frame header at the start of data
+func parseFrameHeader(data []byte) (frameHeader, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return frameHeader{}, false
+	}
+	h := frameHeader{
+		version:   int(data[1]>>3) & 3,
+		layer:     int(data[1]>>1) & 3,
+		protected: data[1]&1 == 0,
+		mono:      data[3]>>6 == 3,
+	}
+	bitrateIndex := int(data[2] >> 4)
+	sampleRateIndex := int(data[2]>>2) & 3
+	padding := int(data[2]>>1) & 1
+	if h.version == 1 || h.layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return frameHeader{}, false
+	}
+	bitrate := bitrates[h.version == mpeg1][h.layer][bitrateIndex] * 1000
+	h.sampleRate = sampleRates[h.version][sampleRateIndex]
+
+	switch {
+	case h.layer == 3: // Layer I
+		h.samples = 384
+		h.length = (12*bitrate/h.sampleRate + padding) * 4
+	case h.layer == 2 || h.version == mpeg1: // Layer II, or Layer III of MPEG-1
+		h.samples = 1152
+		h.length = 144*bitrate/h.sampleRate + padding
+	default: // Layer III of MPEG-2 and 2.5
+		h.samples = 576
+		h.length = 72*bitrate/h.sampleRate + padding
+	}
+	return h, h.length > 4
+}
+
+// mp3Frame is one complete MPEG audio frame
+type mp3Frame struct {
+	header frameHeader
+	data   []byte
+}
+
+func (f mp3Frame) duration() time.Duration {
+	return time.Duration(f.header.samples) * time.Second / time.Duration(f.header.sampleRate)
+}
+
+// isVBRHeader reports whether the frame is a Xing, Info or VBRI header frame, which holds no audio
+// but tells players the length of the stream it starts
+func (f mp3Frame) isVBRHeader() bool {
+	// The Xing tag follows the side information, whose size depends on the version and channels
+	sideInfo := 32
+	switch {
+	case f.header.version == mpeg1 && f.header.mono:
+		sideInfo = 17
+	case f.header.version != mpeg1 && !f.header.mono:
+		sideInfo = 17
+	case f.header.version != mpeg1:
+		sideInfo = 9
+	}
+	offset := 4 + sideInfo
+	if f.header.protected {
+		offset += 2
+	}
+	if len(f.data) >= offset+4 {
+		tag := string(f.data[offset : offset+4])
+		if tag == "Xing" || tag == "Info" {
+			return true
+		}
+	}
+	return len(f.data) >= 36+4 && string(f.data[36:40]) == "VBRI"
+}
+
+// mp3Frames returns the audio frames of an MP3 stream, skipping ID3v2 and ID3v1 tags, VBR header
+// frames and any bytes between frames. skipped counts the bytes left out.
+func mp3Frames(data []byte) (frames []mp3Frame, skipped int) {
+	offset := 0
+	for offset+4 <= len(data) {
+		rest := data[offset:]
+		if size, ok := id3v2Size(rest); ok {
+			offset += size
+			skipped += size
+			continue
+		}
+		if len(rest) == 128 && bytes.HasPrefix(rest, []byte("TAG")) {
+			skipped += 128
+			break
+		}
+		header, ok := parseFrameHeader(rest)
+		if !ok || header.length > len(rest) {
+			offset++
+			skipped++
+			continue
+		}
+		frame := mp3Frame{header: header, data: rest[:header.length]}
+		offset += header.length
+		if len(frames) == 0 && frame.isVBRHeader() {
+			skipped += header.length
+			continue
+		}
+		frames = append(frames, frame)
+	}
+	return frames, skipped + max(len(data)-offset, 0)
+}
+
+// id3v2Size returns the size of the ID3v2 tag at the start of data, including its header and footer
+func id3v2Size(data []byte) (int, bool) {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return 0, false
+	}
+	// The size is syncsafe: 7 bits per byte
+	size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10
+	}
+	return min(size, len(data)), true
+}
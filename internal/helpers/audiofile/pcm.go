@@ -0,0 +1,59 @@
+package audiofile
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PCM is 16-bit linear PCM audio with the samples of all channels interleaved
+type PCM struct {
+	SampleRate int
+	Channels   int
+	Samples    []int16
+}
+
+// Frames is the number of samples per channel
+func (p *PCM) Frames() int {
+	return len(p.Samples) / p.Channels
+}
+
+// DecodePCM reads the samples of a 16-bit PCM WAV file
+func DecodePCM(data []byte) (*PCM, error) {
+	wav, err := parseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	audioFormat := binary.LittleEndian.Uint16(wav.format[0:2])
+	channels := int(binary.LittleEndian.Uint16(wav.format[2:4]))
+	sampleRate := int(binary.LittleEndian.Uint32(wav.format[4:8]))
+	bitsPerSample := binary.LittleEndian.Uint16(wav.format[14:16])
+	if audioFormat != 1 || bitsPerSample != 16 {
+		return nil, fmt.Errorf("wav is not 16-bit PCM (format %d, %d bits)", audioFormat, bitsPerSample)
+	}
+	if channels < 1 || sampleRate < 1 {
+		return nil, fmt.Errorf("wav has %d channels at %d Hz", channels, sampleRate)
+	}
+	samples := make([]int16, len(wav.data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(wav.data[2*i:]))
+	}
+	// Drop a trailing partial frame
+	samples = samples[:len(samples)/channels*channels]
+	return &PCM{SampleRate: sampleRate, Channels: channels, Samples: samples}, nil
+}
+
+// WAV encodes the samples as a 16-bit PCM WAV file
+func (p *PCM) WAV() []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:2], 1)
+	binary.LittleEndian.PutUint16(format[2:4], uint16(p.Channels))
+	binary.LittleEndian.PutUint32(format[4:8], uint32(p.SampleRate))
+	binary.LittleEndian.PutUint32(format[8:12], uint32(p.SampleRate*p.Channels*2))
+	binary.LittleEndian.PutUint16(format[12:14], uint16(p.Channels*2))
+	binary.LittleEndian.PutUint16(format[14:16], 16)
+	data := make([]byte, 2*len(p.Samples))
+	for i, sample := range p.Samples {
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(sample))
+	}
+	return buildWAV(format, data)
+}
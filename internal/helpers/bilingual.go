@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/util"
+)
+
+// SegmentParagraphs splits the story text into its non-empty paragraphs, trimmed
+func SegmentParagraphs(storyText string) []string {
+	var paragraphs []string
+	for _, span := range paragraphSpans(storyText) {
+		paragraphs = append(paragraphs, storyText[span.start:span.end])
+	}
+	return paragraphs
+}
+
+// generateBilingualParagraphs aligns every paragraph of the story with its translation into second
+// and narrates both sides of each pair separately, with at most BilingualAudioWorkers in flight.
+// Paragraphs are translated one to one, so the pairs stay aligned however the translator rewords them.
+// Each language is read in one voice, picked once under one budget decision, so the narrator does not
+// change from paragraph to paragraph. A segment whose audio fails keeps an empty audio_url; the call
+// only fails if every segment fails.
+func (sgh *StoryGenerationHelper) generateBilingualParagraphs(ctx context.Context, storyText, language, second, theme string) ([]model.StoryParagraph, error) {
+	originals := SegmentParagraphs(storyText)
+	if len(originals) == 0 {
+		return nil, fmt.Errorf("story text has no paragraphs")
+	}
+	sgh.logger.Infof("Generating %s/%s bilingual story with %d paragraphs", language, second, len(originals))
+
+	translations, err := sgh.translator.Translate(ctx, originals, languages.Lookup(language).Code(), languages.Lookup(second).Code())
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate paragraphs: %v", err)
+	}
+
+	paragraphs := make([]model.StoryParagraph, len(originals))
+	var segments []*model.ParagraphSegment
+	for i := range originals {
+		paragraphs[i] = model.StoryParagraph{
+			Index:       i,
+			Original:    model.ParagraphSegment{Language: language, Text: originals[i]},
+			Translation: model.ParagraphSegment{Language: second, Text: translations[i]},
+		}
+		segments = append(segments, &paragraphs[i].Original, &paragraphs[i].Translation)
+	}
+
+	decision := sgh.narrationVoice(ctx)
+	voices := map[string]narrationVoice{
+		language: sgh.pinVoice(decision, language, theme),
+		second:   sgh.pinVoice(decision, second, theme),
+	}
+
+	semaphore := make(chan struct{}, max(sgh.settings.BilingualAudioWorkers, 1))
+	errs := make([]error, len(segments))
+	var wg sync.WaitGroup
+	for i, segment := range segments {
+		wg.Add(1)
+		util.GoroutineWithRecovery(func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			audioData, _, _, err := sgh.narrateStoryIn(ctx, segment.Text, segment.Language, theme, voices[segment.Language], false, false)
+			if err != nil {
+				errs[i] = err
+				sgh.logger.Errorf("Failed to narrate %s paragraph %d: %v", segment.Language, i/2, err)
+				return
+			}
+			audioFields, err := uploadAudio(sgh.storageService, audioData)
+			if err != nil {
+				errs[i] = err
+				sgh.logger.Errorf("Failed to upload %s paragraph %d audio: %v", segment.Language, i/2, err)
+				return
+			}
+			segment.AudioURL = audioFields["audio_url"].(string)
+		})
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(segments) {
+		return nil, fmt.Errorf("all %d paragraph segments failed: %v", failed, errs[0])
+	}
+	if failed > 0 {
+		sgh.logger.Warnf("%d of %d bilingual paragraph segments have no audio", failed, len(segments))
+	}
+	return paragraphs, nil
+}
+
+// paragraphMaps converts bilingual paragraphs into the shape stored on the story document
+func paragraphMaps(paragraphs []model.StoryParagraph) []map[string]interface{} {
+	maps := make([]map[string]interface{}, 0, len(paragraphs))
+	for _, paragraph := range paragraphs {
+		maps = append(maps, paragraph.ToMap())
+	}
+	return maps
+}
+
+// validateBilingual checks the second language of a bilingual story
+func validateBilingual(language, second string) error {
+	if second == "" {
+		return nil
+	}
+	if err := languages.Validate(second); err != nil {
+		return err
+	}
+	if strings.EqualFold(language, second) {
+		return fmt.Errorf("bilingual language must differ from the story language %q", language)
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+// Package cassette records the traffic of provider clients to disk and replays it, so provider
+// behavior, including error paths, can be tested offline and deterministically.
+//
+// CASSETTE_MODE=record saves every request/response pair to CASSETTE_DIR/<name>.json with API keys,
+// tokens and cookies removed; CASSETTE_MODE=replay serves them back without touching the network.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"rio-go-model/configs"
+)
+
+// Redacted replaces secrets in recorded requests and responses
+const Redacted = "REDACTED"
+
+// ErrNotRecorded is returned in replay mode for a request the cassette has no response for
+var ErrNotRecorded = errors.New("cassette: no recorded interaction")
+
+// sensitiveHeaders are replaced by Redacted; response cookies are dropped
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "X-Goog-Api-Key", "X-Api-Key", "Api-Key", "Cookie"}
+
+// sensitiveParams are query parameters replaced by Redacted
+var sensitiveParams = []string{"key", "api_key", "apikey", "token", "access_token"}
+
+// secretEnv names environment variables whose values are removed wherever they appear
+var secretEnv = []string{"HUGGINGFACE_TOKEN", "GEMINI_API_KEY", "GOOGLE_API_KEY", "FAL_KEY"}
+
+// Request is a recorded request
+type Request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// BodyEncoding is "base64" for binary bodies
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// Response is a recorded response, or the error the call failed with
+type Response struct {
+	Status       int               `json:"status,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	BodyEncoding string            `json:"body_encoding,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// Interaction is one request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette holds the interactions of one provider. A nil *Cassette passes calls through.
+type Cassette struct {
+	Name         string        `json:"name"`
+	Interactions []Interaction `json:"interactions"`
+
+	mode    string
+	path    string
+	secrets []string
+	mu      sync.Mutex
+	used    []bool
+}
+
+var (
+	openMu sync.Mutex
+	opened = make(map[string]*Cassette)
+)
+
+// Open returns the cassette of a provider for the configured CASSETTE_MODE, or nil when the mode is off.
+// Clients of the same provider share one cassette.
+func Open(name string) *Cassette {
+	settings := configs.GetSettings()
+	if settings.CassetteMode == "" || settings.CassetteMode == configs.CassetteModeOff {
+		return nil
+	}
+	openMu.Lock()
+	defer openMu.Unlock()
+	key := settings.CassetteMode + ":" + filepath.Join(settings.CassetteDir, name)
+	if c, ok := opened[key]; ok {
+		return c
+	}
+	c, err := Load(settings.CassetteDir, name, settings.CassetteMode)
+	if err != nil {
+		// Every call will fail with ErrNotRecorded, which names the missing cassette
+		log.Printf("Warning: %v", err)
+		c = &Cassette{Name: name, mode: settings.CassetteMode, path: filepath.Join(settings.CassetteDir, name+".json"), secrets: envSecrets()}
+	}
+	opened[key] = c
+	return c
+}
+
+// Load reads a cassette for replay, or starts an empty one for recording
+func Load(dir, name, mode string) (*Cassette, error) {
+	c := &Cassette{Name: name, mode: mode, path: filepath.Join(dir, name+".json"), secrets: envSecrets()}
+	if mode != configs.CassetteModeReplay {
+		return c, nil
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c, fmt.Errorf("error reading cassette %s: %v", c.path, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return c, fmt.Errorf("error decoding cassette %s: %v", c.path, err)
+	}
+	c.used = make([]bool, len(c.Interactions))
+	return c, nil
+}
+
+// Replaying reports whether calls are served from the cassette. Constructors use it to skip credentials.
+func (c *Cassette) Replaying() bool {
+	return c != nil && c.mode == configs.CassetteModeReplay
+}
+
+// Client returns an HTTP client with the given timeout whose requests go through the cassette
+func (c *Cassette) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: c.Transport(nil)}
+}
+
+// Transport wraps base, or http.DefaultTransport when base is nil, with the cassette
+func (c *Cassette) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if c == nil {
+		return base
+	}
+	return &transport{cassette: c, base: base}
+}
+
+// Do records or replays a call that does not go over HTTP, such as a gRPC method. request is
+// encoded as JSON to match recordings and response must be JSON encodable.
+func Do[T any](c *Cassette, method string, request interface{}, call func() (T, error)) (T, error) {
+	if c == nil {
+		return call()
+	}
+	var zero T
+	body, err := json.Marshal(request)
+	if err != nil {
+		return zero, fmt.Errorf("cassette: error encoding %s request: %v", method, err)
+	}
+	recorded := c.sanitizeRequest(Request{Method: "CALL", URL: method, Body: string(body)})
+
+	if c.Replaying() {
+		response, err := c.find(recorded)
+		if err != nil {
+			return zero, err
+		}
+		if response.Error != "" {
+			return zero, errors.New(response.Error)
+		}
+		var result T
+		if err := json.Unmarshal([]byte(response.Body), &result); err != nil {
+			return zero, fmt.Errorf("cassette: error decoding %s response: %v", method, err)
+		}
+		return result, nil
+	}
+
+	result, callErr := call()
+	response := Response{}
+	if callErr != nil {
+		response.Error = callErr.Error()
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return result, fmt.Errorf("cassette: error encoding %s response: %v", method, err)
+		}
+		response.Body = string(data)
+	}
+	c.record(recorded, response)
+	return result, callErr
+}
+
+// transport records or replays HTTP requests
+type transport struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.cassette
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cassette: error reading request body: %v", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := c.sanitizeRequest(newRequest(req, body))
+
+	if c.Replaying() {
+		response, err := c.find(recorded)
+		if err != nil {
+			return nil, err
+		}
+		if response.Error != "" {
+			return nil, errors.New(response.Error)
+		}
+		return response.httpResponse(req)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		c.record(recorded, Response{Error: err.Error()})
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: error reading response body: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	c.record(recorded, c.sanitizeResponse(newResponse(resp, respBody)))
+	return resp, nil
+}
+
+func newRequest(req *http.Request, body []byte) Request {
+	r := Request{Method: req.Method, URL: req.URL.String(), Headers: make(map[string]string)}
+	for name := range req.Header {
+		r.Headers[name] = req.Header.Get(name)
+	}
+	r.Body, r.BodyEncoding = encodeBody(body)
+	return r
+}
+
+func newResponse(resp *http.Response, body []byte) Response {
+	r := Response{Status: resp.StatusCode, Headers: make(map[string]string)}
+	for name := range resp.Header {
+		if name == "Set-Cookie" {
+			continue
+		}
+		r.Headers[name] = resp.Header.Get(name)
+	}
+	r.Body, r.BodyEncoding = encodeBody(body)
+	return r
+}
+
+// httpResponse rebuilds the recorded response for req
+func (r Response) httpResponse(req *http.Request) (*http.Response, error) {
+	body, err := decodeBody(r.Body, r.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// find returns the first unused interaction matching the request. Once all matches are used the
+// last one is served again, so retries and repeated calls keep working.
+func (c *Cassette) find(req Request) (Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := -1
+	for i, interaction := range c.Interactions {
+		if !interaction.Request.matches(req) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction.Response, nil
+		}
+		last = i
+	}
+	if last >= 0 {
+		return c.Interactions[last].Response, nil
+	}
+	return Response{}, fmt.Errorf("%w for %s %s in %s", ErrNotRecorded, req.Method, req.URL, c.path)
+}
+
+func (r Request) matches(other Request) bool {
+	return r.Method == other.Method && r.URL == other.URL && r.Body == other.Body
+}
+
+// record appends an interaction and saves the cassette, so nothing is lost when the process exits
+func (c *Cassette) record(req Request, resp Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, Interaction{Request: req, Response: resp})
+	if err := c.save(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cassette %s: %v", c.Name, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("error creating cassette directory: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing cassette %s: %v", c.path, err)
+	}
+	return nil
+}
+
+// sanitizeRequest removes credentials from headers, query parameters and the body
+func (c *Cassette) sanitizeRequest(r Request) Request {
+	for _, name := range sensitiveHeaders {
+		if _, ok := r.Headers[http.CanonicalHeaderKey(name)]; ok {
+			r.Headers[http.CanonicalHeaderKey(name)] = Redacted
+		}
+	}
+	if u, err := url.Parse(r.URL); err == nil && u.RawQuery != "" {
+		query := u.Query()
+		for _, param := range sensitiveParams {
+			if query.Has(param) {
+				query.Set(param, Redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+		r.URL = u.String()
+	}
+	r.URL = c.redact(r.URL)
+	if r.BodyEncoding == "" {
+		r.Body = c.redact(r.Body)
+	}
+	return r
+}
+
+// sanitizeResponse removes secrets echoed back in a response body
+func (c *Cassette) sanitizeResponse(r Response) Response {
+	if r.BodyEncoding == "" {
+		r.Body = c.redact(r.Body)
+	}
+	return r
+}
+
+func (c *Cassette) redact(text string) string {
+	for _, secret := range c.secrets {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// envSecrets returns the values of secretEnv that are long enough to replace safely
+func envSecrets() []string {
+	var secrets []string
+	for _, name := range secretEnv {
+		if value := os.Getenv(name); len(value) >= 8 {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding != "base64" {
+		return []byte(body), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: error decoding body: %v", err)
+	}
+	return data, nil
+}
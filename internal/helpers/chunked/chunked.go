@@ -0,0 +1,129 @@
+// Package chunked synthesizes the chunks of a long narration concurrently. Every provider has its own
+// limit on calls in flight, shared by all the stories being narrated, and each chunk is retried on its
+// own, so one failed call no longer throws the whole story away. Results come back in chunk order.
+package chunked
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Options controls one chunked synthesis
+type Options struct {
+	// Provider names the service the chunks are sent to; its calls share one concurrency limit
+	Provider string
+	// Concurrency is the most calls in flight to the provider, across all syntheses
+	Concurrency int
+	// Attempts is how many times a chunk is tried before the synthesis fails
+	Attempts int
+	// Timeout caps the whole synthesis, retries and waiting for a slot included
+	Timeout time.Duration
+	// Backoff is the wait before the first retry of a chunk, doubled for every retry after it
+	Backoff time.Duration
+}
+
+// SynthesizeFunc synthesizes chunk i and returns its audio
+type SynthesizeFunc func(ctx context.Context, i int) ([]byte, error)
+
+var (
+	slotsMu sync.Mutex
+	slots   = map[string]chan struct{}{}
+)
+
+// providerSlots returns the semaphore of the provider, created with size slots on first use
+func providerSlots(provider string, size int) chan struct{} {
+	slotsMu.Lock()
+	defer slotsMu.Unlock()
+	if slots[provider] == nil {
+		slots[provider] = make(chan struct{}, size)
+	}
+	return slots[provider]
+}
+
+// Synthesize runs synthesize for chunks 0..n-1 and returns their audio in order. The first chunk that
+// still fails after its attempts, or the timeout, cancels the chunks still running.
+func Synthesize(ctx context.Context, options Options, n int, synthesize SynthesizeFunc) ([][]byte, error) {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	if options.Attempts < 1 {
+		options.Attempts = 1
+	}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	started := time.Now()
+	sem := providerSlots(options.Provider, options.Concurrency)
+	results := make([][]byte, n)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			audio, err := synthesizeChunk(ctx, options, sem, i, synthesize)
+			if err != nil {
+				fail(fmt.Errorf("chunk %d: %v", i+1, err))
+				return
+			}
+			results[i] = audio
+		}(i)
+	}
+	wg.Wait()
+	record(options.Provider, n, time.Since(started), firstErr == nil)
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// synthesizeChunk tries chunk i until it succeeds, runs out of attempts or the context ends
+func synthesizeChunk(ctx context.Context, options Options, sem chan struct{}, i int, synthesize SynthesizeFunc) ([]byte, error) {
+	backoff := options.Backoff
+	var lastErr error
+	for attempt := 1; attempt <= options.Attempts; attempt++ {
+		if attempt > 1 {
+			recordRetry(options.Provider)
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%v (after %d attempts: %v)", ctx.Err(), attempt-1, lastErr)
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%v (after %d attempts: %v)", ctx.Err(), attempt-1, lastErr)
+			}
+			return nil, ctx.Err()
+		}
+		started := time.Now()
+		audio, err := synthesize(ctx, i)
+		<-sem
+		recordChunk(options.Provider, time.Since(started))
+		if err == nil {
+			return audio, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
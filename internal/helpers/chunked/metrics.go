@@ -0,0 +1,83 @@
+package chunked
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats are the chunked synthesis metrics of one provider since the process started
+type Stats struct {
+	Provider string `json:"provider"`
+	// Syntheses is the number of chunked narrations, Failures how many of them failed
+	Syntheses int64 `json:"syntheses"`
+	Failures  int64 `json:"failures"`
+	// Chunks is the number of provider calls made, Retries how many of them repeated a failed chunk
+	Chunks  int64 `json:"chunks"`
+	Retries int64 `json:"retries"`
+	// MaxChunks is the most chunks one narration was split into
+	MaxChunks int `json:"max_chunks"`
+	// Chunk and synthesis latencies in milliseconds
+	AvgChunkMillis     int64 `json:"avg_chunk_ms"`
+	MaxChunkMillis     int64 `json:"max_chunk_ms"`
+	AvgSynthesisMillis int64 `json:"avg_synthesis_ms"`
+	MaxSynthesisMillis int64 `json:"max_synthesis_ms"`
+
+	chunkTotal     time.Duration
+	synthesisTotal time.Duration
+}
+
+var (
+	metricsMu sync.Mutex
+	metrics   = map[string]*Stats{}
+)
+
+// statsFor returns the stats of the provider; metricsMu must be held
+func statsFor(provider string) *Stats {
+	if metrics[provider] == nil {
+		metrics[provider] = &Stats{Provider: provider}
+	}
+	return metrics[provider]
+}
+
+func recordChunk(provider string, latency time.Duration) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	stats := statsFor(provider)
+	stats.Chunks++
+	stats.chunkTotal += latency
+	stats.AvgChunkMillis = (stats.chunkTotal / time.Duration(stats.Chunks)).Milliseconds()
+	stats.MaxChunkMillis = max(stats.MaxChunkMillis, latency.Milliseconds())
+}
+
+func recordRetry(provider string) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	statsFor(provider).Retries++
+}
+
+func record(provider string, chunks int, latency time.Duration, ok bool) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	stats := statsFor(provider)
+	stats.Syntheses++
+	if !ok {
+		stats.Failures++
+	}
+	stats.MaxChunks = max(stats.MaxChunks, chunks)
+	stats.synthesisTotal += latency
+	stats.AvgSynthesisMillis = (stats.synthesisTotal / time.Duration(stats.Syntheses)).Milliseconds()
+	stats.MaxSynthesisMillis = max(stats.MaxSynthesisMillis, latency.Milliseconds())
+}
+
+// Snapshot returns the metrics of every provider, sorted by provider
+func Snapshot() []Stats {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	snapshot := make([]Stats, 0, len(metrics))
+	for _, stats := range metrics {
+		snapshot = append(snapshot, *stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Provider < snapshot[j].Provider })
+	return snapshot
+}
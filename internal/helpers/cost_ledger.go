@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/util"
+)
+
+// costScopeKey is the context key under which the current cost scope is stored
+type costScopeKey struct{}
+
+// costScope identifies who and what a provider call is paid for
+type costScope struct {
+	Email   string
+	Theme   string
+	StoryID string
+}
+
+// withCostScope returns a context whose provider calls are charged to scope
+func withCostScope(ctx context.Context, scope costScope) context.Context {
+	return context.WithValue(ctx, costScopeKey{}, scope)
+}
+
+// costScopeFrom returns the cost scope of the context, empty when none is set
+func costScopeFrom(ctx context.Context) costScope {
+	scope, _ := ctx.Value(costScopeKey{}).(costScope)
+	return scope
+}
+
+// recordCost writes one provider call to the cost ledger without blocking generation
+func (sgh *StoryGenerationHelper) recordCost(ctx context.Context, provider, modelName, unitType string, units int64) {
+	scope := costScopeFrom(ctx)
+	entry := &model.CostEntry{
+		StoryID:       scope.StoryID,
+		Email:         scope.Email,
+		Theme:         scope.Theme,
+		Provider:      provider,
+		Model:         modelName,
+		UnitType:      unitType,
+		Units:         units,
+		EstimatedCost: sgh.settings.EstimateCost(provider, modelName, units),
+		CreatedAt:     time.Now().UTC(),
+	}
+	util.GoroutineWithRecovery(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := sgh.storyDatabase.CreateCostEntry(ctx, entry); err != nil {
+			sgh.logger.Errorf("Failed to record cost for %s/%s: %v", provider, modelName, err)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+// Package dialogue splits a story into narration and quoted speech, works out who speaks each
+// quote and casts a voice for every speaker, so dialogue can be read in the voices of the characters.
+package dialogue
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Narrator is the speaker of every line outside quotation marks, and of quotes nobody could be found for
+const Narrator = "Narrator"
+
+// Line is a stretch of narration or one quote
+type Line struct {
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+	// Quoted is true for speech; its Speaker is empty until it is attributed
+	Quoted bool `json:"quoted"`
+}
+
+// closingQuotes maps each opening quotation mark to the mark that closes it.
+// Single quotes are left out because they double as apostrophes.
+var closingQuotes = map[rune]rune{
+	'"': '"',
+	'“': '”',
+	'„': '“',
+	'«': '»',
+}
+
+// Split cuts text into narration and quoted speech, in order. The quotation marks are dropped and an
+// unclosed quote is read as narration.
+func Split(text string) []Line {
+	var lines []Line
+	add := func(segment string, quoted bool) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			return
+		}
+		line := Line{Text: segment, Quoted: quoted}
+		if !quoted {
+			line.Speaker = Narrator
+		}
+		lines = append(lines, line)
+	}
+	start := 0
+	for i, r := range text {
+		if i < start {
+			continue
+		}
+		closing, ok := closingQuotes[r]
+		if !ok {
+			continue
+		}
+		open := i + len(string(r))
+		end := strings.IndexRune(text[open:], closing)
+		if end < 0 {
+			break
+		}
+		add(text[start:i], false)
+		add(text[open:open+end], true)
+		start = open + end + len(string(closing))
+	}
+	add(text[start:], false)
+	return lines
+}
+
+// Quotes returns the indexes of the quoted lines
+func Quotes(lines []Line) []int {
+	var quotes []int
+	for i, line := range lines {
+		if line.Quoted {
+			quotes = append(quotes, i)
+		}
+	}
+	return quotes
+}
+
+// speechVerbs introduce or follow a quote in English dialogue tags
+const speechVerbs = `(?:said|says|asked|cried|shouted|whispered|replied|called|answered|laughed|exclaimed|added|sang|yelled)`
+
+var (
+	// tagAfter matches `said Pip` or `Pip said` at the start of the narration after a quote
+	tagAfter = regexp.MustCompile(`^[\s,.!?;:—-]*(?:` + speechVerbs + `\s+(\p{Lu}\p{L}+)|(\p{Lu}\p{L}+)\s+` + speechVerbs + `)\b`)
+	// tagBefore matches `Pip said` at the end of the narration before a quote
+	tagBefore = regexp.MustCompile(`(\p{Lu}\p{L}+)\s+` + speechVerbs + `[\s,:]*$`)
+)
+
+// pronouns are not names, so a quote they introduce stays unattributed
+var pronouns = map[string]bool{"He": true, "She": true, "They": true, "It": true, "I": true, "We": true, "You": true}
+
+// Attribute fills in the speaker of quotes with an English dialogue tag such as `"Look!" said Pip`
+// or `Mira asked, "Where?"`. Quotes that already have a speaker are left alone.
+func Attribute(lines []Line) {
+	for i := range lines {
+		if !lines[i].Quoted || lines[i].Speaker != "" {
+			continue
+		}
+		if i+1 < len(lines) && !lines[i+1].Quoted {
+			if match := tagAfter.FindStringSubmatch(lines[i+1].Text); match != nil {
+				if name := match[1] + match[2]; !pronouns[name] {
+					lines[i].Speaker = name
+					continue
+				}
+			}
+		}
+		if i > 0 && !lines[i-1].Quoted {
+			if match := tagBefore.FindStringSubmatch(lines[i-1].Text); match != nil && !pronouns[match[1]] {
+				lines[i].Speaker = match[1]
+			}
+		}
+	}
+}
+
+// speakerLine matches an answer line such as `3: Pip`
+var speakerLine = regexp.MustCompile(`^\s*[-*]*\s*(\d+)\s*[:.)]\s*(.+?)\s*$`)
+
+// ParseSpeakers reads `number: speaker` lines, as a model answers when asked who says each numbered quote
+func ParseSpeakers(text string) map[int]string {
+	speakers := make(map[int]string)
+	for _, line := range strings.Split(text, "\n") {
+		match := speakerLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		name := strings.Trim(match[2], `*"'“”`)
+		if err != nil || name == "" {
+			continue
+		}
+		speakers[number] = name
+	}
+	return speakers
+}
+
+// Cast gives every speaker of lines a voice. The narrator reads in narratorVoice; each character gets
+// the voice its name hashes to, moving on to the next free voice so characters sound different from
+// each other and from the narrator while there are voices left. The same name gets the same voice in
+// every story as long as it does not collide.
+func Cast(lines []Line, voices []string, narratorVoice string) map[string]string {
+	cast := map[string]string{Narrator: narratorVoice}
+	used := map[string]bool{narratorVoice: true}
+	for _, line := range lines {
+		speaker := line.Speaker
+		if _, ok := cast[speaker]; ok || speaker == "" || len(voices) == 0 {
+			continue
+		}
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(speaker)))
+		first := int(h.Sum32() % uint32(len(voices)))
+		voice := voices[first]
+		for offset := 0; offset < len(voices); offset++ {
+			if candidate := voices[(first+offset)%len(voices)]; !used[candidate] {
+				voice = candidate
+				break
+			}
+		}
+		cast[speaker] = voice
+		used[voice] = true
+	}
+	return cast
+}
+
+// VoiceFor returns the voice a line is read in, falling back to the narrator for unknown speakers
+func VoiceFor(cast map[string]string, line Line) string {
+	if voice, ok := cast[line.Speaker]; ok {
+		return voice
+	}
+	return cast[Narrator]
+}
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"rio-go-model/internal/helpers/dialogue"
+	"rio-go-model/internal/model"
+)
+
+// speakerPrompt asks the model who says each quote of a story
+const speakerPrompt = `Below is a children's story followed by the quotes spoken in it, numbered in order.
+For each quote, write who says it, as "number: Name" on its own line, using the character's name as the story gives it.
+Write "number: Narrator" when you cannot tell. Write only the lines, nothing else.
+
+Story:
+%s
+
+Quotes:
+%s`
+
+// dialogueLines splits the story into narration and quotes and works out who says each quote, asking
+// the model first and reading English dialogue tags for the quotes it leaves out. Quotes nobody is found
+// for are read by the narrator.
+func (sgh *StoryGenerationHelper) dialogueLines(ctx context.Context, storyText string) []dialogue.Line {
+	lines := dialogue.Split(storyText)
+	quotes := dialogue.Quotes(lines)
+	if len(quotes) == 0 {
+		return lines
+	}
+
+	if isSuspended, err := sgh.storyDatabase.SuspendGeminiAPI(ctx, "gemini"); err != nil || isSuspended {
+		sgh.logger.Infof("Skipping speaker attribution, gemini api unavailable")
+	} else {
+		var numbered strings.Builder
+		for n, i := range quotes {
+			fmt.Fprintf(&numbered, "%d: %s\n", n+1, lines[i].Text)
+		}
+		response, err := sgh.geminiStoryGenerator.GenerateText(fmt.Sprintf(speakerPrompt, storyText, numbered.String()), "gemini-2.0-flash-lite")
+		if err != nil {
+			sgh.logger.Errorf("Failed to attribute dialogue: %v", err)
+		} else {
+			sgh.storyDatabase.UpdateAPITokens(ctx, "gemini", (int64)(response.TotalTokens))
+			sgh.recordCost(ctx, "gemini", response.Model, model.UnitTokens, int64(response.TotalTokens))
+			for n, speaker := range dialogue.ParseSpeakers(response.Story) {
+				if n >= 1 && n <= len(quotes) {
+					lines[quotes[n-1]].Speaker = speaker
+				}
+			}
+		}
+	}
+
+	dialogue.Attribute(lines)
+	for i := range lines {
+		if lines[i].Speaker == "" || strings.EqualFold(lines[i].Speaker, dialogue.Narrator) {
+			lines[i].Speaker = dialogue.Narrator
+		}
+	}
+	return lines
+}
+
+// narrateDialogue reads the story with a voice for the narrator and one for each character who speaks.
+// It returns the audio, the voice of each speaker and the Google voice type used. It fails when the
+// story has no attributed dialogue or the audio budget is used up, so the caller can narrate in one voice.
+// With pcm set the audio is WAV, so a soundscape can be mixed under it.
+func (sgh *StoryGenerationHelper) narrateDialogue(ctx context.Context, storyText, language, theme string, pcm bool) ([]byte, map[string]string, string, error) {
+	speech, output := sgh.audioStoryGenerator, "dialogue"
+	if pcm && sgh.pcmStoryGenerator != nil {
+		speech, output = sgh.pcmStoryGenerator, "dialogue+pcm"
+	}
+	provider, ok := speech.(DialogueProvider)
+	if !ok {
+		return nil, nil, "", fmt.Errorf("speech provider cannot narrate dialogue")
+	}
+	suspended, voice, err := sgh.storyDatabase.SuspendAudioAPI(ctx, "audio")
+	if err != nil || suspended {
+		return nil, nil, voice, fmt.Errorf("google audio api unavailable for dialogue")
+	}
+
+	// The cache key is the story text, so a hit also skips the speaker attribution call
+	key := audioCacheKey("google-tts", voice, language, theme, output, storyText)
+	if audioData, _, meta, ok := sgh.cachedNarration(ctx, key); ok {
+		var cast map[string]string
+		if err := json.Unmarshal([]byte(meta["cast"]), &cast); err == nil {
+			return audioData, cast, voice, nil
+		}
+	}
+
+	lines := sgh.dialogueLines(ctx, storyText)
+	speakers := 0
+	for _, line := range lines {
+		if line.Quoted && line.Speaker != dialogue.Narrator {
+			speakers++
+		}
+	}
+	if speakers == 0 {
+		return nil, nil, voice, fmt.Errorf("story has no attributed dialogue")
+	}
+
+	sgh.logger.Infof("Narrating %d lines of dialogue with per-character voices", len(lines))
+	audioData, cast, totalTokens, err := provider.GenerateDialogueAdapter(lines, language, theme, voice)
+	sgh.storyDatabase.UpdateAPITokens(ctx, "audio", (int64)(totalTokens))
+	if err != nil {
+		return nil, nil, voice, err
+	}
+	sgh.recordCost(ctx, "google-tts", voice, model.UnitCharacters, int64(utf8.RuneCountInString(storyText)))
+	if castJSON, err := json.Marshal(cast); err == nil {
+		sgh.cacheNarration(ctx, key, "google-tts", audioData, nil, map[string]string{"cast": string(castJSON)})
+	}
+	return audioData, cast, voice, nil
+}
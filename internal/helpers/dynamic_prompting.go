@@ -1,19 +1,22 @@
 package helpers
 
 import (
+	"fmt"
 	"log"
-
-	// "strings"
+	"math/rand"
+	"strings"
 
 	"rio-go-model/configs"
-	"rio-go-model/configs/english"
-	"rio-go-model/configs/telugu"
+	"rio-go-model/configs/catalog"
+	"rio-go-model/configs/languages"
 	"rio-go-model/internal/util"
 )
 
 // DynamicPrompting represents a service for generating dynamic story prompts
 type DynamicPrompting struct {
 	logger *log.Logger
+	// random picks topics; nil uses the shared random source
+	random *rand.Rand
 }
 
 // NewDynamicPrompting creates a new DynamicPrompting instance
@@ -23,33 +26,28 @@ func NewDynamicPrompting() *DynamicPrompting {
 	}
 }
 
+// NewSeededDynamicPrompting creates a DynamicPrompting that picks the same topics for the same seed
+func NewSeededDynamicPrompting(seed int64) *DynamicPrompting {
+	d := NewDynamicPrompting()
+	d.random = rand.New(rand.NewSource(seed))
+	return d
+}
+
 // GetPlanetProtectorsStories generates planet protector story prompts
 func (d *DynamicPrompting) GetPlanetProtectorsStories(country, city string, preference string, language string, storiesPerPreference int) (string, error) {
 	d.logger.Printf("Generating planet protector stories for country: %s, city: %s", country, city)
 
-	planetProtectors := english.ThemesSettings()
-	var promptText string
-
-	for i := 0; i < storiesPerPreference; i++ {
-		topicNumber, err := util.RandomFrom(planetProtectors.PlanetProtectorTopicsList)
-		if err != nil {
-			topicNumber = 0
-		}
-		topic := planetProtectors.PlanetProtectorTopicsList[topicNumber]
-		if i < storiesPerPreference-1 {
-			promptText += topic + ", "
-		} else {
-			promptText += topic
-		}
+	lang := catalog.Current().Language(language)
+	promptText, err := d.pickTopics(lang.TopicList(catalog.PlanetProtector, ""), storiesPerPreference)
+	if err != nil {
+		return "", fmt.Errorf("no planet protector topics for %s: %v", language, err)
 	}
 	log.Println("promptText: ", promptText)
-	var superPrompt string
-	switch language {
-	case "English":
-		superPrompt = english.SuperPlanetProtectorPrompt(promptText, preference, storiesPerPreference)
-	case "Telugu":
-		superPrompt = telugu.SuperPlanetProtectorPrompt(promptText, preference, storiesPerPreference)
+	vars := catalog.TopicVars{Items: promptText, Preference: preference, Count: storiesPerPreference}
+	if superPrompt, ok, err := lang.RenderTopicPrompt(catalog.PlanetProtector, vars); ok {
+		return superPrompt, err
 	}
+	superPrompt := languages.Lookup(language).TopicPrompt(catalog.PlanetProtector, vars)
 
 	d.logger.Printf("Generated prompt: %s", superPrompt)
 	return superPrompt, nil
@@ -59,31 +57,17 @@ func (d *DynamicPrompting) GetPlanetProtectorsStories(country, city string, pref
 func (d *DynamicPrompting) GetMindfulStories(country, religion string, preferences []string, language string, storiesPerPreference int) (string, error) {
 	d.logger.Printf("Generating mindful stories for country: %s, religion: %s", country, religion)
 
-	mindfulStoriesSettings := english.ThemesSettings()
-	var promptText string
-
-	for i := 0; i < storiesPerPreference; i++ {
-		topicNumber, err := util.RandomFrom(mindfulStoriesSettings.MindfulStoriesList[religion])
-		if err != nil {
-			topicNumber = 0
-		}
-		topic := mindfulStoriesSettings.MindfulStoriesList[religion][topicNumber]
-		if i < storiesPerPreference-1 {
-			promptText += topic + ", "
-		} else {
-			promptText += topic
-		}
+	lang := catalog.Current().Language(language)
+	promptText, err := d.pickTopics(lang.TopicList(catalog.Mindful, religion), storiesPerPreference)
+	if err != nil {
+		return "", fmt.Errorf("no mindful topics for %s in %s: %v", religion, language, err)
 	}
 	log.Println("promptText: ", promptText)
-	var superPrompt string
-
-	switch language {
-	case "English":
-		superPrompt = english.SuperMindfulStoriesPrompt(promptText, religion, storiesPerPreference)
-	case "Telugu":
-		superPrompt = telugu.SuperMindfulStoriesPrompt(promptText, religion, storiesPerPreference)
+	vars := catalog.TopicVars{Items: promptText, Religion: religion, Count: storiesPerPreference}
+	if superPrompt, ok, err := lang.RenderTopicPrompt(catalog.Mindful, vars); ok {
+		return superPrompt, err
 	}
-	return superPrompt, nil
+	return languages.Lookup(language).TopicPrompt(catalog.Mindful, vars), nil
 }
 
 // GetChillStories generates chill story prompts
@@ -91,30 +75,33 @@ func (d *DynamicPrompting) GetChillStories(preference string, language string, s
 	d.logger.Printf("Generating chill stories for preferences: %v", preference)
 	d.logger.Printf("storiesPerPreference: %d", storiesPerPreference)
 
-	chillStoriesSettings := english.ThemesSettings()
-	var promptText string
+	lang := catalog.Current().Language(language)
+	promptText, err := d.pickTopics(lang.TopicList(catalog.Chill, ""), storiesPerPreference)
+	if err != nil {
+		return "", fmt.Errorf("no chill topics for %s: %v", language, err)
+	}
+	log.Println("promptText: ", promptText)
+	vars := catalog.TopicVars{Items: promptText, Preference: preference, Count: storiesPerPreference}
+	if superPrompt, ok, err := lang.RenderTopicPrompt(catalog.Chill, vars); ok {
+		return superPrompt, err
+	}
+	return languages.Lookup(language).TopicPrompt(catalog.Chill, vars), nil
+}
 
-	for i := 0; i < storiesPerPreference; i++ {
-		topicNumber, err := util.RandomFrom(chillStoriesSettings.ChillStoriesList)
+// pickTopics joins count topics picked at random from list
+func (d *DynamicPrompting) pickTopics(list []string, count int) (string, error) {
+	picked := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		topicNumber, err := util.RandomFrom(list)
 		if err != nil {
-			topicNumber = 0
+			return "", err
 		}
-		topic := chillStoriesSettings.ChillStoriesList[topicNumber]
-		if i < storiesPerPreference-1 {
-			promptText += topic + ", "
-		} else {
-			promptText += topic
+		if d.random != nil {
+			topicNumber = d.random.Intn(len(list))
 		}
+		picked = append(picked, list[topicNumber])
 	}
-	log.Println("promptText: ", promptText)
-	var superPrompt string
-	switch language {
-	case "English":
-		superPrompt = english.SuperChillStoriesPrompt(promptText, preference, storiesPerPreference)
-	case "Telugu":
-		superPrompt = telugu.SuperChillStoriesPrompt(promptText, preference, storiesPerPreference)
-	}
-	return superPrompt, nil
+	return strings.Join(picked, ", "), nil
 }
 
 // Helper functions to get configuration values
@@ -0,0 +1,99 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+	"unicode/utf8"
+)
+
+// charactersPerSecond is the narration speed used to size fake audio
+const charactersPerSecond = 15
+
+// silentMP3Frame is one MPEG-1 Layer III frame (128 kbps, 44.1 kHz, mono) with empty side
+// information, which decodes to 1152 samples of silence
+var silentMP3Frame = func() []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0xC4})
+	return frame
+}()
+
+// mp3FrameDuration is the playing time of one frame
+const mp3FrameDuration = time.Second * 1152 / 44100
+
+// SpeechDuration is how long narrating text would take
+func SpeechDuration(text string) time.Duration {
+	duration := time.Duration(utf8.RuneCountInString(text)) * time.Second / charactersPerSecond
+	if duration < time.Second {
+		return time.Second
+	}
+	return duration
+}
+
+// SilentMP3 returns an MP3 stream of silence lasting at least duration
+func SilentMP3(duration time.Duration) []byte {
+	frames := int((duration + mp3FrameDuration - 1) / mp3FrameDuration)
+	return bytes.Repeat(silentMP3Frame, frames)
+}
+
+// SilentWAV returns a 16-bit mono PCM WAV file of silence
+func SilentWAV(duration time.Duration, sampleRate int) []byte {
+	dataSize := int(duration.Seconds()*float64(sampleRate)) * 2
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))           // fmt chunk size
+	binary.Write(&buf, binary.LittleEndian, uint16(1))            // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1))            // mono
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))   // sample rate
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*2)) // byte rate
+	binary.Write(&buf, binary.LittleEndian, uint16(2))            // block align
+	binary.Write(&buf, binary.LittleEndian, uint16(16))           // bits per sample
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+// PlaceholderPNG draws a square gradient with a disc, coloured from a hash of key,
+// so the same prompt always gives the same picture
+func PlaceholderPNG(key string, size int) []byte {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	from := color.RGBA{R: byte(sum), G: byte(sum >> 8), B: byte(sum >> 16), A: 255}
+	// The second colour is the complement so the gradient always has contrast
+	to := color.RGBA{R: 255 - from.R, G: 255 - from.G, B: 255 - from.B, A: 255}
+	disc := color.RGBA{R: byte(sum >> 24), G: byte(sum >> 32), B: byte(sum >> 40), A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	center, radius := size/2, size/4
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := x-center, y-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(x, y, disc)
+				continue
+			}
+			t := float64(x+y) / float64(2*size)
+			img.SetRGBA(x, y, color.RGBA{
+				R: lerp(from.R, to.R, t),
+				G: lerp(from.G, to.G, t),
+				B: lerp(from.B, to.B, t),
+				A: 255,
+			})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func lerp(a, b byte, t float64) byte {
+	return byte(float64(a) + (float64(b)-float64(a))*t)
+}
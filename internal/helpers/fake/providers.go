@@ -0,0 +1,201 @@
+// Package fake provides offline, deterministic stand-ins for the AI providers used in
+// story generation. They are wired in when PROVIDER_MODE=fake.
+package fake
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/dialogue"
+	"rio-go-model/internal/helpers/readalong"
+	"rio-go-model/internal/model"
+)
+
+// Model is the model name reported by every fake provider
+const Model = "fake"
+
+// ImageSize is the width and height of placeholder images
+const ImageSize = 768
+
+// WAVSampleRate is the sample rate of the fallback audio
+const WAVSampleRate = 24000
+
+var topicSubjects = []string{
+	"Little Cloud", "Brave Turtle", "Sleepy Moon", "Curious Kitten", "Kind Elephant",
+	"Singing River", "Lost Kite", "Tiny Seed", "Friendly Dragon", "Wise Owl",
+}
+
+var topicLessons = []string{
+	"Learned to Share", "Found a New Friend", "Said Thank You", "Helped the Village",
+	"Was Not Afraid", "Told the Truth", "Waited Patiently", "Planted a Garden",
+}
+
+// Text writes canned topics, stories and character sheets
+type Text struct{}
+
+// NewText creates a fake text provider
+func NewText() *Text {
+	return &Text{}
+}
+
+// CreateTopics returns DEFAULT_STORY_TO_GENERATE titles chosen from the prompt hash
+func (t *Text) CreateTopics(prompt string) (*model.TopicResponse, error) {
+	count := configs.GetSettings().DefaultStoryToGenerate
+	if count <= 0 {
+		count = 1
+	}
+	seed := hash(prompt)
+	titles := make([]string, count)
+	for i := range titles {
+		subject := topicSubjects[(seed+uint64(i))%uint64(len(topicSubjects))]
+		lesson := topicLessons[(seed/7+uint64(i))%uint64(len(topicLessons))]
+		titles[i] = fmt.Sprintf("The %s Who %s", subject, lesson)
+	}
+	return &model.TopicResponse{Title: titles, TotalTokens: tokens(prompt), Model: Model}, nil
+}
+
+// CreateStory returns a short three paragraph story about topic
+func (t *Text) CreateStory(theme, topic string, kwargs map[string]interface{}) (*model.StoryResponse, error) {
+	story := strings.Join([]string{
+		fmt.Sprintf("Once upon a time there was a story called %s. Pip the little bird and Mira the rabbit lived at the edge of a quiet forest.", topic),
+		"One morning they found a problem they could not solve alone. Pip flew high to look around, and Mira listened carefully to everyone they met.",
+		"By working together they made everything right again. That night, under the stars, they agreed that friends are stronger side by side.",
+	}, "\n\n")
+	return &model.StoryResponse{Story: story, TotalTokens: tokens(story), Model: Model}, nil
+}
+
+// GenerateText answers any prompt with the character sheet of the canned story
+func (t *Text) GenerateText(prompt string, modelName string) (*model.StoryResponse, error) {
+	sheet := "Pip: a small blue bird with a yellow scarf and round black eyes\n" +
+		"Mira: a white rabbit with long ears, a pink nose and a green dress"
+	return &model.StoryResponse{Story: sheet, TotalTokens: tokens(prompt), Model: Model}, nil
+}
+
+// Images draws placeholder pictures
+type Images struct{}
+
+// Generate returns a PNG that depends only on the prompt and seed
+func (Images) Generate(prompt string, seed int64) []byte {
+	return PlaceholderPNG(fmt.Sprintf("%d:%s", seed, prompt), ImageSize)
+}
+
+// Voices are the voice names the fake speech provider casts dialogue with
+var Voices = []string{"fake-narrator", "fake-voice-a", "fake-voice-b", "fake-voice-c"}
+
+// Speech narrates text as silent MP3 audio of matching length, or silent WAV audio from PCM()
+type Speech struct {
+	pcm bool
+}
+
+// NewSpeech creates a fake text-to-speech provider
+func NewSpeech() *Speech {
+	return &Speech{}
+}
+
+// PCM returns a fake provider that narrates to 16-bit PCM WAV, like GoogleTTS.PCM
+func (s *Speech) PCM() *Speech {
+	return &Speech{pcm: true}
+}
+
+// silence returns silent audio in the provider's format and how long it really lasts
+func (s *Speech) silence(duration time.Duration) ([]byte, time.Duration) {
+	if s.pcm {
+		return SilentWAV(duration, WAVSampleRate), duration
+	}
+	audio := SilentMP3(duration)
+	// SilentMP3 rounds up to whole frames, so the audio lasts a little longer than duration
+	return audio, mp3FrameDuration * time.Duration(len(audio)/len(silentMP3Frame))
+}
+
+// GenerateAudioAdapter returns silent audio and the character count as billed tokens
+func (s *Speech) GenerateAudioAdapter(text string, language string, theme string, voice string) ([]byte, int32, error) {
+	if text == "" {
+		return nil, 0, fmt.Errorf("text is empty")
+	}
+	audio, _ := s.silence(SpeechDuration(text))
+	return audio, int32(len([]rune(text))), nil
+}
+
+// GenerateReadAlongAdapter returns silent audio per chunk with every word timed in proportion to its length
+func (s *Speech) GenerateReadAlongAdapter(text string, language string, theme string, voice string) ([]byte, *readalong.Timing, int32, error) {
+	chunks := readalong.Split(text, 5000)
+	if len(chunks) == 0 {
+		return nil, nil, 0, fmt.Errorf("text is empty")
+	}
+	var builder readalong.Builder
+	var audioChunks [][]byte
+	for _, chunk := range chunks {
+		var words []string
+		for _, word := range chunk.Words {
+			words = append(words, word.Text)
+		}
+		chunkText := strings.Join(words, " ")
+		duration := SpeechDuration(chunkText)
+		runes, total := 0, utf8.RuneCountInString(chunkText)
+		var timepoints []readalong.Timepoint
+		for _, word := range chunk.Words {
+			timepoints = append(timepoints, readalong.Timepoint{
+				Mark:    readalong.MarkName(word.Index),
+				Seconds: duration.Seconds() * float64(runes) / float64(total),
+			})
+			runes += utf8.RuneCountInString(word.Text) + 1
+		}
+		chunkAudio, chunkDuration := s.silence(duration)
+		builder.AddChunk(chunk, timepoints, chunkDuration)
+		audioChunks = append(audioChunks, chunkAudio)
+	}
+	audio, err := audiofile.Join(audioChunks)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return audio, builder.Timing(), int32(len([]rune(text))), nil
+}
+
+// GenerateDialogueAdapter returns silent audio for every line and casts the fake voices
+func (s *Speech) GenerateDialogueAdapter(lines []dialogue.Line, language string, theme string, voice string) ([]byte, map[string]string, int32, error) {
+	if len(lines) == 0 {
+		return nil, nil, 0, fmt.Errorf("no lines to narrate")
+	}
+	cast := dialogue.Cast(lines, Voices, Voices[0])
+	var audioChunks [][]byte
+	var characters int
+	for _, line := range lines {
+		lineAudio, _ := s.silence(SpeechDuration(line.Text))
+		audioChunks = append(audioChunks, lineAudio)
+		characters += len([]rune(line.Text))
+	}
+	audio, err := audiofile.Join(audioChunks)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return audio, cast, int32(characters), nil
+}
+
+// FallbackAudio stands in for the fal.ai fallback and returns silent WAV audio
+type FallbackAudio struct{}
+
+// NewFallbackAudio creates a fake fallback audio provider
+func NewFallbackAudio() *FallbackAudio {
+	return &FallbackAudio{}
+}
+
+// GenerateAudio returns silent WAV audio as long as narrating prompt would take
+func (a *FallbackAudio) GenerateAudio(prompt string) ([]byte, error) {
+	return SilentWAV(SpeechDuration(prompt), WAVSampleRate), nil
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// tokens estimates a token count as one token per word
+func tokens(s string) int32 {
+	return int32(len(strings.Fields(s)))
+}
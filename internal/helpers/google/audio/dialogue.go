@@ -0,0 +1,48 @@
+package audio
+
+import (
+	"fmt"
+
+	"rio-go-model/internal/helpers/dialogue"
+	"rio-go-model/internal/util"
+	"rio-go-model/internal/util/segment"
+)
+
+// GenerateDialogueAdapter reads every line in the voice cast for its speaker and joins the audio into
+// one track. The narrator gets a random voice of the voice type, as in GenerateAudioAdapter, and each
+// character one of the other voices of that type. It returns the audio, the cast and the billed characters.
+func (g *GoogleTTS) GenerateDialogueAdapter(lines []dialogue.Line, language string, theme string, voice string) ([]byte, map[string]string, int32, error) {
+	var totalTokens int32
+	if len(lines) == 0 {
+		return nil, nil, totalTokens, fmt.Errorf("no lines to narrate")
+	}
+	languageCode, narratorVoice, err := g.selectVoice(language, theme, voice)
+	if err != nil {
+		return nil, nil, totalTokens, err
+	}
+	suffixes := voiceSuffixes(language, voice)
+	voices := make([]string, len(suffixes))
+	for i := range suffixes {
+		voices[i] = util.GetVoice(languageCode, i, suffixes)
+	}
+	cast := dialogue.Cast(lines, voices, narratorVoice)
+	g.Logger.Printf("Generating dialogue audio for %d lines with %d voices", len(lines), len(cast))
+
+	// Every line is a request, or several for a line over the input limit, synthesized together
+	var requests []GoogleTTSRequest
+	for _, line := range lines {
+		voiceName := dialogue.VoiceFor(cast, line)
+		for _, chunk := range segment.Chunks(line.Text, maxSSMLBytes) {
+			requests = append(requests, GoogleTTSRequest{Text: chunk, LanguageCode: languageCode, LanguageName: voiceName})
+		}
+		if g.storyCharacters != nil {
+			totalTokens += int32(g.storyCharacters.CountAudioChars(line.Text, ""))
+		}
+	}
+
+	combinedAudio, err := g.synthesizeChunks(requests)
+	if err != nil {
+		return nil, nil, totalTokens, err
+	}
+	return combinedAudio, cast, totalTokens, nil
+}
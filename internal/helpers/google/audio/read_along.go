@@ -0,0 +1,175 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/cassette"
+	"rio-go-model/internal/helpers/chunked"
+	"rio-go-model/internal/helpers/readalong"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// timepointURL is the REST method that reports when each SSML mark is reached
+const timepointURL = "https://texttospeech.googleapis.com/v1beta1/text:synthesize"
+
+// maxSSMLBytes is the largest SSML input Google TTS accepts in one request
+const maxSSMLBytes = 5000
+
+// ttsProvider names Google TTS in the chunked synthesis concurrency limit and metrics
+const ttsProvider = "google-tts"
+
+// newTimepointClient creates an authorized HTTP client for timepointURL whose calls go through the cassette
+func newTimepointClient(ctx context.Context, recorder *cassette.Cassette, opts ...option.ClientOption) (*http.Client, error) {
+	opts = append(opts, option.WithScopes("https://www.googleapis.com/auth/cloud-platform"))
+	transport, err := htransport.NewTransport(ctx, recorder.Transport(nil), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create timepoint transport: %v", err)
+	}
+	return &http.Client{Transport: transport, Timeout: 60 * time.Second}, nil
+}
+
+type timepointRequest struct {
+	Input struct {
+		SSML string `json:"ssml"`
+	} `json:"input"`
+	Voice struct {
+		LanguageCode string `json:"languageCode"`
+		Name         string `json:"name"`
+	} `json:"voice"`
+	AudioConfig struct {
+		AudioEncoding string `json:"audioEncoding"`
+	} `json:"audioConfig"`
+	EnableTimePointing []string `json:"enableTimePointing"`
+}
+
+type timepointResponse struct {
+	AudioContent []byte `json:"audioContent"`
+	Timepoints   []struct {
+		MarkName    string  `json:"markName"`
+		TimeSeconds float64 `json:"timeSeconds"`
+	} `json:"timepoints"`
+}
+
+// synthesizeWithTimepoints narrates marked SSML and returns the audio with the time of every mark
+func (g *GoogleTTS) synthesizeWithTimepoints(ctx context.Context, request GoogleTTSRequest) GoogleTTSResponse {
+	if g.timepointClient == nil {
+		return GoogleTTSResponse{Error: "timepoints are not available"}
+	}
+	if request.SSML == "" {
+		return GoogleTTSResponse{Error: "ssml is required for timepoints"}
+	}
+	var body timepointRequest
+	body.Input.SSML = request.SSML
+	body.Voice.LanguageCode = request.LanguageCode
+	body.Voice.Name = request.LanguageName
+	encoding, format := g.audioEncoding()
+	body.AudioConfig.AudioEncoding = encoding.String()
+	body.EnableTimePointing = []string{"SSML_MARK"}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return GoogleTTSResponse{Error: fmt.Sprintf("failed to encode request: %v", err)}
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, timepointURL, bytes.NewReader(payload))
+	if err != nil {
+		return GoogleTTSResponse{Error: fmt.Sprintf("failed to create request: %v", err)}
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	g.Logger.Printf("Calling Google TTS API with timepoints...")
+	httpResponse, err := g.timepointClient.Do(httpRequest)
+	if err != nil {
+		g.Logger.Printf("failed to synthesize speech with timepoints: %v", err)
+		return GoogleTTSResponse{Error: "failed to synthesize speech"}
+	}
+	defer httpResponse.Body.Close()
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return GoogleTTSResponse{Error: fmt.Sprintf("failed to read response: %v", err)}
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		g.Logger.Printf("failed to synthesize speech with timepoints: status %d: %s", httpResponse.StatusCode, responseBody)
+		return GoogleTTSResponse{Error: "failed to synthesize speech"}
+	}
+
+	var decoded timepointResponse
+	if err := json.Unmarshal(responseBody, &decoded); err != nil {
+		return GoogleTTSResponse{Error: fmt.Sprintf("failed to decode response: %v", err)}
+	}
+	timepoints := make([]readalong.Timepoint, 0, len(decoded.Timepoints))
+	for _, timepoint := range decoded.Timepoints {
+		timepoints = append(timepoints, readalong.Timepoint{Mark: timepoint.MarkName, Seconds: timepoint.TimeSeconds})
+	}
+	g.Logger.Printf("Google TTS API call successful, audio content length: %d, timepoints: %d", len(decoded.AudioContent), len(timepoints))
+	return GoogleTTSResponse{
+		AudioContent: decoded.AudioContent,
+		AudioFormat:  format,
+		Timepoints:   timepoints,
+	}
+}
+
+// GenerateReadAlongAdapter narrates text like GenerateAudioAdapter and also returns the timing of every
+// word. The text is marked word by word and split into SSML chunks, and the timepoints of each chunk are
+// moved along by the length of the audio before it. Voices without SSML marks are narrated without timing.
+func (g *GoogleTTS) GenerateReadAlongAdapter(text string, language string, theme string, voice string) ([]byte, *readalong.Timing, int32, error) {
+	languageCode, languageName, err := g.selectVoice(language, theme, voice)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if g.timepointClient == nil || !supportsSSML(languageName) {
+		g.Logger.Printf("Voice %s has no read-along timepoints, narrating without timing", languageName)
+		audioData, totalTokens, err := g.GenerateAudioAdapter(text, language, theme, voice)
+		return audioData, nil, totalTokens, err
+	}
+
+	chunks := readalong.Split(text, maxSSMLBytes)
+	if len(chunks) == 0 {
+		return nil, nil, 0, fmt.Errorf("text is empty")
+	}
+	g.Logger.Printf("Generating read-along audio in %d chunks with voice %s", len(chunks), languageName)
+	// Chunks are synthesized like any other chunked narration; each writes only its own timepoints
+	timepoints := make([][]readalong.Timepoint, len(chunks))
+	audioChunks, err := chunked.Synthesize(context.Background(), chunkOptions(), len(chunks), func(ctx context.Context, i int) ([]byte, error) {
+		response := g.generateAudio(ctx, GoogleTTSRequest{
+			SSML:         chunks[i].SSML,
+			LanguageCode: languageCode,
+			LanguageName: languageName,
+			Timepoints:   true,
+		})
+		if response.Error != "" {
+			return nil, fmt.Errorf("failed to generate audio: %s", response.Error)
+		}
+		timepoints[i] = response.Timepoints
+		return response.AudioContent, nil
+	})
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("audio generation failed at %v", err)
+	}
+
+	var builder readalong.Builder
+	var totalTokens int32
+	for i, chunk := range chunks {
+		if g.storyCharacters != nil {
+			totalTokens += int32(g.storyCharacters.CountAudioChars("", chunk.SSML))
+		}
+		info, err := audiofile.Probe(audioChunks[i])
+		if err != nil {
+			return nil, nil, totalTokens, fmt.Errorf("failed to measure chunk %d: %v", i+1, err)
+		}
+		builder.AddChunk(chunk, timepoints[i], info.Duration)
+	}
+
+	combinedAudio, err := audiofile.Join(audioChunks)
+	if err != nil {
+		return nil, nil, totalTokens, fmt.Errorf("failed to combine audio chunks: %v", err)
+	}
+	return combinedAudio, builder.Timing(), totalTokens, nil
+}
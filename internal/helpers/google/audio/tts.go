@@ -4,12 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
+	"rio-go-model/configs"
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/cassette"
+	"rio-go-model/internal/helpers/chunked"
+	"rio-go-model/internal/helpers/readalong"
 	"rio-go-model/internal/util"
+	"rio-go-model/internal/util/segment"
 
 	"rio-go-model/internal/util/tokens"
 
@@ -22,6 +29,28 @@ type GoogleTTS struct {
 	Client          *texttospeech.Client
 	Logger          *log.Logger
 	storyCharacters *tokens.StoryCharacters
+	// cassette records or replays the gRPC calls; nil calls Google directly
+	cassette *cassette.Cassette
+	// timepointClient calls the REST API that reports SSML mark timepoints; nil when it could not be created
+	timepointClient *http.Client
+	// pcm narrates to 16-bit PCM WAV instead of MP3
+	pcm bool
+}
+
+// PCM returns a copy of the client that narrates to 16-bit PCM WAV instead of MP3, so a soundscape
+// can be mixed under the narration
+func (g *GoogleTTS) PCM() *GoogleTTS {
+	pcm := *g
+	pcm.pcm = true
+	return &pcm
+}
+
+// audioEncoding returns the encoding the client narrates to and the format of the audio it returns
+func (g *GoogleTTS) audioEncoding() (texttospeechpb.AudioEncoding, string) {
+	if g.pcm {
+		return texttospeechpb.AudioEncoding_LINEAR16, audiofile.FormatWAV
+	}
+	return texttospeechpb.AudioEncoding_MP3, audiofile.FormatMP3
 }
 
 type GoogleTTSRequest struct {
@@ -29,11 +58,14 @@ type GoogleTTSRequest struct {
 	LanguageName string
 	LanguageCode string
 	SSML         string
+	// Timepoints asks for the time each SSML mark is reached
+	Timepoints bool
 }
 
 type GoogleTTSResponse struct {
 	AudioContent []byte
 	AudioFormat  string
+	Timepoints   []readalong.Timepoint
 	Error        string
 }
 
@@ -42,21 +74,27 @@ func NewGoogleTTS() *GoogleTTS {
 	var client *texttospeech.Client
 	var err error
 	log.Println("Initializing Google TTS client...")
+	recorder := cassette.Open("google-tts")
 	// Try to use service account file first
 	credPath := "serviceAccount.json"
-	if _, statErr := os.Stat(credPath); statErr == nil {
+	var opts []option.ClientOption
+	if recorder.Replaying() {
+		// Replayed calls never reach Google, so no credentials are needed
+		opts = append(opts, option.WithoutAuthentication())
+	} else if _, statErr := os.Stat(credPath); statErr == nil {
 		log.Println("Using service account from file for texttospeech")
-		client, err = texttospeech.NewClient(ctx, option.WithCredentialsFile(credPath))
-		if err != nil {
-			log.Fatalf("Failed to create texttospeech client: %v", err)
-		}
+		opts = append(opts, option.WithCredentialsFile(credPath))
 	} else {
-		log.Println("Using default credentials for texttospeech")
 		// In Cloud Run, use the default service account
-		client, err = texttospeech.NewClient(ctx)
-		if err != nil {
-			log.Fatalf("Failed to create texttospeech client: %v", err)
-		}
+		log.Println("Using default credentials for texttospeech")
+	}
+	client, err = texttospeech.NewClient(ctx, opts...)
+	if err != nil {
+		log.Fatalf("Failed to create texttospeech client: %v", err)
+	}
+	timepointClient, err := newTimepointClient(ctx, recorder, opts...)
+	if err != nil {
+		log.Printf("Warning: read-along timepoints are unavailable: %v", err)
 	}
 	log.Println("Google TTS client initialized successfully")
 	storyCharacters := tokens.NewStoryCharacters()
@@ -64,6 +102,8 @@ func NewGoogleTTS() *GoogleTTS {
 		Client:          client,
 		storyCharacters: storyCharacters,
 		Logger:          log.New(os.Stdout, "GoogleTTS: ", log.LstdFlags),
+		cassette:        recorder,
+		timepointClient: timepointClient,
 	}
 }
 
@@ -71,36 +111,23 @@ func (g *GoogleTTS) GenerateAudioAdapter(text string, language string, theme str
 	g.Logger.Printf("GenerateAudioAdapter called - Language: %s, Text length: %d, Theme: %s", language, len(text), theme)
 	var ssml string
 	var totalTokens int32
-	languageCode := util.LanguageMapper(language, theme)
-	voiceList := util.GetVoiceList(voice)
-	no, err := util.RandomFromLength(len(voiceList))
+	languageCode, languageName, err := g.selectVoice(language, theme, voice)
 	if err != nil {
-		g.Logger.Printf("Failed to get random voice number: %v", err)
-		return nil, totalTokens, fmt.Errorf("failed to get random voice number: %v", err)
+		return nil, totalTokens, err
 	}
-	languageName := util.GetVoice(languageCode, no, voiceList)
-	log.Println("Language name: %s", languageName)
-	// voices, err := g.ListVoices(languageCode)
-	// if err != nil {
-	// 	g.Logger.Printf("Failed to list voices: %v", err)
-	// 	return nil, totalTokens, fmt.Errorf("failed to list voices: %v", err)
-	// }
-	// g.Logger.Printf("Voices: %v", voices)
-	g.Logger.Printf("Mapped language code: %s, Voice name: %s", languageCode, languageName)
 
-	// if language == "Telugu" && voice == tts.Standard.String() {
-	// 	g.Logger.Printf("Processing Telugu text with SSML...")
-	// 	teluguSSMLBuilder := util.NewTeluguSSMLBuilder()
-	// 	ssml = teluguSSMLBuilder.BuildTeluguSSML(text)
-	// 	g.Logger.Printf("Generated Telugu SSML length: %d bytes", len(ssml))
-	// 	g.Logger.Printf("Updated voice name for Telugu: %s", languageName)
-	// 	// Check if SSML exceeds 5000 byte limit
-	// 	if len(ssml) > 5000 {
-	// 		g.Logger.Printf("SSML exceeds 5000 byte limit (%d bytes), splitting into chunks...", len(ssml))
-	// 		audio, err := g.generateAudioInChunks(text, language, languageCode, languageName)
-	// 		return audio, totalTokens, err
-	// 	}
-	// }
+	// Languages with an SSML builder are narrated from SSML, in chunks when it exceeds the input limit
+	if builder, ok := util.SSMLBuilderFor(language); ok && supportsSSML(languageName) {
+		chunks := util.BuildSSMLChunks(builder, text, maxSSMLBytes)
+		if len(chunks) > 1 {
+			g.Logger.Printf("SSML exceeds %d byte limit, narrating %d chunks...", maxSSMLBytes, len(chunks))
+			return g.generateSSMLChunks(chunks, languageCode, languageName)
+		}
+		if len(chunks) == 1 {
+			ssml = chunks[0]
+			g.Logger.Printf("Generated SSML length: %d bytes", len(ssml))
+		}
+	}
 
 	// Check if normal text exceeds 5000 byte limit (for non-SSML languages)
 	if len(ssml) == 0 && len(text) > 5000 {
@@ -131,76 +158,131 @@ func (g *GoogleTTS) GenerateAudioAdapter(text string, language string, theme str
 	return response.AudioContent, totalTokens, nil
 }
 
-// generateAudioInChunksNormal splits long text into smaller chunks without SSML and combines the audio
-func (g *GoogleTTS) generateAudioInChunksNormal(text, language, languageCode, languageName string) ([]byte, error) {
-	g.Logger.Printf("Splitting text into chunks for processing (normal text)...")
+// selectVoice maps the story language to a language code and picks a random voice of the voice type
+func (g *GoogleTTS) selectVoice(language, theme, voice string) (languageCode, languageName string, err error) {
+	languageCode = util.LanguageMapper(language, theme)
+	// A voice picked by PickVoice is a full voice name of the locale and is kept
+	if strings.HasPrefix(voice, languageCode+"-") {
+		return languageCode, voice, nil
+	}
+	voiceList := voiceSuffixes(language, voice)
+	no, err := util.RandomFromLength(len(voiceList))
+	if err != nil {
+		g.Logger.Printf("Failed to get random voice number: %v", err)
+		return "", "", fmt.Errorf("failed to get random voice number: %v", err)
+	}
+	languageName = util.GetVoice(languageCode, no, voiceList)
+	log.Println("Language name: %s", languageName)
+	// voices, err := g.ListVoices(languageCode)
+	// if err != nil {
+	// 	g.Logger.Printf("Failed to list voices: %v", err)
+	// 	return nil, totalTokens, fmt.Errorf("failed to list voices: %v", err)
+	// }
+	// g.Logger.Printf("Voices: %v", voices)
+	g.Logger.Printf("Mapped language code: %s, Voice name: %s", languageCode, languageName)
+	return languageCode, languageName, nil
+}
 
-	// Split text into sentences for better chunking
-	sentences := g.splitIntoSentences(text)
-	var audioChunks [][]byte
+// PickVoice picks a random voice of the voice type for the language, as every narration does, and
+// returns its full name. Narrations given that name as their voice all read in it.
+func (g *GoogleTTS) PickVoice(language string, theme string, voice string) (string, error) {
+	_, languageName, err := g.selectVoice(language, theme, voice)
+	return languageName, err
+}
 
-	g.Logger.Printf("Original text length: %d characters, split into %d sentences", len(text), len(sentences))
+// supportsSSML reports whether a voice accepts SSML; Chirp 3 HD voices only read plain text
+func supportsSSML(voiceName string) bool {
+	return !strings.Contains(voiceName, "Chirp")
+}
 
-	// Process each sentence as a separate chunk
-	// Fail-fast: If ANY chunk fails, stop immediately and return error (story will be bypassed)
-	for i, sentence := range sentences {
-		if len(sentence) == 0 {
-			continue
+// generateSSMLChunks narrates SSML chunks in order and joins the audio
+func (g *GoogleTTS) generateSSMLChunks(chunks []string, languageCode, languageName string) ([]byte, int32, error) {
+	var totalTokens int32
+	requests := make([]GoogleTTSRequest, len(chunks))
+	for i, chunk := range chunks {
+		requests[i] = GoogleTTSRequest{SSML: chunk, LanguageCode: languageCode, LanguageName: languageName}
+		if g.storyCharacters != nil {
+			totalTokens += int32(g.storyCharacters.CountAudioChars("", chunk))
 		}
+	}
+	combinedAudio, err := g.synthesizeChunks(requests)
+	if err != nil {
+		return nil, totalTokens, err
+	}
+	return combinedAudio, totalTokens, nil
+}
 
-		g.Logger.Printf("Processing chunk %d/%d: %d characters", i+1, len(sentences), len(sentence))
-
-		// If chunk is still too long, split further
-		if len(sentence) > 5000 {
-			g.Logger.Printf("Chunk %d still too long (%d bytes), splitting further...", i+1, len(sentence))
-			subChunks := g.splitTextIntoSmallerChunks(sentence, 4000)
-			for j, subChunk := range subChunks {
-				audioData, err := g.generateSingleChunk("", subChunk, languageCode, languageName)
-				if err != nil {
-					g.Logger.Printf("❌ Failed to generate audio for sub-chunk %d of chunk %d: %v", j+1, i+1, err)
-					g.Logger.Printf("⏹️  Stopping audio generation - story will be bypassed")
-					return nil, fmt.Errorf("audio generation failed at chunk %d, sub-chunk %d: %v", i+1, j+1, err)
-				}
-				audioChunks = append(audioChunks, audioData)
-			}
-		} else {
-			// Generate audio for this chunk
-			audioData, err := g.generateSingleChunk("", sentence, languageCode, languageName)
-			if err != nil {
-				g.Logger.Printf("❌ Failed to generate audio for chunk %d: %v", i+1, err)
-				g.Logger.Printf("⏹️  Stopping audio generation - story will be bypassed")
-				return nil, fmt.Errorf("audio generation failed at chunk %d: %v", i+1, err)
-			}
-			audioChunks = append(audioChunks, audioData)
+// synthesizeChunks narrates the requests concurrently, within the Google TTS concurrency limit and
+// TTS_TIMEOUT, retrying each failed chunk on its own, and joins the audio in request order
+func (g *GoogleTTS) synthesizeChunks(requests []GoogleTTSRequest) ([]byte, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no audio chunks to generate")
+	}
+	started := time.Now()
+	audioChunks, err := chunked.Synthesize(context.Background(), chunkOptions(), len(requests), func(ctx context.Context, i int) ([]byte, error) {
+		response := g.generateAudio(ctx, requests[i])
+		if response.Error != "" {
+			g.Logger.Printf("❌ Failed to generate audio for chunk %d/%d: %s", i+1, len(requests), response.Error)
+			return nil, fmt.Errorf("failed to generate audio: %s", response.Error)
 		}
+		return response.AudioContent, nil
+	})
+	if err != nil {
+		g.Logger.Printf("⏹️  Stopping audio generation - story will be bypassed")
+		return nil, fmt.Errorf("audio generation failed at %v", err)
 	}
+	g.Logger.Printf("✅ All %d chunks processed in %v, combining...", len(audioChunks), time.Since(started).Round(time.Millisecond))
 
-	// Validation: Check if all chunks were processed
-	if len(audioChunks) == 0 {
-		return nil, fmt.Errorf("no audio chunks generated")
+	combinedAudio, err := audiofile.Join(audioChunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine audio chunks: %v", err)
 	}
-
-	g.Logger.Printf("✅ All %d chunks processed successfully", len(audioChunks))
-
-	// Combine all audio chunks
-	g.Logger.Printf("Combining %d audio chunks...", len(audioChunks))
-	combinedAudio := g.combineAudioChunks(audioChunks)
-
-	// Final validation: Check combined audio size is reasonable
 	if len(combinedAudio) == 0 {
 		return nil, fmt.Errorf("combined audio is empty")
 	}
 	g.Logger.Printf("✅ Audio generation complete: %d bytes", len(combinedAudio))
-
 	return combinedAudio, nil
 }
 
+// chunkOptions limits, retries and times out the chunks of a narration as the settings say
+func chunkOptions() chunked.Options {
+	settings := configs.GetSettings()
+	return chunked.Options{
+		Provider:    ttsProvider,
+		Concurrency: settings.TTSConcurrency,
+		Attempts:    settings.TTSChunkAttempts,
+		Timeout:     settings.TTSTimeout,
+		Backoff:     time.Second,
+	}
+}
+
+// voiceSuffixes returns the voice name suffixes of a voice type for the language
+func voiceSuffixes(language, voice string) []string {
+	if voiceList := languages.Lookup(language).Voices(voice); voiceList != nil {
+		return voiceList
+	}
+	return util.GetVoiceList(voice)
+}
+
+// generateAudioInChunksNormal splits long text into smaller chunks without SSML and combines the audio
+func (g *GoogleTTS) generateAudioInChunksNormal(text, language, languageCode, languageName string) ([]byte, error) {
+	// Pack whole sentences, punctuation included, into chunks within the TTS input limit
+	chunks := segment.Chunks(text, maxSSMLBytes)
+	g.Logger.Printf("Original text length: %d bytes, split into %d chunks", len(text), len(chunks))
+
+	requests := make([]GoogleTTSRequest, len(chunks))
+	for i, chunk := range chunks {
+		requests[i] = GoogleTTSRequest{Text: chunk, LanguageCode: languageCode, LanguageName: languageName}
+	}
+	return g.synthesizeChunks(requests)
+}
+
 // generateAudioInChunks splits long text into smaller chunks and combines the audio
 func (g *GoogleTTS) generateAudioInChunks(text, language, languageCode, languageName string) ([]byte, error) {
 	g.Logger.Printf("Splitting text into chunks for processing...")
 
-	// Split text into sentences for better chunking
-	sentences := g.splitIntoSentences(text)
+	// Split text into sentences, keeping their punctuation for the SSML builder
+	sentences := segment.Sentences(text)
 	var audioChunks [][]byte
 
 	// Process each sentence as a separate chunk
@@ -219,7 +301,7 @@ func (g *GoogleTTS) generateAudioInChunks(text, language, languageCode, language
 		// If chunk SSML is still too long, split further
 		if len(chunkSSML) > 5000 {
 			g.Logger.Printf("Chunk %d SSML still too long (%d bytes), splitting further...", i+1, len(chunkSSML))
-			subChunks := g.splitTextIntoSmallerChunks(sentence, 4000) // Leave room for SSML markup
+			subChunks := segment.Chunks(sentence, 4000) // Leave room for SSML markup
 			for j, subChunk := range subChunks {
 				subSSML := teluguSSMLBuilder.BuildTeluguSSML(subChunk)
 				audioData, err := g.generateSingleChunk(subSSML, "", languageCode, languageName)
@@ -246,7 +328,11 @@ func (g *GoogleTTS) generateAudioInChunks(text, language, languageCode, language
 	}
 
 	g.Logger.Printf("Successfully generated %d audio chunks, combining...", len(audioChunks))
-	return g.combineAudioChunks(audioChunks), nil
+	combinedAudio, err := audiofile.Join(audioChunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine audio chunks: %v", err)
+	}
+	return combinedAudio, nil
 }
 
 // generateSingleChunk generates audio for a single SSML chunk
@@ -264,50 +350,14 @@ func (g *GoogleTTS) generateSingleChunk(ssml, text, languageCode, languageName s
 	return response.AudioContent, nil
 }
 
-// splitIntoSentences splits text into sentences
-func (g *GoogleTTS) splitIntoSentences(text string) []string {
-	// Simple sentence splitting by periods, exclamation marks, and question marks
-	// This is a basic implementation - you might want to use a more sophisticated NLP library
-	re := regexp.MustCompile(`[.!?]+`)
-	sentences := re.Split(text, -1)
-	var result []string
-	for _, sentence := range sentences {
-		sentence = strings.TrimSpace(sentence)
-		if len(sentence) > 0 {
-			result = append(result, sentence)
-		}
-	}
-	return result
-}
-
-// splitTextIntoSmallerChunks splits text into smaller chunks by character count
-func (g *GoogleTTS) splitTextIntoSmallerChunks(text string, maxChars int) []string {
-	var chunks []string
-	for i := 0; i < len(text); i += maxChars {
-		end := i + maxChars
-		if end > len(text) {
-			end = len(text)
-		}
-		chunk := text[i:end]
-		chunks = append(chunks, chunk)
-	}
-	return chunks
-}
-
-// combineAudioChunks combines multiple audio chunks into one
-func (g *GoogleTTS) combineAudioChunks(chunks [][]byte) []byte {
-	// For MP3 files, we need to concatenate the raw audio data
-	// This is a simple concatenation - for production, you might want to use a proper audio library
-	var combined []byte
-	for _, chunk := range chunks {
-		combined = append(combined, chunk...)
-	}
-	return combined
+func (g *GoogleTTS) GenerateAudio(request GoogleTTSRequest) GoogleTTSResponse {
+	return g.generateAudio(context.Background(), request)
 }
 
-func (g *GoogleTTS) GenerateAudio(request GoogleTTSRequest) GoogleTTSResponse {
+// generateAudio synthesizes one request, giving up when ctx ends or after 60 seconds
+func (g *GoogleTTS) generateAudio(ctx context.Context, request GoogleTTSRequest) GoogleTTSResponse {
 	g.Logger.Printf("GenerateAudio called with SSML: %d, Text: %d, LanguageCode: %s", len(request.SSML), len(request.Text), request.LanguageCode)
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 	var input *texttospeechpb.SynthesisInput
 	if request.SSML != "" {
@@ -335,6 +385,12 @@ func (g *GoogleTTS) GenerateAudio(request GoogleTTSRequest) GoogleTTSResponse {
 		request.LanguageName = "en-US-Chirp3-HD-Achernar"
 	}
 
+	if request.Timepoints {
+		// The v1 API has no time pointing, so marked SSML goes to the v1beta1 REST method
+		return g.synthesizeWithTimepoints(ctx, request)
+	}
+
+	encoding, format := g.audioEncoding()
 	req := &texttospeechpb.SynthesizeSpeechRequest{
 		Input: input,
 		Voice: &texttospeechpb.VoiceSelectionParams{
@@ -342,13 +398,14 @@ func (g *GoogleTTS) GenerateAudio(request GoogleTTSRequest) GoogleTTSResponse {
 			Name:         request.LanguageName,
 		},
 		AudioConfig: &texttospeechpb.AudioConfig{
-			AudioEncoding: texttospeechpb.AudioEncoding_MP3,
-			// EnableTimePointing: true,
+			AudioEncoding: encoding,
 		},
 	}
 
 	g.Logger.Printf("Calling Google TTS API...")
-	response, err := g.Client.SynthesizeSpeech(ctx, req)
+	response, err := cassette.Do(g.cassette, "texttospeech.SynthesizeSpeech", req, func() (*texttospeechpb.SynthesizeSpeechResponse, error) {
+		return g.Client.SynthesizeSpeech(ctx, req)
+	})
 	if err != nil {
 		g.Logger.Printf("=== TTS Request Debug ===")
 		g.Logger.Printf("Language Code: %s", request.LanguageCode)
@@ -371,7 +428,7 @@ func (g *GoogleTTS) GenerateAudio(request GoogleTTSRequest) GoogleTTSResponse {
 
 	return GoogleTTSResponse{
 		AudioContent: response.AudioContent,
-		AudioFormat:  "mp3",
+		AudioFormat:  format,
 		Error:        "",
 	}
 }
@@ -382,7 +439,10 @@ func (g *GoogleTTS) ListVoices(languageCode string) ([]*texttospeechpb.Voice, er
 		languageCode = "en-US"
 	}
 	ctx := context.Background()
-	voices, err := g.Client.ListVoices(ctx, &texttospeechpb.ListVoicesRequest{})
+	req := &texttospeechpb.ListVoicesRequest{}
+	voices, err := cassette.Do(g.cassette, "texttospeech.ListVoices", req, func() (*texttospeechpb.ListVoicesResponse, error) {
+		return g.Client.ListVoices(ctx, req)
+	})
 	if err != nil {
 		g.Logger.Printf("failed to list voices: %v", err)
 		return nil, fmt.Errorf("failed to list voices: %v", err)
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/cassette"
 	"rio-go-model/internal/util/tokens"
 
 	"google.golang.org/genai"
@@ -44,10 +45,17 @@ func NewGeminiStoryGenerationHelper() *GeminiStoryGenerationHelper {
 		log.Println("Warning: GEMINI_API_KEY not set")
 	}
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+	config := &genai.ClientConfig{
 		Backend: genai.BackendGeminiAPI,
 		APIKey:  apiKey,
-	})
+	}
+	if c := cassette.Open("gemini"); c != nil {
+		config.HTTPClient = c.Client(0)
+		if c.Replaying() && config.APIKey == "" {
+			config.APIKey = cassette.Redacted
+		}
+	}
+	client, err := genai.NewClient(ctx, config)
 	if err != nil {
 		log.Fatalf("Failed to create Gemini client: %v", err)
 	}
@@ -85,6 +93,7 @@ func (s *GeminiStoryGenerationHelper) CreateTopics(prompt string) (*model.TopicR
 	return &model.TopicResponse{
 		Title:       topics,
 		TotalTokens: totalTokens,
+		Model:       topicsResponse.Model,
 	}, nil
 }
 
@@ -207,5 +216,6 @@ func (s *GeminiStoryGenerationHelper) GenerateText(prompt string, modelName stri
 	return &model.StoryResponse{
 		Story:       storyText,
 		TotalTokens: totalTokens,
+		Model:       modelName,
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"rio-go-model/internal/helpers/cassette"
 	"rio-go-model/internal/model"
 	"time"
 
@@ -24,10 +25,17 @@ func NewGeminiImageGenerationHelper() *GeminiImageGenerationHelper {
 		log.Println("Warning: GEMINI_API_KEY not set")
 	}
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+	config := &genai.ClientConfig{
 		Backend: genai.BackendGeminiAPI,
 		APIKey:  apiKey,
-	})
+	}
+	if c := cassette.Open("gemini-image"); c != nil {
+		config.HTTPClient = c.Client(0)
+		if c.Replaying() && config.APIKey == "" {
+			config.APIKey = cassette.Redacted
+		}
+	}
+	client, err := genai.NewClient(ctx, config)
 	if err != nil {
 		log.Fatalf("Failed to create Gemini client: %v", err)
 	}
@@ -42,17 +50,24 @@ func NewGeminiImageGenerationHelper() *GeminiImageGenerationHelper {
 	return helper
 }
 
-func (s *GeminiImageGenerationHelper) CreateTopicsImage(prompt string) (imageBytes []byte, err error) {
+// CreateTopicsImage draws an image for the prompt, keeping to the story's style sheet when one is given
+func (s *GeminiImageGenerationHelper) CreateTopicsImage(prompt string, sheet *model.StyleSheet) (imageBytes []byte, err error) {
 	s.logger.Printf("Creating image for prompt")
-	imageResponse := s.GenerateImage(prompt, "gemini-2.5-flash-image")
+	imageResponse := s.GenerateImage(prompt, "gemini-2.5-flash-image", sheet)
 	if imageResponse.Error != "" {
 		return nil, fmt.Errorf("failed to generate image: %v", imageResponse.Error)
 	}
 	return imageResponse.Image, nil
 }
 
-func (s *GeminiImageGenerationHelper) GenerateImage(prompt string, modelName string) *model.ImageResponse {
+// GenerateImage generates an image, applying the style sheet directive and seed when one is given
+func (s *GeminiImageGenerationHelper) GenerateImage(prompt string, modelName string, sheet *model.StyleSheet) *model.ImageResponse {
 	s.logger.Printf("Creating image for prompt")
+	if sheet != nil {
+		if directive := sheet.PromptDirective(); directive != "" {
+			prompt = fmt.Sprintf("%s, %s", prompt, directive)
+		}
+	}
 
 	if s.client == nil {
 		return &model.ImageResponse{
@@ -70,6 +85,9 @@ func (s *GeminiImageGenerationHelper) GenerateImage(prompt string, modelName str
 			AspectRatio: "1:1",
 		},
 	}
+	if sheet != nil && sheet.Seed != 0 {
+		config.Seed = genai.Ptr(int32(sheet.Seed))
+	}
 
 	resp, err := s.client.Models.GenerateContent(
 		ctx,
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/cassette"
 	"rio-go-model/internal/model"
 	"rio-go-model/internal/util"
 )
@@ -42,6 +43,20 @@ type AIMessage struct {
 // AIResponse represents the response structure from AI model API
 type AIResponse struct {
 	Choices []AIChoice `json:"choices"`
+	Usage   *AIUsage   `json:"usage,omitempty"`
+}
+
+// AIUsage represents the token usage reported by the AI model API
+type AIUsage struct {
+	TotalTokens int32 `json:"total_tokens"`
+}
+
+// totalTokens returns the reported token usage, or 0 when the API omits it
+func (r *AIResponse) totalTokens() int32 {
+	if r.Usage == nil {
+		return 0
+	}
+	return r.Usage.TotalTokens
 }
 
 // AIChoice represents a choice from the AI model
@@ -61,9 +76,7 @@ func NewStoryCreator() *StoryCreator {
 		apiKey: apiKey,
 		// baseURL: "https://api.together.xyz/v1", // Together AI endpoint
 		baseURL: "https://router.huggingface.co/v1", // Fal AI endpoint
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		client:  cassette.Open("huggingface").Client(60 * time.Second),
 	}
 }
 
@@ -116,7 +129,9 @@ func (s *StoryCreator) CreateTopics(prompt string) (*model.TopicResponse, error)
 	}
 
 	return &model.TopicResponse{
-		Title: topics,
+		Title:       topics,
+		TotalTokens: response.totalTokens(),
+		Model:       request.Model,
 	}, nil
 }
 
@@ -185,7 +200,9 @@ func (s *StoryCreator) CreateStory(theme, topic string, kwargs map[string]interf
 	s.logger.Println("Successfully generated story")
 
 	return &model.StoryResponse{
-		Story: story,
+		Story:       story,
+		TotalTokens: response.totalTokens(),
+		Model:       request.Model,
 	}, nil
 }
 
@@ -10,6 +10,9 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"rio-go-model/internal/helpers/cassette"
+	"rio-go-model/internal/model"
 	// "rio-go-model/configs"
 )
 
@@ -26,6 +29,7 @@ type AIImageRequest struct {
 	Prompt         string `json:"prompt"`
 	ResponseFormat string `json:"response_format"`
 	Model          string `json:"model"`
+	Seed           *int64 `json:"seed,omitempty"`
 }
 
 // TopicResponse represents the response for topic generation
@@ -46,15 +50,19 @@ func NewImageCreator() *ImageCreator {
 		apiKey: apiKey,
 		// baseURL: "https://api.together.xyz/v1", // Together AI endpoint
 		baseURL: "https://router.huggingface.co/together/v1", // Fal AI endpoint
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		client:  cassette.Open("flux").Client(60 * time.Second),
 	}
 }
 
-// CreateTopics generates topics from a prompt using AI model
-func (s *ImageCreator) CreateImage(prompt string) (*ImageResponse, error) {
-	s.logger.Printf("Creating Images from prompt " + prompt)
+// CreateImage generates an image from a prompt using AI model.
+// When a style sheet is given its directive is appended to the prompt and its seed is sent along.
+func (s *ImageCreator) CreateImage(prompt string, sheet *model.StyleSheet) (*ImageResponse, error) {
+	if sheet != nil {
+		if directive := sheet.PromptDirective(); directive != "" {
+			prompt = fmt.Sprintf("%s, %s", prompt, directive)
+		}
+	}
+	s.logger.Printf("Creating Images from prompt %s", prompt)
 
 	// Prepare the request
 	request := AIImageRequest{
@@ -62,6 +70,9 @@ func (s *ImageCreator) CreateImage(prompt string) (*ImageResponse, error) {
 		Prompt:         prompt,
 		ResponseFormat: "base64",
 	}
+	if sheet != nil && sheet.Seed != 0 {
+		request.Seed = &sheet.Seed
+	}
 
 	// Make API call
 	response, err := s.makeAIRequest("/images/generations", request)
@@ -143,7 +154,7 @@ func (s *ImageCreator) makeAIRequest(endpoint string, request AIImageRequest) (*
 			}
 			if u := jsonResponse.Data[0].URL; u != "" {
 				// fetch bytes if API returned a URL
-				r2, err := http.Get(u)
+				r2, err := s.client.Get(u)
 				if err != nil {
 					return &ImageResponse{Error: fmt.Sprintf("Failed to fetch image URL: %v", err)}, nil
 				}
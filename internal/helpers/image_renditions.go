@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"rio-go-model/internal/helpers/imaging"
+	"rio-go-model/internal/helpers/mediacache"
+	"rio-go-model/internal/model"
+)
+
+// generateCheckedImage generates an image and runs it through the imaging pipeline.
+// A blank or corrupt image is regenerated once before giving up.
+func (sgh *StoryGenerationHelper) generateCheckedImage(ctx context.Context, prompt string, sheet *model.StyleSheet) ([]byte, *imaging.Result, error) {
+	var lastErr error
+	for attempt := 1; attempt <= 2; attempt++ {
+		imageData, err := sgh.GenerateImage(ctx, prompt, sheet)
+		if err != nil {
+			return nil, nil, err
+		}
+		result, err := imaging.Process(imageData)
+		if err == nil {
+			return imageData, result, nil
+		}
+		sgh.logger.Warnf("Rejected generated image (attempt %d): %v", attempt, err)
+		lastErr = err
+	}
+	return nil, nil, fmt.Errorf("generated image failed validation: %v", lastErr)
+}
+
+// uploadImageRenditions stores the original image and its renditions at content-addressed paths, so
+// an image served from the media cache points to the cached file and its renditions are stored once.
+// It returns the blob path of each, keyed by "original" and the rendition names.
+func (sgh *StoryGenerationHelper) uploadImageRenditions(imageData []byte, result *imaging.Result) (map[string]string, error) {
+	paths := make(map[string]string, len(result.Renditions)+1)
+
+	originalPath, err := mediacache.Store(sgh.storageService, mediacache.KindImage, "original."+result.Format, imageData)
+	if err != nil {
+		return nil, err
+	}
+	paths["original"] = originalPath
+
+	for _, rendition := range result.Renditions {
+		path, err := mediacache.Store(sgh.storageService, mediacache.KindImage, rendition.Name+"."+rendition.Extension, rendition.Data)
+		if err != nil {
+			return nil, err
+		}
+		paths[rendition.Name] = path
+	}
+	return paths, nil
+}
@@ -0,0 +1,167 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+)
+
+var (
+	// ErrCorruptImage is returned when the bytes cannot be decoded as an image
+	ErrCorruptImage = errors.New("image is corrupt or in an unsupported format")
+	// ErrInvalidDimensions is returned when the image is too small or too large
+	ErrInvalidDimensions = errors.New("image dimensions are out of range")
+	// ErrBlankImage is returned when the image is a single flat color
+	ErrBlankImage = errors.New("image is blank")
+)
+
+const (
+	minDimension = 256
+	maxDimension = 4096
+	// blankStdDev is the luminance standard deviation below which an image counts as blank
+	blankStdDev = 4.0
+	jpegQuality = 82
+)
+
+// Rendition is one resized copy of an image
+type Rendition struct {
+	Name        string
+	Data        []byte
+	Extension   string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// renditionSizes maps a rendition name to the length of its longest edge
+var renditionSizes = []struct {
+	name    string
+	maxEdge int
+}{
+	{"thumb", 256},
+	{"medium", 768},
+}
+
+// Result is a validated image together with its renditions
+type Result struct {
+	Format     string
+	Width      int
+	Height     int
+	Renditions []Rendition
+}
+
+// Process decodes and validates an image and produces its JPEG renditions.
+// WebP is not produced because the standard library has no WebP encoder.
+func Process(data []byte) (*Result, error) {
+	img, format, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Format: format, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	for _, size := range renditionSizes {
+		resized := resize(img, size.maxEdge)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s rendition: %v", size.name, err)
+		}
+		result.Renditions = append(result.Renditions, Rendition{
+			Name:        size.name,
+			Data:        buf.Bytes(),
+			Extension:   "jpg",
+			ContentType: "image/jpeg",
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+		})
+	}
+	return result, nil
+}
+
+// Validate checks an image the way Process does, without producing renditions, and returns its format
+func Validate(data []byte) (string, error) {
+	_, format, err := decode(data)
+	return format, err
+}
+
+// decode decodes an image and rejects it when it is out of the dimension range or blank
+func decode(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrCorruptImage, err)
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < minDimension || height < minDimension || width > maxDimension || height > maxDimension {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrInvalidDimensions, width, height)
+	}
+	if isBlank(img) {
+		return nil, "", ErrBlankImage
+	}
+	return img, format, nil
+}
+
+// isBlank samples the image on a grid and reports whether its luminance is flat
+func isBlank(img image.Image) bool {
+	bounds := img.Bounds()
+	const grid = 64
+	var sum, sumSq float64
+	n := 0
+	for gy := 0; gy < grid; gy++ {
+		y := bounds.Min.Y + gy*bounds.Dy()/grid
+		for gx := 0; gx < grid; gx++ {
+			x := bounds.Min.X + gx*bounds.Dx()/grid
+			r, g, b, _ := img.At(x, y).RGBA()
+			lum := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			sum += lum
+			sumSq += lum * lum
+			n++
+		}
+	}
+	mean := sum / float64(n)
+	variance := sumSq/float64(n) - mean*mean
+	return math.Sqrt(math.Max(variance, 0)) < blankStdDev
+}
+
+// resize scales the image so its longest edge is maxEdge using area averaging.
+// Images already within maxEdge are copied unchanged.
+func resize(src image.Image, maxEdge int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dw, dh := sw, sh
+	if sw >= sh && sw > maxEdge {
+		dw, dh = maxEdge, max(1, sh*maxEdge/sw)
+	} else if sh > sw && sh > maxEdge {
+		dw, dh = max(1, sw*maxEdge/sh), maxEdge
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0 := bounds.Min.Y + dy*sh/dh
+		y1 := max(y0+1, bounds.Min.Y+(dy+1)*sh/dh)
+		for dx := 0; dx < dw; dx++ {
+			x0 := bounds.Min.X + dx*sw/dw
+			x1 := max(x0+1, bounds.Min.X+(dx+1)*sw/dw)
+			var r, g, b, a, count uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := src.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					count++
+				}
+			}
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i+0] = uint8(r / count >> 8)
+			dst.Pix[i+1] = uint8(g / count >> 8)
+			dst.Pix[i+2] = uint8(b / count >> 8)
+			dst.Pix[i+3] = uint8(a / count >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,136 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/mediacache"
+	"rio-go-model/internal/helpers/readalong"
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/services/database"
+	"rio-go-model/internal/util"
+)
+
+// firestoreCacheIndex is the media cache index kept in Firestore, shared by every instance
+type firestoreCacheIndex struct {
+	db *database.StoryDatabase
+}
+
+func (f firestoreCacheIndex) Get(ctx context.Context, key string) (*mediacache.Entry, error) {
+	data, err := f.db.GetMediaCacheEntry(ctx, key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return mediacache.EntryFromMap(key, data), nil
+}
+
+func (f firestoreCacheIndex) Put(ctx context.Context, entry *mediacache.Entry) error {
+	return f.db.SetMediaCacheEntry(ctx, entry.Key, entry.ToMap())
+}
+
+func (f firestoreCacheIndex) Hit(ctx context.Context, key string) error {
+	return f.db.RecordMediaCacheHit(ctx, key)
+}
+
+// newMediaCache creates the media cache MEDIA_CACHE selects, or nil when it is off
+func newMediaCache(settings *configs.Settings, storyDB *database.StoryDatabase, storageService *database.StorageService) *mediacache.Cache {
+	ttl := time.Duration(settings.MediaCacheTTLDays) * 24 * time.Hour
+	switch settings.MediaCache {
+	case configs.MediaCacheFirestore:
+		return mediacache.New(firestoreCacheIndex{db: storyDB}, storageService, ttl)
+	case configs.MediaCacheMemory:
+		return mediacache.New(mediacache.NewMemoryIndex(ttl), storageService, ttl)
+	}
+	return nil
+}
+
+// audioCacheKey hashes everything that decides narrated audio: the provider and voice type, the TTS
+// locale, the SSML builder the text goes through, the output requested and the text itself
+func audioCacheKey(provider, voice, language, theme, output, text string) string {
+	return mediacache.Key(mediacache.KindAudio, provider, voice, util.LanguageMapper(language, theme),
+		languages.Lookup(language).SSMLBuilder(), output, text)
+}
+
+// imageCacheKey hashes the image provider, the full prompt and the style sheet it is drawn to
+func imageCacheKey(provider, prompt string, sheet *model.StyleSheet) string {
+	var sheetJSON []byte
+	if sheet != nil {
+		sheetJSON, _ = json.Marshal(sheet)
+	}
+	return mediacache.Key(mediacache.KindImage, provider, prompt, string(sheetJSON))
+}
+
+// cachedMedia returns the cached files of key by their name without extension, or ok false on a
+// miss. A cache that cannot be read counts as a miss.
+func (sgh *StoryGenerationHelper) cachedMedia(ctx context.Context, key string) (files map[string][]byte, meta map[string]string, ok bool) {
+	if sgh.mediaCache == nil {
+		return nil, nil, false
+	}
+	entry, stored, err := sgh.mediaCache.Get(ctx, key)
+	if err != nil {
+		sgh.logger.Warnf("Media cache lookup failed, generating instead: %v", err)
+		return nil, nil, false
+	}
+	if entry == nil {
+		return nil, nil, false
+	}
+	files = make(map[string][]byte, len(stored))
+	for name, data := range stored {
+		files[mediacache.BaseName(name)] = data
+	}
+	sgh.logger.Infof("Media cache hit for %s %s", entry.Provider, entry.Kind)
+	return files, entry.Meta, true
+}
+
+// cacheMedia stores generated files under key. Generation already succeeded, so a failure is only logged.
+func (sgh *StoryGenerationHelper) cacheMedia(ctx context.Context, key, kind, provider string, files map[string][]byte, meta map[string]string) {
+	if sgh.mediaCache == nil {
+		return
+	}
+	if err := sgh.mediaCache.Put(ctx, key, kind, provider, files, meta); err != nil {
+		sgh.logger.Warnf("Failed to cache %s from %s: %v", kind, provider, err)
+	}
+}
+
+// cachedNarration returns cached audio and, when it was timed, its read-along timing
+func (sgh *StoryGenerationHelper) cachedNarration(ctx context.Context, key string) ([]byte, *readalong.Timing, map[string]string, bool) {
+	files, meta, ok := sgh.cachedMedia(ctx, key)
+	if !ok || len(files["audio"]) == 0 {
+		return nil, nil, nil, false
+	}
+	var timing *readalong.Timing
+	if data, found := files["timing"]; found {
+		timing = &readalong.Timing{}
+		if err := json.Unmarshal(data, timing); err != nil {
+			sgh.logger.Warnf("Cached timing is unreadable, narrating again: %v", err)
+			return nil, nil, nil, false
+		}
+	}
+	return files["audio"], timing, meta, true
+}
+
+// cacheNarration stores narrated audio with its real extension, its timing if any, and meta
+func (sgh *StoryGenerationHelper) cacheNarration(ctx context.Context, key, provider string, audioData []byte, timing *readalong.Timing, meta map[string]string) {
+	if sgh.mediaCache == nil {
+		return
+	}
+	info, err := audiofile.Probe(audioData)
+	if err != nil {
+		sgh.logger.Warnf("Not caching unreadable audio from %s: %v", provider, err)
+		return
+	}
+	files := map[string][]byte{"audio." + info.Extension: audioData}
+	if timing != nil {
+		timingJSON, err := timing.JSON()
+		if err != nil {
+			sgh.logger.Warnf("Not caching audio from %s with unencodable timing: %v", provider, err)
+			return
+		}
+		files["timing.json"] = timingJSON
+	}
+	sgh.cacheMedia(ctx, key, mediacache.KindAudio, provider, files, meta)
+}
@@ -0,0 +1,241 @@
+// Package mediacache keeps synthesized audio and generated images under a hash of everything that
+// went into them, so the same text in the same voice, or the same image prompt, is paid for once.
+// Files are stored once at content-addressed blob paths, cache/<kind>/<hash of the bytes>.<ext>,
+// which stories point to directly, and an index maps each key to its files. Entries not used for
+// the cache's TTL are misses, and the orphan cleanup job deletes them with the files nothing uses.
+package mediacache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix is the storage folder of cached files
+const Prefix = "cache/"
+
+// Kinds of cached media
+const (
+	KindAudio = "audio"
+	KindImage = "image"
+)
+
+// Entry is one cached result: the blob path of each of its files, keyed by file name, and any
+// details the caller needs back on a hit
+type Entry struct {
+	Key       string
+	Kind      string
+	Provider  string
+	Files     map[string]string
+	Meta      map[string]string
+	CreatedAt time.Time
+	// LastUsedAt is the time of the last hit, or CreatedAt before the first
+	LastUsedAt time.Time
+}
+
+// ToMap converts the entry into the shape stored in the media cache collection
+func (e *Entry) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"kind":       e.Kind,
+		"provider":   e.Provider,
+		"files":      e.Files,
+		"meta":       e.Meta,
+		"created_at": e.CreatedAt,
+		"hits":       0,
+	}
+}
+
+// EntryFromMap reads an entry stored by ToMap
+func EntryFromMap(key string, data map[string]interface{}) *Entry {
+	entry := &Entry{Key: key, Files: map[string]string{}, Meta: map[string]string{}}
+	entry.Kind, _ = data["kind"].(string)
+	entry.Provider, _ = data["provider"].(string)
+	entry.CreatedAt, _ = data["created_at"].(time.Time)
+	entry.LastUsedAt = entry.CreatedAt
+	if lastHit, ok := data["last_hit_at"].(time.Time); ok && lastHit.After(entry.LastUsedAt) {
+		entry.LastUsedAt = lastHit
+	}
+	for field, values := range map[string]map[string]string{"files": entry.Files, "meta": entry.Meta} {
+		stored, _ := data[field].(map[string]interface{})
+		for name, value := range stored {
+			if text, ok := value.(string); ok {
+				values[name] = text
+			}
+		}
+	}
+	return entry
+}
+
+// Index maps content hashes to cache entries
+type Index interface {
+	// Get returns the entry of key, or nil on a miss
+	Get(ctx context.Context, key string) (*Entry, error)
+	Put(ctx context.Context, entry *Entry) error
+	// Hit records that the entry of key was served
+	Hit(ctx context.Context, key string) error
+}
+
+// MemoryIndex is an Index held by this instance only. Entries unused for ttl are dropped as
+// others are added, so it does not grow without bound.
+type MemoryIndex struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+	ttl     time.Duration
+}
+
+// NewMemoryIndex creates an empty in-memory index
+func NewMemoryIndex(ttl time.Duration) *MemoryIndex {
+	return &MemoryIndex{entries: map[string]*Entry{}, ttl: ttl}
+}
+
+func (m *MemoryIndex) Get(ctx context.Context, key string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[key], nil
+}
+
+func (m *MemoryIndex) Put(ctx context.Context, entry *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttl > 0 {
+		for key, existing := range m.entries {
+			if expired(existing, m.ttl) {
+				delete(m.entries, key)
+			}
+		}
+	}
+	m.entries[entry.Key] = entry
+	return nil
+}
+
+func (m *MemoryIndex) Hit(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries[key]; ok {
+		entry.LastUsedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+// Storage holds the cached files
+type Storage interface {
+	UploadFileAtPath(fileData []byte, path string) (string, error)
+	ReadBlob(path string) ([]byte, error)
+	// TouchBlob marks the file at path as used now, reporting false when there is none
+	TouchBlob(path string) (bool, error)
+}
+
+// Cache stores media in Storage and looks it up through an Index
+type Cache struct {
+	index   Index
+	storage Storage
+	ttl     time.Duration
+}
+
+// New creates a cache over index and storage whose entries miss once unused for ttl; zero keeps them forever
+func New(index Index, storage Storage, ttl time.Duration) *Cache {
+	return &Cache{index: index, storage: storage, ttl: ttl}
+}
+
+// expired reports whether an entry has gone unused for ttl
+func expired(entry *Entry, ttl time.Duration) bool {
+	return time.Since(entry.LastUsedAt) > ttl
+}
+
+// Path returns the content-addressed blob path of a file of kind: the hash of its bytes with the
+// extension of name
+func Path(kind, name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%s%s/%s%s", Prefix, kind, hex.EncodeToString(sum[:]), path.Ext(name))
+}
+
+// BaseName returns a cached file name without its extension, if it has one
+func BaseName(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// Shared reports whether a blob path is a cached file. Stories can share one, so only the orphan
+// cleanup job deletes them.
+func Shared(blobPath string) bool {
+	return strings.HasPrefix(blobPath, Prefix)
+}
+
+// Store uploads data to its content-addressed path and returns the path. Identical data is already
+// there, so it is only marked as used, keeping the cleanup job from deleting it before the caller
+// points a story to it.
+func Store(storage Storage, kind, name string, data []byte) (string, error) {
+	blobPath := Path(kind, name, data)
+	if exists, err := storage.TouchBlob(blobPath); err == nil && exists {
+		return blobPath, nil
+	}
+	return storage.UploadFileAtPath(data, blobPath)
+}
+
+// Key hashes the parts that determine a result, such as provider, voice, locale, input and output
+// format. Each part is length-prefixed, so moving text from one part to the next changes the key.
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(strconv.Itoa(len(part)) + ":" + part))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the entry of key with the contents of its files, keyed by file name. The entry is nil
+// on a miss, including when the index names a file that can no longer be read.
+func (c *Cache) Get(ctx context.Context, key string) (*Entry, map[string][]byte, error) {
+	entry, err := c.index.Get(ctx, key)
+	if err != nil || entry == nil {
+		return nil, nil, err
+	}
+	// The cleanup job may already be deleting the files of an expired entry
+	if c.ttl > 0 && expired(entry, c.ttl) {
+		return nil, nil, nil
+	}
+	files := make(map[string][]byte, len(entry.Files))
+	for name, path := range entry.Files {
+		data, err := c.storage.ReadBlob(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cached file %s is unreadable: %v", path, err)
+		}
+		files[name] = data
+	}
+	// The hit count is only informational, so failing to record it does not fail the lookup
+	if err := c.index.Hit(ctx, key); err != nil {
+		log.Printf("Warning: failed to record media cache hit on %s: %v", key, err)
+	}
+	return entry, files, nil
+}
+
+// Put stores files, keyed by file name with its extension, at their content-addressed paths and
+// indexes them under key with meta
+func (c *Cache) Put(ctx context.Context, key, kind, provider string, files map[string][]byte, meta map[string]string) error {
+	now := time.Now().UTC()
+	entry := &Entry{
+		Key:        key,
+		Kind:       kind,
+		Provider:   provider,
+		Files:      make(map[string]string, len(files)),
+		Meta:       meta,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if entry.Meta == nil {
+		entry.Meta = map[string]string{}
+	}
+	for name, data := range files {
+		blobPath, err := Store(c.storage, kind, name, data)
+		if err != nil {
+			return err
+		}
+		entry.Files[name] = blobPath
+	}
+	return c.index.Put(ctx, entry)
+}
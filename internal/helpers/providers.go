@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"rio-go-model/internal/helpers/dialogue"
+	"rio-go-model/internal/helpers/fake"
+	"rio-go-model/internal/helpers/readalong"
+	"rio-go-model/internal/model"
+)
+
+// StoryTextProvider writes story topics and stories
+type StoryTextProvider interface {
+	CreateTopics(prompt string) (*model.TopicResponse, error)
+	CreateStory(theme, topic string, kwargs map[string]interface{}) (*model.StoryResponse, error)
+}
+
+// GeminiTextProvider is a StoryTextProvider that also answers free-form prompts
+type GeminiTextProvider interface {
+	StoryTextProvider
+	GenerateText(prompt string, modelName string) (*model.StoryResponse, error)
+}
+
+// ImageProvider draws an illustration for a prompt, keeping to the style sheet when one is given
+type ImageProvider interface {
+	CreateImage(prompt string, sheet *model.StyleSheet) (*ImageResponse, error)
+}
+
+// SpeechProvider narrates story text, returning the audio and the billed characters
+type SpeechProvider interface {
+	GenerateAudioAdapter(text string, language string, theme string, voice string) ([]byte, int32, error)
+}
+
+// ReadAlongProvider is a SpeechProvider that also times every word for read-along highlighting.
+// The timing is nil when the chosen voice cannot report it.
+type ReadAlongProvider interface {
+	GenerateReadAlongAdapter(text string, language string, theme string, voice string) ([]byte, *readalong.Timing, int32, error)
+}
+
+// VoicePicker is a SpeechProvider that can pick a voice of a voice type up front. Passing the picked
+// voice as the voice of the other methods reads in it, so several narrations sound alike.
+type VoicePicker interface {
+	PickVoice(language string, theme string, voice string) (string, error)
+}
+
+// DialogueProvider is a SpeechProvider that reads every line in the voice of its speaker.
+// It returns the audio, the voice of each speaker and the billed characters.
+type DialogueProvider interface {
+	GenerateDialogueAdapter(lines []dialogue.Line, language string, theme string, voice string) ([]byte, map[string]string, int32, error)
+}
+
+// FallbackAudioProvider narrates text when the speech provider is over budget
+type FallbackAudioProvider interface {
+	GenerateAudio(prompt string) ([]byte, error)
+}
+
+// fakeImageCreator adapts fake.Images to ImageProvider
+type fakeImageCreator struct {
+	images fake.Images
+}
+
+// CreateImage returns a placeholder PNG seeded by the style sheet
+func (f fakeImageCreator) CreateImage(prompt string, sheet *model.StyleSheet) (*ImageResponse, error) {
+	var seed int64
+	if sheet != nil {
+		seed = sheet.Seed
+	}
+	return &ImageResponse{Data: f.images.Generate(prompt, seed)}, nil
+}
@@ -0,0 +1,143 @@
+// Package readalong builds the word timing used to highlight story text as it is narrated.
+// Every word is preceded by an SSML mark, the speech provider reports when each mark is reached,
+// and the marks of a narration synthesized in chunks are rebased onto one timeline.
+package readalong
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Word is one narrated word and when it is spoken, in seconds from the start of the audio
+type Word struct {
+	Index int     `json:"index"`
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Timing is the read-along data stored next to the audio of a story
+type Timing struct {
+	Duration float64 `json:"duration"`
+	Words    []Word  `json:"words"`
+}
+
+// Timepoint is an SSML mark the provider reached, in seconds from the start of the chunk audio
+type Timepoint struct {
+	Mark    string
+	Seconds float64
+}
+
+// Chunk is a piece of the text as SSML with a mark before every word
+type Chunk struct {
+	SSML string
+	// Words are the marked words of the chunk; only Index and Text are set
+	Words []Word
+}
+
+// MarkName returns the name of the mark placed before the word with the given index
+func MarkName(index int) string {
+	return "w" + strconv.Itoa(index)
+}
+
+// Split marks every word of text and packs the words into SSML chunks of at most maxBytes.
+// Words are numbered across the whole text, so mark names stay unique between chunks.
+// A single word longer than maxBytes gets a chunk of its own.
+func Split(text string, maxBytes int) []Chunk {
+	const open, closing = "<speak>", "</speak>"
+	var chunks []Chunk
+	var body strings.Builder
+	var words []Word
+	flush := func() {
+		if len(words) > 0 {
+			chunks = append(chunks, Chunk{SSML: open + body.String() + closing, Words: words})
+		}
+		body.Reset()
+		words = nil
+	}
+	for i, word := range strings.Fields(text) {
+		marked := fmt.Sprintf(`<mark name="%s"/>%s `, MarkName(i), escape(word))
+		if len(words) > 0 && len(open)+body.Len()+len(marked)+len(closing) > maxBytes {
+			flush()
+		}
+		body.WriteString(marked)
+		words = append(words, Word{Index: i, Text: word})
+	}
+	flush()
+	return chunks
+}
+
+// escape makes text safe to place inside SSML
+func escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// Builder assembles the timing of a narration synthesized chunk by chunk
+type Builder struct {
+	timing Timing
+}
+
+// AddChunk places the words of the next chunk on the timeline. timepoints count from the start
+// of the chunk audio, which lasts duration; words the provider reported no mark for are left out.
+func (b *Builder) AddChunk(chunk Chunk, timepoints []Timepoint, duration time.Duration) {
+	offset := b.timing.Duration
+	seconds := make(map[string]float64, len(timepoints))
+	for _, timepoint := range timepoints {
+		seconds[timepoint.Mark] = timepoint.Seconds
+	}
+	var words []Word
+	for _, word := range chunk.Words {
+		start, ok := seconds[MarkName(word.Index)]
+		if !ok {
+			continue
+		}
+		word.Start = offset + start
+		words = append(words, word)
+	}
+	end := offset + duration.Seconds()
+	// A word lasts until the next one starts; the last one until its chunk ends
+	for i := range words {
+		words[i].End = end
+		if i+1 < len(words) {
+			words[i].End = words[i+1].Start
+		}
+	}
+	b.timing.Words = append(b.timing.Words, words...)
+	b.timing.Duration = end
+}
+
+// Timing returns the timing built so far
+func (b *Builder) Timing() *Timing {
+	timing := b.timing
+	return &timing
+}
+
+// JSON encodes the timing as stored alongside the audio
+func (t *Timing) JSON() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// WebVTT renders the timing as a WebVTT caption track with one cue per word
+func (t *Timing) WebVTT() []byte {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, word := range t.Words {
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, vttTimestamp(word.Start), vttTimestamp(word.End), vttEscaper.Replace(word.Text))
+	}
+	return []byte(b.String())
+}
+
+// vttEscaper escapes the characters WebVTT cue text reserves
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// vttTimestamp formats seconds as hh:mm:ss.ttt
+func vttTimestamp(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, d.Milliseconds()%1000)
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/mediacache"
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/services/database"
+	"rio-go-model/internal/services/scheduler"
+)
+
+// orphanBlobPrefixes are the storage folders holding generated story media, the content-addressed
+// media cache folder included
+var orphanBlobPrefixes = []string{"images/", "audio/", "timing/", mediacache.Prefix}
+
+// MaintenanceJobs returns the nightly pre-generation and maintenance jobs
+func MaintenanceJobs(storyDB *database.StoryDatabase, storageService *database.StorageService) []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:        "pregenerate-profiles",
+			Schedule:    "0 2 * * *",
+			Timeout:     2 * time.Hour,
+			Concurrency: scheduler.Forbid,
+			Run: func(ctx context.Context) (string, error) {
+				return NewStoryGenerationHelper(storyDB, storageService).pregenerateProfiles(ctx)
+			},
+		},
+		{
+			Name:        "reset-api-triggers",
+			Schedule:    "10 0 * * *",
+			Timeout:     2 * time.Minute,
+			Concurrency: scheduler.Forbid,
+			Run: func(ctx context.Context) (string, error) {
+				reset, err := storyDB.ResetExpiredAPITriggers(ctx)
+				return fmt.Sprintf("reset %d api triggers %v", len(reset), reset), err
+			},
+		},
+		{
+			Name:        "backfill-media",
+			Schedule:    "0 4 * * *",
+			Timeout:     time.Hour,
+			Concurrency: scheduler.Forbid,
+			Run: func(ctx context.Context) (string, error) {
+				return NewStoryGenerationHelper(storyDB, storageService).backfillMissingMedia(ctx)
+			},
+		},
+		{
+			Name:        "clean-orphan-blobs",
+			Schedule:    "0 5 * * 0",
+			Timeout:     time.Hour,
+			Concurrency: scheduler.Forbid,
+			Run: func(ctx context.Context) (string, error) {
+				return cleanOrphanBlobs(ctx, storyDB, storageService)
+			},
+		},
+	}
+}
+
+// pregenerateProfiles generates stories ahead of time for the most common profile keys,
+// so new users with those settings find their stories ready
+func (sgh *StoryGenerationHelper) pregenerateProfiles(ctx context.Context) (string, error) {
+	keys, err := sgh.storyDatabase.MostCommonProfileKeys(ctx, sgh.settings.PregenerateProfileKeys)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		metadata := &MetadataRequest{
+			Country:     key.Country,
+			City:        key.City,
+			Religions:   key.Religions,
+			Preferences: key.Preferences,
+			Language:    key.Language,
+		}
+		if err := metadata.Validate(); err != nil {
+			sgh.logger.Warnf("Skipping profile key %s/%s: %v", key.Country, key.Language, err)
+			continue
+		}
+		sgh.logger.Infof("Pre-generating stories for %s/%s %v (%d users)", key.Country, key.Language, key.Preferences, key.Users)
+		sgh.generateProfileStories(ctx, "", metadata)
+	}
+	return fmt.Sprintf("pre-generated stories for %d profile keys", len(keys)), nil
+}
+
+// backfillMissingMedia regenerates the image or audio of stories saved without them
+func (sgh *StoryGenerationHelper) backfillMissingMedia(ctx context.Context) (string, error) {
+	stories, err := sgh.storyDatabase.ListStoriesMissingMedia(ctx, sgh.settings.BackfillStoriesPerRun)
+	if err != nil {
+		return "", err
+	}
+	filled, failed := 0, 0
+	for _, story := range stories {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err := sgh.backfillStoryMedia(ctx, story); err != nil {
+			sgh.logger.Errorf("Failed to backfill media of story %v: %v", story["id"], err)
+			failed++
+			continue
+		}
+		filled++
+	}
+	summary := fmt.Sprintf("backfilled %d of %d stories", filled, len(stories))
+	if failed > 0 {
+		return summary, fmt.Errorf("%d stories could not be backfilled", failed)
+	}
+	return summary, nil
+}
+
+// backfillStoryMedia generates whichever of the image and audio a story is missing
+func (sgh *StoryGenerationHelper) backfillStoryMedia(ctx context.Context, story map[string]interface{}) error {
+	docID, _ := story["id"].(string)
+	title, _ := story["title"].(string)
+	storyText, _ := story["story_text"].(string)
+	language, _ := story["language"].(string)
+	theme, _ := story["theme"].(string)
+	storyID, _ := story["story_id"].(string)
+	ctx = withCostScope(ctx, costScope{Theme: theme, StoryID: storyID})
+	updates := make(map[string]interface{})
+
+	if imageURL, _ := story["image_url"].(string); imageURL == "" {
+		var sheet *model.StyleSheet
+		if sheetData, ok := story["style_sheet"].(map[string]interface{}); ok {
+			sheet = (&model.StyleSheet{}).FromMap(sheetData)
+		}
+		imageData, result, err := sgh.generateCheckedImage(ctx, title, sheet)
+		if err != nil {
+			return fmt.Errorf("image generation failed: %v", err)
+		}
+		paths, err := sgh.uploadImageRenditions(imageData, result)
+		if err != nil {
+			return fmt.Errorf("image upload failed: %v", err)
+		}
+		updates["image_url"] = paths["original"]
+		updates["image_thumb_url"] = paths["thumb"]
+		updates["image_medium_url"] = paths["medium"]
+	}
+
+	if audioURL, _ := story["audio_url"].(string); audioURL == "" {
+		audioData, _, err := sgh.generateStoryAudio(ctx, storyText, language, theme)
+		if err != nil {
+			return fmt.Errorf("audio generation failed: %v", err)
+		}
+		audioFields, err := uploadAudio(sgh.storageService, audioData)
+		if err != nil {
+			return fmt.Errorf("audio upload failed: %v", err)
+		}
+		for key, value := range audioFields {
+			updates[key] = value
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	return sgh.storyDatabase.UpdateStory(ctx, docID, updates)
+}
+
+// cleanOrphanBlobs expires media cache entries unused for MEDIA_CACHE_TTL_DAYS, then deletes generated
+// media that neither a story nor a live cache entry points to. Files used within the last
+// ORPHAN_BLOB_MIN_AGE_HOURS are kept so stories still being generated are not affected.
+func cleanOrphanBlobs(ctx context.Context, storyDB *database.StoryDatabase, storageService *database.StorageService) (string, error) {
+	settings := configs.GetSettings()
+	minAge := time.Duration(settings.OrphanBlobMinAgeHours) * time.Hour
+	ttl := time.Duration(settings.MediaCacheTTLDays) * 24 * time.Hour
+	expired, cached, err := storyDB.ExpireMediaCacheEntries(ctx, time.Now().Add(-ttl))
+	if err != nil {
+		return "", err
+	}
+	referenced, err := storyDB.ReferencedBlobs(ctx)
+	if err != nil {
+		return "", err
+	}
+	// An empty result more likely means a bad read than a bucket of orphans
+	if len(referenced) == 0 {
+		return "", fmt.Errorf("no story media found, refusing to delete")
+	}
+	cutoff := time.Now().Add(-minAge)
+	deleted, scanned := 0, 0
+	var failures []string
+	for _, prefix := range orphanBlobPrefixes {
+		blobs, err := storageService.ListBlobs(ctx, prefix)
+		if err != nil {
+			return "", err
+		}
+		for _, blob := range blobs {
+			scanned++
+			// A cached file is touched whenever a story reuses it, which moves its update time
+			if referenced[blob.Name] || cached[blob.Name] || blob.Updated.After(cutoff) {
+				continue
+			}
+			if err := storageService.DeleteFile(blob.Name); err != nil {
+				failures = append(failures, blob.Name)
+				continue
+			}
+			deleted++
+		}
+	}
+	summary := fmt.Sprintf("expired %d media cache entries, deleted %d orphaned of %d scanned files", expired, deleted, scanned)
+	if len(failures) > 0 {
+		return summary, fmt.Errorf("failed to delete %s", strings.Join(failures, ", "))
+	}
+	return summary, nil
+}
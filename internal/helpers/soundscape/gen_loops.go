@@ -0,0 +1,119 @@
+//go:build ignore
+
+// gen_loops synthesizes the bundled soundscape loops into loops/. The loops are generated rather than
+// recorded so they carry no license and can be rebuilt: go generate ./internal/helpers/soundscape
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"rio-go-model/internal/helpers/audiofile"
+)
+
+const (
+	sampleRate = 16000
+	seconds    = 6
+	length     = sampleRate * seconds
+	// crossfade is how much extra audio is rendered and folded back onto the start to hide the seam
+	crossfade = sampleRate / 2
+)
+
+func main() {
+	loops := map[string]func(*rand.Rand) []float64{
+		"forest":  forest,
+		"ocean":   ocean,
+		"lullaby": lullaby,
+	}
+	for name, render := range loops {
+		samples := seamless(render(rand.New(rand.NewSource(int64(len(name))))))
+		pcm := &audiofile.PCM{SampleRate: sampleRate, Channels: 1, Samples: toInt16(samples)}
+		path := filepath.Join("loops", name+".wav")
+		if err := os.WriteFile(path, pcm.WAV(), 0o644); err != nil {
+			log.Fatalf("failed to write %s: %v", path, err)
+		}
+		log.Printf("wrote %s", path)
+	}
+}
+
+// forest is a soft breeze with a few bird chirps
+func forest(r *rand.Rand) []float64 {
+	out := make([]float64, length+crossfade)
+	var low float64
+	for i := range out {
+		low += 0.02 * (r.Float64()*2 - 1 - low)
+		gust := 0.6 + 0.4*math.Sin(2*math.Pi*float64(i)/float64(length))
+		out[i] = 1.5 * low * gust
+	}
+	for chirp := 0; chirp < 7; chirp++ {
+		start := r.Intn(len(out) - sampleRate/2)
+		base := 2500 + r.Float64()*1500
+		for note := 0; note < 2+r.Intn(3); note++ {
+			offset := start + note*sampleRate/10
+			for j := 0; j < sampleRate/14 && offset+j < len(out); j++ {
+				t := float64(j) / sampleRate
+				envelope := math.Sin(math.Pi * float64(j) / float64(sampleRate/14))
+				frequency := base + 1800*t*14
+				out[offset+j] += 0.18 * envelope * math.Sin(2*math.Pi*frequency*t)
+			}
+		}
+	}
+	return out
+}
+
+// ocean is low noise swelling and ebbing once per loop, like a wave
+func ocean(r *rand.Rand) []float64 {
+	out := make([]float64, length+crossfade)
+	var low, lower float64
+	for i := range out {
+		low += 0.05 * (r.Float64()*2 - 1 - low)
+		lower += 0.3 * (low - lower)
+		swell := 0.25 + 0.75*math.Pow(math.Sin(math.Pi*float64(i%length)/float64(length)), 2)
+		out[i] = 2.2 * lower * swell
+	}
+	return out
+}
+
+// lullaby is a slow music-box tune on a pentatonic scale
+func lullaby(r *rand.Rand) []float64 {
+	out := make([]float64, length+crossfade)
+	scale := []float64{523.25, 587.33, 659.25, 783.99, 880.00}
+	melody := []int{2, 1, 0, 1, 2, 2, 2, 4, 3, 1, 0, 0}
+	step := length / len(melody)
+	for n, degree := range melody {
+		frequency := scale[degree] / 2
+		start := n * step
+		for j := 0; start+j < len(out) && j < 3*step; j++ {
+			t := float64(j) / sampleRate
+			tone := math.Sin(2*math.Pi*frequency*t) + 0.3*math.Sin(4*math.Pi*frequency*t)
+			out[start+j] += 0.3 * math.Exp(-2.5*t) * tone
+		}
+	}
+	return out
+}
+
+// seamless folds the audio rendered past the loop length back onto the start with a crossfade
+func seamless(samples []float64) []float64 {
+	out := append([]float64(nil), samples[:length]...)
+	for i := 0; i < crossfade; i++ {
+		fade := float64(i) / crossfade
+		out[i] = out[i]*fade + samples[length+i]*(1-fade)
+	}
+	return out
+}
+
+// toInt16 scales the samples so the loudest reaches about -3 dBFS
+func toInt16(samples []float64) []int16 {
+	var peak float64
+	for _, sample := range samples {
+		peak = math.Max(peak, math.Abs(sample))
+	}
+	out := make([]int16, len(samples))
+	for i, sample := range samples {
+		out[i] = int16(sample / peak * 0.7 * math.MaxInt16)
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+// Package soundscape mixes a bundled ambient loop, such as ocean waves or a soft lullaby, under narration.
+// Mixing is done on 16-bit PCM: the loop is resampled to the narration, repeated for its length,
+// turned down, faded in at the start and out over a short tail after the narration ends.
+package soundscape
+
+//go:generate go run gen_loops.go
+
+import (
+	"embed"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"rio-go-model/internal/helpers/audiofile"
+)
+
+//go:embed loops/*.wav
+var loops embed.FS
+
+const (
+	fadeIn = 2 * time.Second
+	// tail is how long the soundscape plays on after the narration, fading out
+	tail = 4 * time.Second
+)
+
+// themeSoundscapes matches each story theme to the loop played under it
+var themeSoundscapes = map[string]string{
+	"1": "forest",
+	"2": "ocean",
+	"3": "lullaby",
+}
+
+// ForTheme returns the name of the loop matched to a story theme, given by its id as StoryHelper gets it
+func ForTheme(theme string) (string, bool) {
+	name, ok := themeSoundscapes[theme]
+	return name, ok
+}
+
+// Names lists the bundled loops
+func Names() []string {
+	entries, _ := loops.ReadDir("loops")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".wav"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mix returns narration, a 16-bit PCM WAV file, with the named loop under it at volume, a fraction of
+// full scale. The narration keeps its timing, so read-along timing fits the mix too.
+func Mix(narration []byte, name string, volume float64) ([]byte, error) {
+	voice, err := audiofile.DecodePCM(narration)
+	if err != nil {
+		return nil, fmt.Errorf("narration cannot be mixed: %v", err)
+	}
+	data, err := loops.ReadFile("loops/" + name + ".wav")
+	if err != nil {
+		return nil, fmt.Errorf("unknown soundscape %q", name)
+	}
+	loop, err := audiofile.DecodePCM(data)
+	if err != nil {
+		return nil, fmt.Errorf("soundscape %s is unreadable: %v", name, err)
+	}
+	bed := resample(loop, voice.SampleRate)
+
+	rate := voice.SampleRate
+	channels := voice.Channels
+	frames := voice.Frames() + int(tail.Seconds()*float64(rate))
+	fadeInFrames := int(fadeIn.Seconds() * float64(rate))
+	fadeOutStart := voice.Frames()
+	fadeOutFrames := frames - fadeOutStart
+
+	mixed := make([]int16, frames*channels)
+	for frame := 0; frame < frames; frame++ {
+		gain := volume
+		if frame < fadeInFrames {
+			gain *= float64(frame) / float64(fadeInFrames)
+		}
+		if frame >= fadeOutStart {
+			gain *= 1 - float64(frame-fadeOutStart)/float64(fadeOutFrames)
+		}
+		background := gain * bed[frame%len(bed)]
+		for channel := 0; channel < channels; channel++ {
+			sample := background
+			if frame < voice.Frames() {
+				sample += float64(voice.Samples[frame*channels+channel])
+			}
+			mixed[frame*channels+channel] = clip(sample)
+		}
+	}
+	return (&audiofile.PCM{SampleRate: rate, Channels: channels, Samples: mixed}).WAV(), nil
+}
+
+// resample converts a loop to mono at rate by linear interpolation, wrapping at the end so it stays seamless
+func resample(loop *audiofile.PCM, rate int) []float64 {
+	mono := make([]float64, loop.Frames())
+	for frame := range mono {
+		var sum float64
+		for channel := 0; channel < loop.Channels; channel++ {
+			sum += float64(loop.Samples[frame*loop.Channels+channel])
+		}
+		mono[frame] = sum / float64(loop.Channels)
+	}
+	if loop.SampleRate == rate {
+		return mono
+	}
+	out := make([]float64, int(float64(len(mono))*float64(rate)/float64(loop.SampleRate)))
+	step := float64(loop.SampleRate) / float64(rate)
+	for i := range out {
+		position := float64(i) * step
+		index := int(position)
+		fraction := position - float64(index)
+		out[i] = mono[index%len(mono)]*(1-fraction) + mono[(index+1)%len(mono)]*fraction
+	}
+	return out
+}
+
+// clip rounds a mixed sample into the 16-bit range
+func clip(sample float64) int16 {
+	return int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(sample))))
+}
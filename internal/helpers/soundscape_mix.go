@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"fmt"
+
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/soundscape"
+)
+
+// soundscapeFor returns the loop to mix under a story of theme id, or "" when none was requested, the
+// theme has none or the speech provider cannot narrate to WAV
+func (sgh *StoryGenerationHelper) soundscapeFor(requested bool, theme string) string {
+	if !requested || sgh.pcmStoryGenerator == nil {
+		return ""
+	}
+	name, _ := soundscape.ForTheme(theme)
+	return name
+}
+
+// uploadSoundscape mixes the named loop under WAV narration and uploads the mix as a second rendition.
+// It returns the soundscape and soundscape_audio_url fields of a story.
+func (sgh *StoryGenerationHelper) uploadSoundscape(audioData []byte, name string) (map[string]interface{}, error) {
+	mixed, err := soundscape.Mix(audioData, name, float64(sgh.settings.SoundscapeVolume)/100)
+	if err != nil {
+		return nil, err
+	}
+	url, err := sgh.storageService.UploadFile(mixed, "audio", audiofile.FormatWAV)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload soundscape mix: %v", err)
+	}
+	return map[string]interface{}{
+		"soundscape":           name,
+		"soundscape_audio_url": url,
+	}, nil
+}
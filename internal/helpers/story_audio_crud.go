@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/mediacache"
 	"rio-go-model/internal/services/database"
 	"rio-go-model/internal/util"
 )
@@ -21,6 +22,15 @@ func NewStoryAudioCrud(db *database.StoryDatabase, storageService *database.Stor
 
 }
 
+// narrationFields are the story fields written with its narration; a reset replaces or clears every one
+var narrationFields = []string{"audio_url", "timing_url", "captions_url", "soundscape", "soundscape_audio_url", "cast"}
+
+// narrationBlobFields are the narration fields holding a blob path
+var narrationBlobFields = []string{"audio_url", "timing_url", "captions_url", "soundscape_audio_url"}
+
+// ResetAudioByThemeID narrates every story of a theme again, the way it was first narrated: in a voice
+// per character if it has a cast, with read-along timing if it has timing and with its soundscape.
+// Fields the new narration does not produce are cleared, and the old files are deleted.
 func (s *StoryAudioCrud) ResetAudioByThemeID(ctx context.Context, themeID string) error {
 	s.logger.Infof("Resetting audio by theme id: %s", themeID)
 	stories, err := s.db.GetStoryByThemeID(ctx, themeID)
@@ -28,51 +38,50 @@ func (s *StoryAudioCrud) ResetAudioByThemeID(ctx context.Context, themeID string
 		return fmt.Errorf("error getting stories by theme id: %v", err)
 	}
 
+	if s.storyGenerator == nil {
+		return fmt.Errorf("storyGenerator is nil")
+	}
+
 	for _, story := range stories {
-		s.storageService.DeleteFile(story["audio_url"].(string))
-		var audioData []byte
+		storyID := story["story_id"].(string)
+		storyText := story["story_text"].(string)
 		language := story["language"].(string)
 		theme := story["theme"].(string)
-		suspended, voice, err := s.db.SuspendAudioAPI(ctx, "audio")
-		if language != "Telugu" && (suspended || err != nil) {
-			if err != nil {
-				s.logger.Errorf("Failed to read audio api trigger: %v", err)
-			} else {
-				s.logger.Errorf("Google Audio API trigger is suspended; using fallback audio generator")
-			}
-			audioData, err = s.storyGenerator.audioGenerator.GenerateAudio(story["story_text"].(string))
-		} else {
-			s.logger.Infof("Using Google Audio API to generate story audio...")
-			s.logger.Infof("Language: %s, Story text length: %d", language, len(story["story_text"].(string)))
+		cast, _ := story["cast"].(map[string]interface{})
+		timingURL, _ := story["timing_url"].(string)
+		previousSoundscape, _ := story["soundscape"].(string)
+		soundscapeName := s.storyGenerator.soundscapeFor(previousSoundscape != "", theme)
+		s.logger.Infof("Language: %s, Story text length: %d", language, len(storyText))
 
-			if s.storyGenerator == nil {
-				s.logger.Errorf("storyGenerator is nil!")
-				continue
-			}
-			if s.storyGenerator.audioStoryGenerator == nil {
-				s.logger.Errorf("audioStoryGenerator is nil!")
-				continue
-			}
-
-			audioData, _, err = s.storyGenerator.audioStoryGenerator.GenerateAudioAdapter(story["story_text"].(string), language, theme, voice)
-			if err != nil {
-				s.logger.Errorf("GenerateAudioAdapter failed: %v", err)
-			} else {
-				s.logger.Infof("GenerateAudioAdapter succeeded, audio data length: %d", len(audioData))
-			}
-		}
+		// Narration unchanged since the last generation comes from the media cache without a provider call
+		audioData, timing, newCast, _, err := s.storyGenerator.narrate(ctx, storyText, language, theme, len(cast) > 0, timingURL != "", soundscapeName)
 		if err != nil {
 			s.logger.Errorf("Failed to generate audio file: %v", err)
 			continue
 		}
-		url, err := s.storageService.UploadFile(audioData, "audio", "wav")
+		fields, err := s.storyGenerator.uploadNarration(audioData, timing, soundscapeName)
 		if err != nil {
 			s.logger.Errorf("Failed to upload audio file: %v", err)
 			continue
 		}
-		story["audio_url"] = url
-		s.db.UpdateStory(ctx, story["story_id"].(string), story)
-
+		if len(newCast) > 0 {
+			fields["cast"] = newCast
+		}
+		for _, key := range narrationFields {
+			if _, ok := fields[key]; !ok {
+				fields[key] = database.DeleteField
+			}
+		}
+		if err := s.db.UpdateStory(ctx, storyID, fields); err != nil {
+			s.logger.Errorf("Failed to update story %s: %v", storyID, err)
+			continue
+		}
+		// The story no longer refers to the old files; cached ones may be shared and are left to the cleanup job
+		for _, key := range narrationBlobFields {
+			if path, ok := story[key].(string); ok && path != "" && !mediacache.Shared(path) {
+				s.storageService.DeleteFile(path)
+			}
+		}
 	}
 
 	return nil
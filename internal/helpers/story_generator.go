@@ -3,18 +3,26 @@ package helpers
 import (
 	"context"
 	"fmt"
+	"maps"
 	"runtime"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	// "log"
 
 	"rio-go-model/configs"
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/fake"
 	"rio-go-model/internal/helpers/google/audio"
 	"rio-go-model/internal/helpers/google/gemini"
 	"rio-go-model/internal/helpers/google/vertex"
 	"rio-go-model/internal/helpers/huggingface"
+	"rio-go-model/internal/helpers/imaging"
+	"rio-go-model/internal/helpers/mediacache"
+	"rio-go-model/internal/helpers/readalong"
 	"rio-go-model/internal/services/database"
 	"rio-go-model/internal/services/translator"
 	"rio-go-model/internal/services/tts"
@@ -29,18 +37,21 @@ import (
 type StoryGenerationHelper struct {
 	settings                    *configs.Settings
 	logger                      *util.CustomLogger
-	storyCreator                *huggingface.StoryCreator
+	storyCreator                StoryTextProvider
 	vertexAiStoryGenerator      *vertex.VertexStoryGenerationHelper
-	geminiStoryGenerator        *gemini.GeminiStoryGenerationHelper
+	geminiStoryGenerator        GeminiTextProvider
 	geminiImageGenerationHelper *gemini.GeminiImageGenerationHelper
-	audioStoryGenerator         *audio.GoogleTTS
-	imageCreator                *ImageCreator
-	audioGenerator              *AudioGenerator
-	dynamicPrompting            *DynamicPrompting
-	storyDatabase               *database.StoryDatabase
-	storageService              *database.StorageService
-	httpClient                  *HTTPClient
-	translator                  *translator.Translator
+	audioStoryGenerator         SpeechProvider
+	// pcmStoryGenerator narrates to WAV for soundscape mixing; nil when the speech provider cannot
+	pcmStoryGenerator SpeechProvider
+	imageCreator      ImageProvider
+	audioGenerator    FallbackAudioProvider
+	dynamicPrompting  *DynamicPrompting
+	storyDatabase     *database.StoryDatabase
+	storageService    *database.StorageService
+	httpClient        *HTTPClient
+	translator        translator.Translator
+	mediaCache        *mediacache.Cache
 }
 
 // HTTPClient represents an HTTP client with connection pooling
@@ -86,6 +97,35 @@ type MetadataRequest struct {
 	Religions   []string `json:"religions"`
 	Preferences []string `json:"preferences"`
 	Language    string   `json:"language"`
+	Storybook   bool     `json:"storybook,omitempty"`
+	// BilingualLanguage, when set, pairs every paragraph with its translation into this language
+	BilingualLanguage string `json:"bilingual_language,omitempty"`
+	// Dialogue reads quoted speech in a voice for each character
+	Dialogue bool `json:"dialogue,omitempty"`
+	// Soundscape adds a rendition of the audio with ambient sound matching the theme underneath
+	Soundscape bool `json:"soundscape,omitempty"`
+}
+
+// Validate defaults an empty language to English and rejects languages without a language pack
+func (m *MetadataRequest) Validate() error {
+	if m.Language == "" {
+		m.Language = languages.Default
+	}
+	if err := languages.Validate(m.Language); err != nil {
+		return err
+	}
+	return validateBilingual(m.Language, m.BilingualLanguage)
+}
+
+// storyOptions returns the per-request generation options passed down to StoryHelper
+func (m *MetadataRequest) storyOptions(email string) map[string]interface{} {
+	return map[string]interface{}{
+		"storybook":          m.Storybook,
+		"bilingual_language": m.BilingualLanguage,
+		"dialogue":           m.Dialogue,
+		"soundscape":         m.Soundscape,
+		"email":              email,
+	}
 }
 
 // NewStoryGenerationHelper creates a new story generation helper
@@ -96,26 +136,53 @@ func NewStoryGenerationHelper(
 	settings := configs.GetSettings()
 	logger := util.GetLogger("story.generator", settings)
 
-	return &StoryGenerationHelper{
-		settings:                    settings,
-		logger:                      logger,
-		storyCreator:                huggingface.NewStoryCreator(),
-		vertexAiStoryGenerator:      vertex.NewVertexStoryGenerationHelper(),
-		geminiStoryGenerator:        gemini.NewGeminiStoryGenerationHelper(),
-		geminiImageGenerationHelper: gemini.NewGeminiImageGenerationHelper(),
-		audioStoryGenerator:         audio.NewGoogleTTS(),
-		imageCreator:                NewImageCreator(),
-		audioGenerator:              NewAudioGenerator(),
-		dynamicPrompting:            NewDynamicPrompting(),
-		storyDatabase:               storyDB,
-		storageService:              storageService,
-		httpClient:                  &HTTPClient{}, // Initialize with proper client
-		translator:                  translator.NewTranslator(),
-	}
-}
-
-// GenerateImage generates an image from a prompt using AI
-func (sgh *StoryGenerationHelper) GenerateImage(prompt string) ([]byte, error) {
+	sgh := &StoryGenerationHelper{
+		settings:         settings,
+		logger:           logger,
+		dynamicPrompting: NewDynamicPrompting(),
+		storyDatabase:    storyDB,
+		storageService:   storageService,
+		httpClient:       &HTTPClient{}, // Initialize with proper client
+		mediaCache:       newMediaCache(settings, storyDB, storageService),
+	}
+
+	// Fake providers run the whole pipeline offline, without any API credentials
+	if settings.ProviderMode == configs.ProviderModeFake {
+		logger.Infof("PROVIDER_MODE=fake, using offline fake providers")
+		text := fake.NewText()
+		sgh.storyCreator = text
+		sgh.geminiStoryGenerator = text
+		speech := fake.NewSpeech()
+		sgh.audioStoryGenerator = speech
+		sgh.pcmStoryGenerator = speech.PCM()
+		sgh.imageCreator = fakeImageCreator{}
+		sgh.audioGenerator = fake.NewFallbackAudio()
+		sgh.translator = translator.NewDictionary()
+		return sgh
+	}
+
+	sgh.storyCreator = huggingface.NewStoryCreator()
+	sgh.vertexAiStoryGenerator = vertex.NewVertexStoryGenerationHelper()
+	sgh.geminiStoryGenerator = gemini.NewGeminiStoryGenerationHelper()
+	sgh.geminiImageGenerationHelper = gemini.NewGeminiImageGenerationHelper()
+	googleTTS := audio.NewGoogleTTS()
+	sgh.audioStoryGenerator = googleTTS
+	sgh.pcmStoryGenerator = googleTTS.PCM()
+	sgh.imageCreator = NewImageCreator()
+	sgh.audioGenerator = NewAudioGenerator()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if t, err := translator.Shared(ctx); err != nil {
+		logger.Errorf("Translation is unavailable: %v", err)
+		sgh.translator = translator.Unavailable(err)
+	} else {
+		sgh.translator = t
+	}
+	return sgh
+}
+
+// GenerateImage generates an image from a prompt using AI, keeping to the style sheet when one is given
+func (sgh *StoryGenerationHelper) GenerateImage(ctx context.Context, prompt string, sheet *model.StyleSheet) ([]byte, error) {
 	sgh.logger.Infof("Generating image for prompt: %s", prompt[:min(len(prompt), 50)])
 
 	// Add kid-friendly modifiers to the prompt
@@ -123,11 +190,22 @@ func (sgh *StoryGenerationHelper) GenerateImage(prompt string) ([]byte, error) {
 		"kid-friendly, child-safe, colorful, cute, playful, %s, suitable for children, cartoon style, soft colors, friendly characters",
 		prompt,
 	)
-	imgResp, err := sgh.imageCreator.CreateImage(kidFriendlyPrompt)
+	key := imageCacheKey("flux", kidFriendlyPrompt, sheet)
+	if files, _, ok := sgh.cachedMedia(ctx, key); ok && len(files["image"]) > 0 {
+		return files["image"], nil
+	}
+	imgResp, err := sgh.imageCreator.CreateImage(kidFriendlyPrompt, sheet)
 	if err != nil {
 		return nil, err
 
 	}
+	if len(imgResp.Data) > 0 {
+		sgh.recordCost(ctx, "flux", "black-forest-labs/FLUX.1-dev", model.UnitImages, 1)
+		// Only images that pass validation are cached, so a rejected image is not served again on retry
+		if format, err := imaging.Validate(imgResp.Data); err == nil {
+			sgh.cacheMedia(ctx, key, mediacache.KindImage, "flux", map[string][]byte{"image." + format: imgResp.Data}, nil)
+		}
+	}
 	return imgResp.Data, nil
 }
 
@@ -139,6 +217,19 @@ func (sgh *StoryGenerationHelper) GetCPUCore() int {
 // StoryHelper generates a complete story with image and audio
 func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_id, topic string, kwargs map[string]interface{}) error {
 	sgh.logger.Infof("Generating story for theme: %s, topic: %s", theme, topic)
+	// Generate unique story ID
+	storyID := uuid.New().String()
+	email, _ := kwargs["email"].(string)
+	ctx = withCostScope(ctx, costScope{Email: email, Theme: theme, StoryID: storyID})
+	// Pick the prompt version here so it can be recorded on the story
+	storyLanguage, _ := kwargs["language"].(string)
+	promptChoice := sgh.storyDatabase.GetPromptRegistry(ctx).Choose(configs.PromptTemplateName(theme), storyLanguage, email, storyID)
+	// Callers pass one kwargs to every topic, so the chosen template goes on a copy and a story
+	// drawn into the built-in prompt does not inherit the template of the story before it
+	kwargs = maps.Clone(kwargs)
+	if promptChoice.Template != nil {
+		kwargs["prompt_template"] = promptChoice.Template
+	}
 	var voice string
 	var isGemini bool
 	// Generate story using StoryCreator
@@ -146,18 +237,25 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 	isSuspended, err := sgh.storyDatabase.SuspendGeminiAPI(ctx, "gemini")
 	sgh.logger.Infof("Should suspend gemini api : %t", isSuspended)
 	var response *model.StoryResponse
-	if (err != nil || isSuspended) && kwargs["language"].(string) != "Telugu" {
+	if (err != nil || isSuspended) && languages.AllowsProvider(languages.Lookup(storyLanguage), languages.ProviderHuggingFace) {
 		response, err = sgh.storyCreator.CreateStory(theme, topic, kwargs)
 		isGemini = false
 	} else {
 		response, err = sgh.geminiStoryGenerator.CreateStory(theme, topic, kwargs)
 		isGemini = true
-		sgh.storyDatabase.UpdateAPITokens(ctx, "gemini", (int64)(response.TotalTokens))
+		if err == nil {
+			sgh.storyDatabase.UpdateAPITokens(ctx, "gemini", (int64)(response.TotalTokens))
+		}
 	}
 
 	if err != nil {
 		return fmt.Errorf("failed to generate story: %v", err)
 	}
+	if isGemini {
+		sgh.recordCost(ctx, "gemini", response.Model, model.UnitTokens, int64(response.TotalTokens))
+	} else {
+		sgh.recordCost(ctx, "huggingface", response.Model, model.UnitTokens, int64(response.TotalTokens))
+	}
 	if response.Error != "" {
 		return fmt.Errorf("story generation error: %s", response.Error)
 	}
@@ -165,80 +263,125 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 		return fmt.Errorf("no story text generated")
 	}
 	storyResponse = &StoryGenerationResponse{StoryText: response.Story}
+
+	// One style sheet per story keeps the cover and the page illustrations consistent
+	styleSheet, err := sgh.BuildStyleSheet(ctx, theme_id, theme, storyResponse.StoryText)
+	if err != nil {
+		sgh.logger.Errorf("Failed to build style sheet: %v", err)
+		styleSheet = nil
+	}
 	// Generate image and audio in parallel using worker pools
 	imageResultChan := make(chan struct {
-		data []byte
-		err  error
+		data   []byte
+		result *imaging.Result
+		err    error
 	}, 1)
 	audioResultChan := make(chan struct {
-		data []byte
-		err  error
+		data   []byte
+		timing *readalong.Timing
+		cast   map[string]string
+		err    error
+	}, 1)
+	pagesResultChan := make(chan struct {
+		pages []model.StoryPage
+		err   error
+	}, 1)
+	paragraphsResultChan := make(chan struct {
+		paragraphs []model.StoryParagraph
+		err        error
 	}, 1)
 
 	language := kwargs["language"].(string)
+	storybook, _ := kwargs["storybook"].(bool)
+	bilingualLanguage, _ := kwargs["bilingual_language"].(string)
+	dialogueMode, _ := kwargs["dialogue"].(bool)
+	soundscapeRequested, _ := kwargs["soundscape"].(bool)
+	soundscapeName := sgh.soundscapeFor(soundscapeRequested, theme)
+	workers := 2
 
 	// Start image generation worker
 	util.GoroutineWithRecovery(func() {
-		translatedTopic := topic
-		if language == "Telugu" {
-			translatedTopic, err = sgh.translator.Translate(topic)
-			if err != nil {
-				sgh.logger.Errorf("Failed to generate image: %v", err)
-			}
-			sgh.logger.Infof("Translated topic: %s", topic)
-		}
-		imageData, err := sgh.GenerateImage(translatedTopic)
+		translatedTopic := sgh.translateForImages(ctx, []string{topic}, language)[0]
+		imageData, imageResult, err := sgh.generateCheckedImage(ctx, translatedTopic, styleSheet)
 		if err != nil {
 			sgh.logger.Errorf("Failed to generate image: %v", err)
 			imageData = nil
 		}
 
 		imageResultChan <- struct {
-			data []byte
-			err  error
-		}{imageData, err}
+			data   []byte
+			result *imaging.Result
+			err    error
+		}{imageData, imageResult, err}
 	})
 
 	// Start audio generation worker
 	util.GoroutineWithRecovery(func() {
 		var audioData []byte
-		var totalTokens int32
-		var suspended bool
-		suspended, voice, err = sgh.storyDatabase.SuspendAudioAPI(ctx, "audio")
-		if (suspended || err != nil) && language != "Telugu" {
-			if err != nil {
-				sgh.logger.Errorf("Failed to read audio api trigger: %v", err)
-			} else {
-				sgh.logger.Errorf("Google Audio API trigger is suspended; using fallback audio generator")
-			}
-			audioData, err = sgh.audioGenerator.GenerateAudio(storyResponse.StoryText)
-		} else {
-			sgh.logger.Infof("Using Google Audio API to generate story audio...")
-			audioData, totalTokens, err = sgh.audioStoryGenerator.GenerateAudioAdapter(storyResponse.StoryText, language, theme, voice)
-			sgh.storyDatabase.UpdateAPITokens(ctx, "audio", (int64)(totalTokens))
-		}
+		var timing *readalong.Timing
+		var cast map[string]string
+		audioData, timing, cast, voice, err = sgh.narrate(ctx, storyResponse.StoryText, language, theme, dialogueMode, sgh.settings.ReadAlongEnabled, soundscapeName)
 		audioResultChan <- struct {
-			data []byte
-			err  error
-		}{audioData, err}
+			data   []byte
+			timing *readalong.Timing
+			cast   map[string]string
+			err    error
+		}{audioData, timing, cast, err}
 	})
 
+	// Storybook pages and bilingual paragraphs have their own deadline, since the story is kept without them
+	extrasCtx, cancelExtras := context.WithTimeout(ctx, sgh.settings.OptionalMediaTimeout)
+	defer cancelExtras()
+
 	// Wait for both operations to complete with timeout
 	ctx, cancel := context.WithTimeout(ctx, sgh.settings.HuggingFaceTimeout)
 	defer cancel()
 
+	// Start storybook page illustration worker
+	extras := 0
+	if storybook {
+		extras++
+		util.GoroutineWithRecovery(func() {
+			pages, err := sgh.generateStorybookPages(extrasCtx, topic, storyResponse.StoryText, language, styleSheet)
+			pagesResultChan <- struct {
+				pages []model.StoryPage
+				err   error
+			}{pages, err}
+		})
+	}
+
+	// Start bilingual paragraph worker
+	if bilingualLanguage != "" {
+		extras++
+		util.GoroutineWithRecovery(func() {
+			paragraphs, err := sgh.generateBilingualParagraphs(extrasCtx, storyResponse.StoryText, language, bilingualLanguage, theme)
+			paragraphsResultChan <- struct {
+				paragraphs []model.StoryParagraph
+				err        error
+			}{paragraphs, err}
+		})
+	}
+
 	var imageData []byte
+	var imageProcessed *imaging.Result
 	var audioData []byte
+	var timing *readalong.Timing
+	var cast map[string]string
+	var pages []model.StoryPage
+	var paragraphs []model.StoryParagraph
 	var imageErr, audioErr error
 
 	// Collect results
-	for i := 0; i < 2; i++ {
+	for i := 0; i < workers; i++ {
 		select {
 		case imageResult := <-imageResultChan:
 			imageData = imageResult.data
+			imageProcessed = imageResult.result
 			imageErr = imageResult.err
 		case audioResult := <-audioResultChan:
 			audioData = audioResult.data
+			timing = audioResult.timing
+			cast = audioResult.cast
 			audioErr = audioResult.err
 		case <-ctx.Done():
 			return fmt.Errorf("timeout waiting for image/audio generation")
@@ -255,39 +398,37 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 		return fmt.Errorf("audio generation failed: %v", audioErr)
 	}
 
-	// Generate unique story ID
-	storyID := uuid.New().String()
-
 	// Upload image and audio to storage in parallel
 	imageUploadChan := make(chan struct {
-		url string
-		err error
+		paths map[string]string
+		err   error
 	}, 1)
 	audioUploadChan := make(chan struct {
-		url string
-		err error
+		fields map[string]interface{}
+		err    error
 	}, 1)
 
 	// Start image upload worker
 	util.GoroutineWithRecovery(func() {
-		url, err := sgh.storageService.UploadFile(imageData, "images", "png")
+		paths, err := sgh.uploadImageRenditions(imageData, imageProcessed)
 		imageUploadChan <- struct {
-			url string
-			err error
-		}{url, err}
+			paths map[string]string
+			err   error
+		}{paths, err}
 	})
 
 	// Start audio upload worker
 	util.GoroutineWithRecovery(func() {
-		url, err := sgh.storageService.UploadFile(audioData, "audio", "wav")
+		fields, err := sgh.uploadNarration(audioData, timing, soundscapeName)
 		audioUploadChan <- struct {
-			url string
-			err error
-		}{url, err}
+			fields map[string]interface{}
+			err    error
+		}{fields, err}
 	})
 
 	// Wait for uploads to complete
-	var imageURL, audioURL string
+	var imagePaths map[string]string
+	var audioFields map[string]interface{}
 	var uploadErr error
 
 	for i := 0; i < 2; i++ {
@@ -296,13 +437,13 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 			if imageResult.err != nil {
 				uploadErr = imageResult.err
 			} else {
-				imageURL = imageResult.url
+				imagePaths = imageResult.paths
 			}
 		case audioResult := <-audioUploadChan:
 			if audioResult.err != nil {
 				uploadErr = audioResult.err
 			} else {
-				audioURL = audioResult.url
+				audioFields = audioResult.fields
 			}
 		case <-ctx.Done():
 			return fmt.Errorf("timeout waiting for file uploads")
@@ -313,6 +454,28 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 		sgh.logger.Errorf("Upload error: %v", uploadErr)
 		return fmt.Errorf("file upload failed: %v", uploadErr)
 	}
+
+	// Collect the extras, saving the story without any that fail or run out of time
+collectExtras:
+	for i := 0; i < extras; i++ {
+		select {
+		case pagesResult := <-pagesResultChan:
+			// A storybook without pages still has the cover image, so keep the story
+			if pagesResult.err != nil {
+				sgh.logger.Errorf("Storybook pages generation error: %v", pagesResult.err)
+			}
+			pages = pagesResult.pages
+		case paragraphsResult := <-paragraphsResultChan:
+			// The story still reads in one language without its pairs, so keep it
+			if paragraphsResult.err != nil {
+				sgh.logger.Errorf("Bilingual paragraphs generation error: %v", paragraphsResult.err)
+			}
+			paragraphs = paragraphsResult.paragraphs
+		case <-extrasCtx.Done():
+			sgh.logger.Warnf("Storybook pages or bilingual paragraphs did not finish within %s, saving the story without them", sgh.settings.OptionalMediaTimeout)
+			break collectExtras
+		}
+	}
 	var storyType string
 	if voice == tts.Chirp3HD.String() && isGemini {
 		storyType = tts.StoryPremium.String()
@@ -322,15 +485,41 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 	// Save to database (non-blocking)
 	util.GoroutineWithRecovery(func() {
 		dbData := map[string]interface{}{
-			"story_id":   storyID,
-			"title":      topic,
-			"story_text": storyResponse.StoryText,
-			"image_url":  imageURL,
-			"audio_url":  audioURL,
-			"audio_type": "wav",
-			"theme":      theme,
-			"story_type": storyType,
-			"language":   kwargs["language"].(string),
+			"story_id":         storyID,
+			"title":            topic,
+			"story_text":       storyResponse.StoryText,
+			"image_url":        imagePaths["original"],
+			"image_thumb_url":  imagePaths["thumb"],
+			"image_medium_url": imagePaths["medium"],
+			"theme":            theme,
+			"story_type":       storyType,
+			"language":         kwargs["language"].(string),
+		}
+		for key, value := range audioFields {
+			dbData[key] = value
+		}
+		addRomanized(dbData, language)
+		if len(pages) > 0 {
+			pageMaps := make([]map[string]interface{}, 0, len(pages))
+			for _, page := range pages {
+				pageMaps = append(pageMaps, page.ToMap())
+			}
+			dbData["pages"] = pageMaps
+		}
+		if len(paragraphs) > 0 {
+			dbData["bilingual_language"] = bilingualLanguage
+			dbData["paragraphs"] = paragraphMaps(paragraphs)
+		}
+		if len(cast) > 0 {
+			dbData["cast"] = cast
+		}
+		if styleSheet != nil {
+			dbData["style_sheet"] = styleSheet.ToMap()
+		}
+		if promptChoice.Template != nil {
+			for key, value := range promptChoice.ToMap() {
+				dbData[key] = value
+			}
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -348,9 +537,220 @@ func (sgh *StoryGenerationHelper) StoryHelper(ctx context.Context, theme, theme_
 	return nil
 }
 
+// addRomanized stores the title and text of a story in Roman script next to the originals,
+// for languages whose pack names a romanization
+func addRomanized(storyData map[string]interface{}, language string) {
+	title, _ := storyData["title"].(string)
+	if romanized, ok := util.Romanize(title, language); ok {
+		storyData["title_romanized"] = romanized
+	}
+	storyText, _ := storyData["story_text"].(string)
+	if romanized, ok := util.Romanize(storyText, language); ok {
+		storyData["story_text_romanized"] = romanized
+	}
+}
+
+// uploadAudio sniffs the format of generated audio, uploads it with the matching extension and
+// returns the audio_url, audio_type, audio_extension and audio_duration fields of a story
+func uploadAudio(storageService *database.StorageService, audioData []byte) (map[string]interface{}, error) {
+	info, err := audiofile.Probe(audioData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generated audio: %v", err)
+	}
+	// Stored content-addressed, so narration served from the media cache points to the cached file
+	url, err := mediacache.Store(storageService, mediacache.KindAudio, "audio."+info.Extension, audioData)
+	if err != nil {
+		return nil, err
+	}
+	fields := info.ToMap()
+	fields["audio_url"] = url
+	return fields, nil
+}
+
+// narrate reads a story the way it was asked for: a voice per character when dialogueMode is set,
+// falling back to one voice, word timing when readAlong is set, and WAV when a soundscape will be
+// mixed under it. It returns the audio, the timing, the cast and the Google voice type used.
+func (sgh *StoryGenerationHelper) narrate(ctx context.Context, storyText, language, theme string, dialogueMode, readAlong bool, soundscapeName string) ([]byte, *readalong.Timing, map[string]string, string, error) {
+	pcm := soundscapeName != ""
+	if dialogueMode {
+		audioData, cast, voice, err := sgh.narrateDialogue(ctx, storyText, language, theme, pcm)
+		if err == nil {
+			return audioData, nil, cast, voice, nil
+		}
+		sgh.logger.Warnf("Dialogue narration failed, narrating in one voice: %v", err)
+	}
+	audioData, timing, voice, err := sgh.narrateStory(ctx, storyText, language, theme, readAlong, pcm)
+	return audioData, timing, nil, voice, err
+}
+
+// uploadNarration uploads the audio of a story with its read-along timing and soundscape mix, and
+// returns the story fields describing them. Only a failed audio upload is an error.
+func (sgh *StoryGenerationHelper) uploadNarration(audioData []byte, timing *readalong.Timing, soundscapeName string) (map[string]interface{}, error) {
+	fields, err := uploadAudio(sgh.storageService, audioData)
+	if err != nil {
+		return nil, err
+	}
+	if timing != nil {
+		// The story still plays without read-along, so a failed timing upload is not fatal
+		timingFields, timingErr := uploadTiming(sgh.storageService, timing)
+		if timingErr != nil {
+			sgh.logger.Warnf("Failed to upload read-along timing: %v", timingErr)
+		}
+		for key, value := range timingFields {
+			fields[key] = value
+		}
+	}
+	if soundscapeName != "" {
+		// The narration is already stored, so a failed mix only leaves the story without background sound
+		soundscapeFields, soundscapeErr := sgh.uploadSoundscape(audioData, soundscapeName)
+		if soundscapeErr != nil {
+			sgh.logger.Warnf("Failed to add the %s soundscape: %v", soundscapeName, soundscapeErr)
+		}
+		for key, value := range soundscapeFields {
+			fields[key] = value
+		}
+	}
+	return fields, nil
+}
+
+// generateStoryAudio narrates a story with Google TTS, or with the fallback generator when the
+// audio budget is used up. It returns the audio and the Google voice type used, if any.
+func (sgh *StoryGenerationHelper) generateStoryAudio(ctx context.Context, storyText, language, theme string) ([]byte, string, error) {
+	audioData, _, voice, err := sgh.narrateStory(ctx, storyText, language, theme, false, false)
+	return audioData, voice, err
+}
+
+// narrateStory is generateStoryAudio that can also time every word for read-along, and narrate to WAV
+// when pcm is set so a soundscape can be mixed under it. The timing is nil unless readAlong is set
+// and the speech provider could time the chosen voice.
+func (sgh *StoryGenerationHelper) narrateStory(ctx context.Context, storyText, language, theme string, readAlong, pcm bool) ([]byte, *readalong.Timing, string, error) {
+	return sgh.narrateStoryIn(ctx, storyText, language, theme, sgh.narrationVoice(ctx), readAlong, pcm)
+}
+
+// narrationVoice is the audio budget decision a narration is read under, with the voice pinned
+// within its voice type when several narrations must sound alike
+type narrationVoice struct {
+	suspended bool
+	err       error
+	// voiceType is the Google voice type of the current budget tier
+	voiceType string
+	// name is a voice picked with pinVoice, or "" for a random voice of voiceType
+	name string
+}
+
+// fallback reports whether a narration in language goes to the fallback generator
+func (v narrationVoice) fallback(language string) bool {
+	return (v.suspended || v.err != nil) && languages.Lookup(language).FallbackAudio()
+}
+
+// narrationVoice reads the audio budget decision
+func (sgh *StoryGenerationHelper) narrationVoice(ctx context.Context) narrationVoice {
+	suspended, voiceType, err := sgh.storyDatabase.SuspendAudioAPI(ctx, "audio")
+	return narrationVoice{suspended: suspended, err: err, voiceType: voiceType}
+}
+
+// pinVoice picks one voice of the decision's voice type for language, so every narration given the
+// result reads in it. The voice stays random when the speech provider cannot pick one up front.
+func (sgh *StoryGenerationHelper) pinVoice(v narrationVoice, language, theme string) narrationVoice {
+	picker, ok := sgh.audioStoryGenerator.(VoicePicker)
+	if !ok || v.fallback(language) {
+		return v
+	}
+	name, err := picker.PickVoice(language, theme, v.voiceType)
+	if err != nil {
+		sgh.logger.Warnf("Failed to pick a %s voice for %s, narrating in random voices: %v", v.voiceType, language, err)
+		return v
+	}
+	v.name = name
+	return v
+}
+
+// narrateStoryIn is narrateStory under a budget decision read beforehand
+func (sgh *StoryGenerationHelper) narrateStoryIn(ctx context.Context, storyText, language, theme string, v narrationVoice, readAlong, pcm bool) ([]byte, *readalong.Timing, string, error) {
+	voice := v.voiceType
+	if v.fallback(language) {
+		if v.err != nil {
+			sgh.logger.Errorf("Failed to read audio api trigger: %v", v.err)
+		} else {
+			sgh.logger.Errorf("Google Audio API trigger is suspended; using fallback audio generator")
+		}
+		key := audioCacheKey("falai", "kokoro/american-english", language, theme, "narration", storyText)
+		if audioData, _, _, ok := sgh.cachedNarration(ctx, key); ok {
+			return audioData, nil, voice, nil
+		}
+		audioData, err := sgh.audioGenerator.GenerateAudio(storyText)
+		if err == nil {
+			sgh.recordCost(ctx, "falai", "kokoro/american-english", model.UnitCharacters, int64(utf8.RuneCountInString(storyText)))
+			sgh.cacheNarration(ctx, key, "falai", audioData, nil, nil)
+		}
+		return audioData, nil, voice, err
+	}
+
+	// A hit skips the provider and the token charge; read-along audio is cached apart, with its timing
+	output := "narration"
+	if readAlong {
+		output = "read-along"
+	}
+	speech := sgh.audioStoryGenerator
+	if pcm && sgh.pcmStoryGenerator != nil {
+		speech = sgh.pcmStoryGenerator
+		output += "+pcm"
+	}
+	// A pinned voice is passed in place of the voice type, and cached apart from random voices of it
+	requestVoice := voice
+	if v.name != "" {
+		requestVoice = v.name
+	}
+	key := audioCacheKey("google-tts", requestVoice, language, theme, output, storyText)
+	if audioData, timing, _, ok := sgh.cachedNarration(ctx, key); ok {
+		return audioData, timing, voice, nil
+	}
+
+	sgh.logger.Infof("Using Google Audio API to generate story audio...")
+	var audioData []byte
+	var timing *readalong.Timing
+	var totalTokens int32
+	var err error
+	if provider, ok := speech.(ReadAlongProvider); ok && readAlong {
+		audioData, timing, totalTokens, err = provider.GenerateReadAlongAdapter(storyText, language, theme, requestVoice)
+	} else {
+		audioData, totalTokens, err = speech.GenerateAudioAdapter(storyText, language, theme, requestVoice)
+	}
+	sgh.storyDatabase.UpdateAPITokens(ctx, "audio", (int64)(totalTokens))
+	if err == nil {
+		sgh.recordCost(ctx, "google-tts", voice, model.UnitCharacters, int64(utf8.RuneCountInString(storyText)))
+		sgh.cacheNarration(ctx, key, "google-tts", audioData, timing, nil)
+	}
+	return audioData, timing, voice, err
+}
+
+// uploadTiming uploads the read-along timing as JSON and as WebVTT captions and returns the
+// timing_url and captions_url fields of a story
+func uploadTiming(storageService *database.StorageService, timing *readalong.Timing) (map[string]interface{}, error) {
+	timingJSON, err := timing.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode timing: %v", err)
+	}
+	timingURL, err := storageService.UploadFile(timingJSON, "timing", "json")
+	if err != nil {
+		return nil, err
+	}
+	captionsURL, err := storageService.UploadFile(timing.WebVTT(), "timing", "vtt")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"timing_url":   timingURL,
+		"captions_url": captionsURL,
+	}, nil
+}
+
 // UploadMetadata handles metadata upload and triggers background processing
 func (sgh *StoryGenerationHelper) UploadMetadata(ctx context.Context, token, username, email string, metadata *MetadataRequest) error {
 	sgh.logger.Infof("Uploading metadata for user: %s", email)
+	if err := metadata.Validate(); err != nil {
+		return err
+	}
 
 	//Check if user profile exists
 	userProfile, err := sgh.storyDatabase.GetUserProfile(ctx, username, email)
@@ -393,10 +793,32 @@ func (sgh *StoryGenerationHelper) IsUserProfileExists(ctx context.Context, usern
 func (sgh *StoryGenerationHelper) runBackgroundTasks(email string, metadata *MetadataRequest) {
 	sgh.logger.Infof("Starting background tasks for user: %s", email)
 
-	ctx := context.Background()
 	// Add panic recovery for the entire background task
 	defer util.RecoverPanic()
 
+	sgh.generateProfileStories(context.Background(), email, metadata)
+
+	// Update user profile status
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := sgh.storyDatabase.UpdateUserProfile(ctx, email, map[string]interface{}{
+		"processing_status": "completed",
+	})
+	if err != nil {
+		sgh.logger.Errorf("Failed to update user profile status: %v", err)
+		// Try to set failed status
+		sgh.storyDatabase.UpdateUserProfile(ctx, email, map[string]interface{}{
+			"processing_status": "failed",
+		})
+	} else {
+		sgh.logger.Infof("Background tasks completed for user: %s", email)
+	}
+}
+
+// generateProfileStories generates the topics and stories of all three themes for a profile,
+// skipping themes that already have enough stories. It returns once every theme is done.
+func (sgh *StoryGenerationHelper) generateProfileStories(ctx context.Context, email string, metadata *MetadataRequest) {
 	var wg sync.WaitGroup
 	wg.Add(3)
 
@@ -408,7 +830,7 @@ func (sgh *StoryGenerationHelper) runBackgroundTasks(email string, metadata *Met
 	// Process theme 1 in a goroutine
 	util.GoroutineWithRecoveryAndHandler(func() {
 		defer wg.Done()
-		if err := sgh.getDynamicPromptingTheme1(ctx, metadata.Country, metadata.City, metadata.Preferences, metadata.Language, semaphore); err != nil {
+		if err := sgh.getDynamicPromptingTheme1(ctx, metadata.Country, metadata.City, metadata.Preferences, metadata.Language, metadata.storyOptions(email), semaphore); err != nil {
 			sgh.logger.Errorf("Theme 1 processing error: %v", err)
 		}
 	}, func(r interface{}) {
@@ -418,7 +840,7 @@ func (sgh *StoryGenerationHelper) runBackgroundTasks(email string, metadata *Met
 	// Process theme 2 in a goroutine
 	util.GoroutineWithRecoveryAndHandler(func() {
 		defer wg.Done()
-		if err := sgh.getDynamicPromptingTheme2(ctx, metadata.Country, metadata.Religions, metadata.Preferences, metadata.Language, semaphore); err != nil {
+		if err := sgh.getDynamicPromptingTheme2(ctx, metadata.Country, metadata.Religions, metadata.Preferences, metadata.Language, metadata.storyOptions(email), semaphore); err != nil {
 			sgh.logger.Errorf("Theme 2 processing error: %v", err)
 		}
 	}, func(r interface{}) {
@@ -428,7 +850,7 @@ func (sgh *StoryGenerationHelper) runBackgroundTasks(email string, metadata *Met
 	// Process theme 3 in a goroutine
 	util.GoroutineWithRecoveryAndHandler(func() {
 		defer wg.Done()
-		if err := sgh.getDynamicPromptingTheme3(ctx, metadata.Preferences, metadata.Language, semaphore); err != nil {
+		if err := sgh.getDynamicPromptingTheme3(ctx, metadata.Preferences, metadata.Language, metadata.storyOptions(email), semaphore); err != nil {
 			sgh.logger.Errorf("Theme 3 processing error: %v", err)
 		}
 	}, func(r interface{}) {
@@ -437,23 +859,6 @@ func (sgh *StoryGenerationHelper) runBackgroundTasks(email string, metadata *Met
 
 	// Wait for all theme processing to complete
 	wg.Wait()
-
-	// Update user profile status
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	err := sgh.storyDatabase.UpdateUserProfile(ctx, email, map[string]interface{}{
-		"processing_status": "completed",
-	})
-	if err != nil {
-		sgh.logger.Errorf("Failed to update user profile status: %v", err)
-		// Try to set failed status
-		sgh.storyDatabase.UpdateUserProfile(ctx, email, map[string]interface{}{
-			"processing_status": "failed",
-		})
-	} else {
-		sgh.logger.Infof("Background tasks completed for user: %s", email)
-	}
 }
 
 func (sgh *StoryGenerationHelper) TopicsGenerator(ctx context.Context, prompt string, language string) ([]string, error) {
@@ -461,7 +866,8 @@ func (sgh *StoryGenerationHelper) TopicsGenerator(ctx context.Context, prompt st
 	// Create topics
 	isSuspended, err := sgh.storyDatabase.SuspendGeminiAPI(ctx, "gemini")
 	var topicsResponse *model.TopicResponse
-	if (err != nil || isSuspended) && language == "English" {
+	huggingFace := languages.AllowsProvider(languages.Lookup(language), languages.ProviderHuggingFace)
+	if (err != nil || isSuspended) && huggingFace {
 		topicsResponse, err = sgh.storyCreator.CreateTopics(prompt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create topics: %v", err)
@@ -476,21 +882,27 @@ func (sgh *StoryGenerationHelper) TopicsGenerator(ctx context.Context, prompt st
 			return nil, fmt.Errorf("failed to create topics: %v", err)
 		}
 		//if topics are not generated, try again with huggingface
-		if len(topicsResponse.Title) == 0 {
+		if len(topicsResponse.Title) == 0 && huggingFace {
 			topicsResponse, err = sgh.storyCreator.CreateTopics(prompt)
 		}
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create topics: %v", err)
 	}
+	if strings.HasPrefix(topicsResponse.Model, "gemini") {
+		sgh.recordCost(ctx, "gemini", topicsResponse.Model, model.UnitTokens, int64(topicsResponse.TotalTokens))
+	} else {
+		sgh.recordCost(ctx, "huggingface", topicsResponse.Model, model.UnitTokens, int64(topicsResponse.TotalTokens))
+	}
 
 	topics := topicsResponse.Title
 	return topics, nil
 }
 
 // getDynamicPromptingTheme1 processes theme 1 with parallel story generation controlled by a semaphore
-func (sgh *StoryGenerationHelper) getDynamicPromptingTheme1(ctx context.Context, country, city string, preferences []string, language string, semaphore chan struct{}) error {
+func (sgh *StoryGenerationHelper) getDynamicPromptingTheme1(ctx context.Context, country, city string, preferences []string, language string, options map[string]interface{}, semaphore chan struct{}) error {
 	sgh.logger.Infof("Starting theme 1 processing for country %s and city %s", country, city)
+	ctx = withCostScope(ctx, costScope{Email: optionString(options, "email"), Theme: "1"})
 	// Check if topics already exist
 	existing, err := sgh.storyDatabase.ReadMDTopics1(ctx, country, city, preferences, language)
 	if err != nil {
@@ -535,6 +947,7 @@ func (sgh *StoryGenerationHelper) getDynamicPromptingTheme1(ctx context.Context,
 					"preferences": preference,
 					"language":    language,
 				}
+				mergeOptions(kwargs, options)
 				err := sgh.StoryHelper(ctx, "1", theme1_id, topic, kwargs)
 				if err != nil {
 					sgh.logger.Errorf("Failed to generate story for topic %s: %v", topic, err)
@@ -611,8 +1024,9 @@ func (sgh *StoryGenerationHelper) listenTheme3(ch <-chan topicWithKey, ctx conte
 }
 
 // getDynamicPromptingTheme2 processes theme 2 with parallel story generation controlled by a semaphore
-func (sgh *StoryGenerationHelper) getDynamicPromptingTheme2(ctx context.Context, country string, religions, preferences []string, language string, semaphore chan struct{}) error {
+func (sgh *StoryGenerationHelper) getDynamicPromptingTheme2(ctx context.Context, country string, religions, preferences []string, language string, options map[string]interface{}, semaphore chan struct{}) error {
 	sgh.logger.Infof("Starting theme 2 processing for country %s and religions %v", country, religions)
+	ctx = withCostScope(ctx, costScope{Email: optionString(options, "email"), Theme: "2"})
 
 	// Check if topics already exist
 	existing, err := sgh.storyDatabase.ReadMDTopics2(ctx, country, religions, preferences, language)
@@ -659,6 +1073,7 @@ func (sgh *StoryGenerationHelper) getDynamicPromptingTheme2(ctx context.Context,
 				"preferences": preferences,
 				"language":    language,
 			}
+			mergeOptions(kwargs, options)
 			for _, topic := range topics {
 				err := sgh.StoryHelper(ctx, "2", theme2_id, topic, kwargs)
 				if err != nil {
@@ -681,8 +1096,9 @@ func (sgh *StoryGenerationHelper) getDynamicPromptingTheme2(ctx context.Context,
 }
 
 // getDynamicPromptingTheme3 processes theme 3 with parallel story generation controlled by a semaphore
-func (sgh *StoryGenerationHelper) getDynamicPromptingTheme3(ctx context.Context, preferences []string, language string, semaphore chan struct{}) error {
+func (sgh *StoryGenerationHelper) getDynamicPromptingTheme3(ctx context.Context, preferences []string, language string, options map[string]interface{}, semaphore chan struct{}) error {
 	sgh.logger.Infof("Starting theme 3 processing for preferences %v", preferences)
+	ctx = withCostScope(ctx, costScope{Email: optionString(options, "email"), Theme: "3"})
 
 	// Check if topics already exist
 	existing, err := sgh.storyDatabase.ReadMDTopics3(ctx, preferences, language)
@@ -725,6 +1141,7 @@ func (sgh *StoryGenerationHelper) getDynamicPromptingTheme3(ctx context.Context,
 				"preferences": preference,
 				"language":    language,
 			}
+			mergeOptions(kwargs, options)
 			sgh.logger.Infof("kwargs.. %v", kwargs)
 			for _, topic := range topics {
 				err := sgh.StoryHelper(ctx, "3", theme3_id, topic, kwargs)
@@ -745,3 +1162,16 @@ func (sgh *StoryGenerationHelper) getDynamicPromptingTheme3(ctx context.Context,
 	sgh.logger.Infof("Completed theme 3 processing")
 	return nil
 }
+
+// optionString reads a string option, returning "" when it is missing
+func optionString(options map[string]interface{}, key string) string {
+	value, _ := options[key].(string)
+	return value
+}
+
+// mergeOptions copies the request options into the story kwargs
+func mergeOptions(kwargs, options map[string]interface{}) {
+	for key, value := range options {
+		kwargs[key] = value
+	}
+}
@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/model"
+)
+
+var (
+	// ErrStoryNotFound is returned when translating a story that does not exist
+	ErrStoryNotFound = errors.New("story not found")
+	// ErrSameLanguage is returned when a story is already written in the language asked for
+	ErrSameLanguage = errors.New("story is already in this language")
+)
+
+// TranslateStory returns the translation of a story into target, creating it on first request.
+// The title and text are translated, the text is narrated in the target language and the
+// illustrations of the original story are reused. created is false when the translation was cached.
+func (sgh *StoryGenerationHelper) TranslateStory(ctx context.Context, email, storyID, target string) (translation map[string]interface{}, created bool, err error) {
+	if err := languages.Validate(target); err != nil {
+		return nil, false, err
+	}
+	cached, err := sgh.storyDatabase.GetStoryTranslation(ctx, storyID, target)
+	if err != nil {
+		return nil, false, err
+	}
+	if cached != nil {
+		sgh.logger.Infof("Serving cached %s translation of story %s", target, storyID)
+		return cached, false, nil
+	}
+
+	story, source, err := sgh.TranslationSource(ctx, storyID, target)
+	if err != nil {
+		return nil, false, err
+	}
+	title, _ := story["title"].(string)
+	storyText, _ := story["story_text"].(string)
+	theme, _ := story["theme"].(string)
+	ctx = withCostScope(ctx, costScope{Email: email, Theme: theme, StoryID: storyID})
+
+	sourceCode, targetCode := languages.Lookup(source).Code(), languages.Lookup(target).Code()
+	sgh.logger.Infof("Translating story %s from %s to %s", storyID, source, target)
+	translated, err := sgh.translator.Translate(ctx, []string{title, storyText}, sourceCode, targetCode)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to translate story: %v", err)
+	}
+	translatedTitle, translatedText := translated[0], translated[1]
+	sgh.recordCost(ctx, "google-translate", "nmt", model.UnitCharacters, int64(utf8.RuneCountInString(title)+utf8.RuneCountInString(storyText)))
+
+	audioData, _, err := sgh.generateStoryAudio(ctx, translatedText, target, theme)
+	if err != nil {
+		return nil, false, fmt.Errorf("audio generation failed: %v", err)
+	}
+	audioFields, err := uploadAudio(sgh.storageService, audioData)
+	if err != nil {
+		return nil, false, fmt.Errorf("file upload failed: %v", err)
+	}
+
+	translationData := map[string]interface{}{
+		"title":           translatedTitle,
+		"story_text":      translatedText,
+		"source_language": source,
+		"theme":           theme,
+	}
+	for key, value := range audioFields {
+		translationData[key] = value
+	}
+	addRomanized(translationData, target)
+	// The translation shares the illustrations of its story
+	for _, key := range []string{"image_url", "image_thumb_url", "image_medium_url", "story_type", "theme_id"} {
+		if value, ok := story[key]; ok {
+			translationData[key] = value
+		}
+	}
+	stored, created, err := sgh.storyDatabase.CreateStoryTranslation(ctx, storyID, target, translationData)
+	if err != nil {
+		return nil, false, err
+	}
+	// When another request translated the story first, this audio is stored content-addressed like
+	// the other's, and the orphan cleanup job deletes it if nothing points to it
+	sgh.logger.Infof("Stored %s translation of story %s", target, storyID)
+	return stored, created, nil
+}
+
+// TranslationSource reads the story to translate into target and its language. It returns
+// ErrStoryNotFound when the story does not exist and ErrSameLanguage when it is already in target.
+func (sgh *StoryGenerationHelper) TranslationSource(ctx context.Context, storyID, target string) (story map[string]interface{}, source string, err error) {
+	story, err = sgh.storyDatabase.GetStoryV2(ctx, storyID)
+	if err != nil {
+		return nil, "", err
+	}
+	if story == nil {
+		return nil, "", ErrStoryNotFound
+	}
+	source, _ = story["language"].(string)
+	if source == "" {
+		source = languages.Default
+	}
+	if source == target {
+		return nil, "", ErrSameLanguage
+	}
+	return story, source, nil
+}
+
+// translateForImages translates texts to English when the language pack draws from English prompts.
+// Image prompts still work in the original language, so texts are returned unchanged if translation fails.
+func (sgh *StoryGenerationHelper) translateForImages(ctx context.Context, texts []string, language string) []string {
+	pack := languages.Lookup(language)
+	if !pack.TranslateForImages() {
+		return texts
+	}
+	english := languages.Lookup(languages.Default).Code()
+	translated, err := sgh.translator.Translate(ctx, texts, pack.Code(), english)
+	if err != nil {
+		sgh.logger.Errorf("Failed to translate image prompts from %s: %v", language, err)
+		return texts
+	}
+	return translated
+}
@@ -0,0 +1,177 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/util"
+)
+
+// sceneSentenceRe matches a sentence including its terminator and trailing quote
+var sceneSentenceRe = regexp.MustCompile(`[^.!?।]+[.!?।]+["'”’]?\s*|[^.!?।]+$`)
+
+// textSpan is a byte range of the story text
+type textSpan struct {
+	start int
+	end   int
+	words int
+}
+
+// SegmentScenes splits the story text into at most maxScenes contiguous scenes.
+// Paragraphs are kept whole where possible; single-paragraph stories are split on sentences.
+// Each page records the byte offsets of its span within storyText.
+func SegmentScenes(storyText string, maxScenes int) []model.StoryPage {
+	if maxScenes <= 0 || strings.TrimSpace(storyText) == "" {
+		return nil
+	}
+
+	units := paragraphSpans(storyText)
+	if len(units) < maxScenes {
+		units = sentenceSpans(storyText)
+	}
+	if len(units) == 0 {
+		return nil
+	}
+
+	sceneCount := min(maxScenes, len(units))
+	totalWords := 0
+	for _, u := range units {
+		totalWords += u.words
+	}
+
+	// Greedily close a scene once its cumulative word count reaches the next share of the story
+	var pages []model.StoryPage
+	start := units[0].start
+	cumulative := 0
+	for i, u := range units {
+		cumulative += u.words
+		remainingUnits := len(units) - i - 1
+		remainingScenes := sceneCount - len(pages) - 1
+		target := totalWords * (len(pages) + 1) / sceneCount
+		if i == len(units)-1 || (remainingScenes > 0 && (cumulative >= target || remainingUnits == remainingScenes)) {
+			pages = append(pages, model.StoryPage{
+				Index: len(pages),
+				Text:  strings.TrimSpace(storyText[start:u.end]),
+				Start: start,
+				End:   u.end,
+			})
+			if i < len(units)-1 {
+				start = units[i+1].start
+			}
+		}
+	}
+	return pages
+}
+
+// paragraphSpans returns the non-empty lines of the text as spans
+func paragraphSpans(text string) []textSpan {
+	var spans []textSpan
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" {
+			start := offset + strings.Index(line, trimmed)
+			spans = append(spans, textSpan{start: start, end: start + len(trimmed), words: len(strings.Fields(trimmed))})
+		}
+		offset += len(line)
+	}
+	return spans
+}
+
+// sentenceSpans returns the sentences of the text as spans
+func sentenceSpans(text string) []textSpan {
+	var spans []textSpan
+	for _, loc := range sceneSentenceRe.FindAllStringIndex(text, -1) {
+		sentence := text[loc[0]:loc[1]]
+		trimmed := strings.TrimSpace(sentence)
+		if trimmed == "" {
+			continue
+		}
+		start := loc[0] + strings.Index(sentence, trimmed)
+		spans = append(spans, textSpan{start: start, end: start + len(trimmed), words: len(strings.Fields(trimmed))})
+	}
+	return spans
+}
+
+// buildScenePrompt turns a scene of the story into an image prompt
+func buildScenePrompt(topic, sceneText string) string {
+	scene := []rune(strings.Join(strings.Fields(sceneText), " "))
+	if len(scene) > 400 {
+		scene = append(scene[:400], '…')
+	}
+	return fmt.Sprintf("illustration for a children's storybook about %s, showing this scene: %s", topic, string(scene))
+}
+
+// generateStorybookPages segments the story into scenes and illustrates each one.
+// Images are generated with at most StorybookImageWorkers in flight and uploaded as they finish.
+// A scene whose image fails keeps an empty image_url; the call only fails if every scene fails.
+func (sgh *StoryGenerationHelper) generateStorybookPages(ctx context.Context, topic, storyText, language string, sheet *model.StyleSheet) ([]model.StoryPage, error) {
+	pages := SegmentScenes(storyText, sgh.settings.StorybookMaxScenes)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("story text could not be segmented into scenes")
+	}
+	sgh.logger.Infof("Generating storybook with %d scenes for topic: %s", len(pages), topic)
+
+	// One batch translates the topic and every scene
+	texts := []string{topic}
+	for _, page := range pages {
+		texts = append(texts, page.Text)
+	}
+	texts = sgh.translateForImages(ctx, texts, language)
+	imageTopic, sceneTexts := texts[0], texts[1:]
+
+	workers := max(sgh.settings.StorybookImageWorkers, 1)
+	semaphore := make(chan struct{}, workers)
+	errs := make([]error, len(pages))
+	var wg sync.WaitGroup
+
+	for i := range pages {
+		wg.Add(1)
+		util.GoroutineWithRecovery(func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			// Pages are checked and stored in renditions like the cover
+			imageData, result, err := sgh.generateCheckedImage(ctx, buildScenePrompt(imageTopic, sceneTexts[i]), sheet)
+			if err != nil {
+				errs[i] = err
+				sgh.logger.Errorf("Failed to generate image for scene %d: %v", i, err)
+				return
+			}
+			paths, err := sgh.uploadImageRenditions(imageData, result)
+			if err != nil {
+				errs[i] = err
+				sgh.logger.Errorf("Failed to upload image for scene %d: %v", i, err)
+				return
+			}
+			pages[i].ImageURL = paths["original"]
+			pages[i].ImageThumbURL = paths["thumb"]
+			pages[i].ImageMediumURL = paths["medium"]
+		})
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(pages) {
+		return nil, fmt.Errorf("all %d storybook images failed: %v", len(pages), errs[0])
+	}
+	if failed > 0 {
+		sgh.logger.Warnf("Storybook generated with %d of %d scene images missing", failed, len(pages))
+	}
+	return pages, nil
+}
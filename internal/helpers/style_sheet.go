@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"rio-go-model/internal/model"
+)
+
+// themeArtStyles is the default art style and palette of each theme's series
+var themeArtStyles = map[string]struct {
+	artStyle string
+	palette  []string
+}{
+	"1": {"soft watercolor nature illustration with rounded shapes", []string{"leaf green", "sky blue", "sunny yellow", "earth brown"}},
+	"2": {"warm gouache storybook painting with gentle light", []string{"saffron", "warm gold", "soft rose", "deep teal"}},
+	"3": {"dreamy pastel crayon illustration", []string{"lavender", "mint", "peach", "cream"}},
+}
+
+// characterSheetPrompt asks the model for the main characters of a story
+const characterSheetPrompt = `List the main characters of the children's story below, at most 4.
+Write each on its own line as "Name: appearance", where appearance is a short English visual description
+(species or age, hair or fur, clothing, colors) that an illustrator can repeat exactly in every picture.
+Write only the lines, nothing else.
+
+Story:
+%s`
+
+// seriesSeed derives a stable, positive 31-bit image seed for a series
+func seriesSeed(seriesID string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(seriesID))
+	return int64(h.Sum32()&0x7fffffff) + 1
+}
+
+// getSeriesStyleSheet loads the style sheet of a series, creating it on first use
+func (sgh *StoryGenerationHelper) getSeriesStyleSheet(ctx context.Context, seriesID, theme string) (*model.StyleSheet, error) {
+	stored, err := sgh.storyDatabase.GetStyleSheet(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		style, ok := themeArtStyles[theme]
+		if !ok {
+			style = themeArtStyles["3"]
+		}
+		sheet := &model.StyleSheet{
+			SeriesID: seriesID,
+			ArtStyle: style.artStyle,
+			Palette:  style.palette,
+			Seed:     seriesSeed(seriesID),
+		}
+		stored, err = sgh.storyDatabase.CreateStyleSheet(ctx, seriesID, sheet.ToMap())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return (&model.StyleSheet{}).FromMap(stored), nil
+}
+
+// parseCharacterSheets reads "Name: appearance" lines
+func parseCharacterSheets(text string) []model.CharacterSheet {
+	var characters []model.CharacterSheet
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*0123456789.) "))
+		name, appearance, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.Trim(strings.TrimSpace(name), "*\"")
+		appearance = strings.TrimSpace(appearance)
+		if name == "" || appearance == "" {
+			continue
+		}
+		characters = append(characters, model.CharacterSheet{Name: name, Appearance: appearance})
+		if len(characters) == 4 {
+			break
+		}
+	}
+	return characters
+}
+
+// BuildStyleSheet returns the style sheet used for every illustration of a story.
+// The art style, palette and seed are shared by the series (theme_id); the characters come from the story text.
+// Character extraction is best effort: without it the sheet still fixes style, palette and seed.
+func (sgh *StoryGenerationHelper) BuildStyleSheet(ctx context.Context, seriesID, theme, storyText string) (*model.StyleSheet, error) {
+	sheet, err := sgh.getSeriesStyleSheet(ctx, seriesID, theme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series style sheet: %v", err)
+	}
+
+	if isSuspended, err := sgh.storyDatabase.SuspendGeminiAPI(ctx, "gemini"); err != nil || isSuspended {
+		sgh.logger.Infof("Skipping character sheet for series %s, gemini api unavailable", seriesID)
+		return sheet, nil
+	}
+	response, err := sgh.geminiStoryGenerator.GenerateText(fmt.Sprintf(characterSheetPrompt, storyText), "gemini-2.0-flash-lite")
+	if err != nil {
+		sgh.logger.Errorf("Failed to generate character sheet: %v", err)
+		return sheet, nil
+	}
+	sgh.storyDatabase.UpdateAPITokens(ctx, "gemini", (int64)(response.TotalTokens))
+	sgh.recordCost(ctx, "gemini", response.Model, model.UnitTokens, int64(response.TotalTokens))
+	sheet.Characters = parseCharacterSheets(response.Story)
+	sgh.logger.Infof("Built style sheet for series %s with %d characters", seriesID, len(sheet.Characters))
+	return sheet, nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Units in which provider calls are billed
+const (
+	UnitTokens     = "tokens"
+	UnitCharacters = "characters"
+	UnitImages     = "images"
+)
+
+// CostEntry is one provider call recorded in the cost ledger
+type CostEntry struct {
+	StoryID       string    `json:"story_id"`
+	Email         string    `json:"email"`
+	Theme         string    `json:"theme"`
+	Provider      string    `json:"provider"`
+	Model         string    `json:"model"`
+	UnitType      string    `json:"unit_type"`
+	Units         int64     `json:"units"`
+	EstimatedCost float64   `json:"estimated_cost"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ToMap converts the entry into the shape stored in the cost ledger collection
+func (c *CostEntry) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"story_id":       c.StoryID,
+		"email":          c.Email,
+		"theme":          c.Theme,
+		"provider":       c.Provider,
+		"model":          c.Model,
+		"unit_type":      c.UnitType,
+		"units":          c.Units,
+		"estimated_cost": c.EstimatedCost,
+		"created_at":     c.CreatedAt,
+	}
+}
+
+// CostReportRow aggregates ledger entries sharing one group key
+type CostReportRow struct {
+	Key           string           `json:"key"`
+	Calls         int64            `json:"calls"`
+	Units         map[string]int64 `json:"units"`
+	EstimatedCost float64          `json:"estimated_cost"`
+}
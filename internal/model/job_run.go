@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// Outcomes of a scheduled job run
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobTimedOut  = "timed_out"
+)
+
+// JobRun is the last run of a scheduled job, shared by all instances
+type JobRun struct {
+	Job        string    `json:"job"`
+	Status     string    `json:"status"`
+	Instance   string    `json:"instance"`
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Summary    string    `json:"summary,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ToMap converts the run into the shape stored in the scheduler runs collection
+func (j *JobRun) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"job":         j.Job,
+		"status":      j.Status,
+		"instance":    j.Instance,
+		"trigger":     j.Trigger,
+		"started_at":  j.StartedAt,
+		"finished_at": j.FinishedAt,
+		"duration_ms": j.DurationMs,
+		"summary":     j.Summary,
+		"error":       j.Error,
+	}
+}
+
+// FromMap fills the run from a stored document
+func (j *JobRun) FromMap(data map[string]interface{}) {
+	j.Job, _ = data["job"].(string)
+	j.Status, _ = data["status"].(string)
+	j.Instance, _ = data["instance"].(string)
+	j.Trigger, _ = data["trigger"].(string)
+	j.StartedAt, _ = data["started_at"].(time.Time)
+	j.FinishedAt, _ = data["finished_at"].(time.Time)
+	j.DurationMs, _ = data["duration_ms"].(int64)
+	j.Summary, _ = data["summary"].(string)
+	j.Error, _ = data["error"].(string)
+}
+
+// ProfileKey is a combination of profile settings that stories are generated for
+type ProfileKey struct {
+	Country     string   `json:"country"`
+	City        string   `json:"city"`
+	Religions   []string `json:"religions"`
+	Preferences []string `json:"preferences"`
+	Language    string   `json:"language"`
+	Users       int      `json:"users"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"rio-go-model/configs"
+)
+
+// Quota kinds counted per user. A story request is one POST /story, whatever number of stories it generates.
+const (
+	QuotaStoryRequests = "story_requests"
+	QuotaRegenerations = "regenerations"
+)
+
+// QuotaUsage is a user's current usage together with the limits that apply to them
+type QuotaUsage struct {
+	Email              string             `json:"email"`
+	Plan               string             `json:"plan"`
+	Day                string             `json:"day"`
+	DayStoryRequests   int                `json:"day_story_requests"`
+	Month              string             `json:"month"`
+	MonthStoryRequests int                `json:"month_story_requests"`
+	DayRegenerations   int                `json:"day_regenerations"`
+	Limits             configs.PlanLimits `json:"limits"`
+	Overridden         bool               `json:"overridden"`
+}
+
+// QuotaExceededError is returned when a user has used up one of their limits
+type QuotaExceededError struct {
+	Limit   string    `json:"limit"`
+	Max     int       `json:"max"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s limit of %d reached, resets at %s", e.Limit, e.Max, e.ResetAt.Format(time.RFC3339))
+}
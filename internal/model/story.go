@@ -4,6 +4,7 @@ package model
 type StoryResponse struct {
 	Story       string `json:"story,omitempty"`
 	TotalTokens int32  `json:"total_tokens,omitempty"`
+	Model       string `json:"model,omitempty"`
 	Error       string `json:"error,omitempty"`
 }
 
@@ -15,6 +16,7 @@ type PromptEngineConfig struct {
 type TopicResponse struct {
 	Title       []string `json:"title,omitempty"`
 	TotalTokens int32    `json:"total_tokens,omitempty"`
+	Model       string   `json:"model,omitempty"`
 	Error       string   `json:"error,omitempty"`
 }
 
@@ -22,3 +24,60 @@ type ImageResponse struct {
 	Image []byte `json:"image,omitempty"`
 	Error string `json:"error,omitempty"`
 }
+
+// StoryPage is one illustrated scene of a storybook-mode story
+type StoryPage struct {
+	Index    int    `json:"index"`
+	Text     string `json:"text"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	ImageURL string `json:"image_url"`
+	// ImageThumbURL and ImageMediumURL are the smaller renditions of the page image
+	ImageThumbURL  string `json:"image_thumb_url,omitempty"`
+	ImageMediumURL string `json:"image_medium_url,omitempty"`
+}
+
+// ToMap converts the page into the shape stored on the story document
+func (p *StoryPage) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"index":            p.Index,
+		"text":             p.Text,
+		"start":            p.Start,
+		"end":              p.End,
+		"image_url":        p.ImageURL,
+		"image_thumb_url":  p.ImageThumbURL,
+		"image_medium_url": p.ImageMediumURL,
+	}
+}
+
+// ParagraphSegment is one language of a bilingual paragraph with its own narration
+type ParagraphSegment struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+	AudioURL string `json:"audio_url"`
+}
+
+// ToMap converts the segment into the shape stored on the story document
+func (s *ParagraphSegment) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"language":  s.Language,
+		"text":      s.Text,
+		"audio_url": s.AudioURL,
+	}
+}
+
+// StoryParagraph is one paragraph of a bilingual story aligned with its translation
+type StoryParagraph struct {
+	Index       int              `json:"index"`
+	Original    ParagraphSegment `json:"original"`
+	Translation ParagraphSegment `json:"translation"`
+}
+
+// ToMap converts the paragraph into the shape stored on the story document
+func (p *StoryParagraph) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"index":       p.Index,
+		"original":    p.Original.ToMap(),
+		"translation": p.Translation.ToMap(),
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"rio-go-model/internal/util"
+)
+
+// CharacterSheet describes how one character must look in every illustration
+type CharacterSheet struct {
+	Name       string `json:"name"`
+	Appearance string `json:"appearance"`
+}
+
+// StyleSheet keeps the illustrations of a story or series visually consistent
+type StyleSheet struct {
+	SeriesID   string           `json:"series_id"`
+	ArtStyle   string           `json:"art_style"`
+	Palette    []string         `json:"palette"`
+	Characters []CharacterSheet `json:"characters,omitempty"`
+	Seed       int64            `json:"seed"`
+}
+
+// ToMap converts the sheet into the shape stored on Firestore documents
+func (s *StyleSheet) ToMap() map[string]interface{} {
+	characters := make([]map[string]interface{}, 0, len(s.Characters))
+	for _, c := range s.Characters {
+		characters = append(characters, map[string]interface{}{
+			"name":       c.Name,
+			"appearance": c.Appearance,
+		})
+	}
+	return map[string]interface{}{
+		"series_id":  s.SeriesID,
+		"art_style":  s.ArtStyle,
+		"palette":    s.Palette,
+		"characters": characters,
+		"seed":       s.Seed,
+	}
+}
+
+// FromMap reads a sheet stored by ToMap
+func (s *StyleSheet) FromMap(m map[string]interface{}) *StyleSheet {
+	sheet := &StyleSheet{
+		Palette: util.SafeStringSlice(m["palette"]),
+	}
+	if v, ok := m["series_id"].(string); ok {
+		sheet.SeriesID = v
+	}
+	if v, ok := m["art_style"].(string); ok {
+		sheet.ArtStyle = v
+	}
+	if v, ok := m["seed"].(int64); ok {
+		sheet.Seed = v
+	}
+	if characters, ok := m["characters"].([]interface{}); ok {
+		for _, c := range characters {
+			cm, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := cm["name"].(string)
+			appearance, _ := cm["appearance"].(string)
+			sheet.Characters = append(sheet.Characters, CharacterSheet{Name: name, Appearance: appearance})
+		}
+	}
+	return sheet
+}
+
+// PromptDirective renders the sheet as text appended to image prompts
+func (s *StyleSheet) PromptDirective() string {
+	var parts []string
+	if s.ArtStyle != "" {
+		parts = append(parts, fmt.Sprintf("art style: %s", s.ArtStyle))
+	}
+	if len(s.Palette) > 0 {
+		parts = append(parts, fmt.Sprintf("color palette: %s", strings.Join(s.Palette, ", ")))
+	}
+	if len(s.Characters) > 0 {
+		characters := make([]string, 0, len(s.Characters))
+		for _, c := range s.Characters {
+			characters = append(characters, fmt.Sprintf("%s (%s)", c.Name, c.Appearance))
+		}
+		parts = append(parts, fmt.Sprintf("characters always drawn as: %s", strings.Join(characters, "; ")))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"rio-go-model/configs"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// budgetPolicyDoc is the document holding the policy set by an admin
+const budgetPolicyDoc = "active"
+
+// budgetPolicyTTL is how long a policy read from Firestore is reused
+const budgetPolicyTTL = time.Minute
+
+// GetBudgetPolicy returns the policy stored in Firestore, or the configured one when none is stored.
+// Reads are cached for a minute; a failed read falls back to the configured policy.
+func (s *StoryDatabase) GetBudgetPolicy(ctx context.Context) *configs.BudgetPolicy {
+	s.budgetPolicyMu.Lock()
+	defer s.budgetPolicyMu.Unlock()
+	if s.budgetPolicyCache != nil && time.Since(s.budgetPolicyCachedAt) < budgetPolicyTTL {
+		return s.budgetPolicyCache
+	}
+
+	policy := configs.GetSettings().BudgetPolicy
+	stored, err := s.getStoredBudgetPolicy(ctx)
+	if err != nil {
+		log.Printf("Warning: using configured budget policy: %v", err)
+	} else if stored != nil {
+		policy = stored
+	}
+	s.budgetPolicyCache = policy
+	s.budgetPolicyCachedAt = time.Now()
+	return policy
+}
+
+// SetBudgetPolicy stores a policy that overrides the configured one
+func (s *StoryDatabase) SetBudgetPolicy(ctx context.Context, policy *configs.BudgetPolicy, updatedBy string) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("error encoding budget policy: %v", err)
+	}
+	_, err = s.client.Collection(s.budgetPolicies).Doc(budgetPolicyDoc).Set(ctx, map[string]interface{}{
+		"policy":     string(raw),
+		"updated_by": updatedBy,
+		"updated_at": getUTCTimestamp(),
+	})
+	if err != nil {
+		return fmt.Errorf("error setting budget policy: %v", err)
+	}
+	s.resetBudgetPolicyCache()
+	return nil
+}
+
+// DeleteBudgetPolicy removes the stored policy so the configured one applies again
+func (s *StoryDatabase) DeleteBudgetPolicy(ctx context.Context) error {
+	_, err := s.client.Collection(s.budgetPolicies).Doc(budgetPolicyDoc).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting budget policy: %v", err)
+	}
+	s.resetBudgetPolicyCache()
+	return nil
+}
+
+// GetBudgetUsage reads the spend recorded for a provider from its api_trigger document
+func (s *StoryDatabase) GetBudgetUsage(ctx context.Context, api_model string) (*configs.BudgetUsage, error) {
+	doc, err := s.client.Collection(s.apiTrigger).Doc(api_model).Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading api model: %v", err)
+	}
+	data := doc.Data()
+	usage := &configs.BudgetUsage{
+		CostAmount:   floatField(data, "costAmount"),
+		BudgetAmount: floatField(data, "budgetAmount"),
+		Threshold:    floatField(data, "threshold"),
+	}
+	usage.ResetAt, _ = data["reset_at"].(time.Time)
+	return usage, nil
+}
+
+// EvaluateBudget returns the budget tier a provider is currently in
+func (s *StoryDatabase) EvaluateBudget(ctx context.Context, api_model string) (configs.BudgetDecision, error) {
+	usage, err := s.GetBudgetUsage(ctx, api_model)
+	if err != nil {
+		return configs.BudgetDecision{}, err
+	}
+	return s.GetBudgetPolicy(ctx).Evaluate(api_model, *usage, getUTCTimestamp()), nil
+}
+
+// nextResetTime returns when the usage recorded now should be reset
+func (s *StoryDatabase) nextResetTime(ctx context.Context) time.Time {
+	return s.GetBudgetPolicy(ctx).NextReset(getUTCTimestamp())
+}
+
+// getStoredBudgetPolicy returns the policy stored in Firestore, or nil when there is none
+func (s *StoryDatabase) getStoredBudgetPolicy(ctx context.Context) (*configs.BudgetPolicy, error) {
+	if s.client == nil {
+		return nil, nil
+	}
+	doc, err := s.client.Collection(s.budgetPolicies).Doc(budgetPolicyDoc).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading budget policy: %v", err)
+	}
+	raw, _ := doc.Data()["policy"].(string)
+	return configs.ParseBudgetPolicy([]byte(raw))
+}
+
+func (s *StoryDatabase) resetBudgetPolicyCache() {
+	s.budgetPolicyMu.Lock()
+	s.budgetPolicyCache = nil
+	s.budgetPolicyMu.Unlock()
+}
+
+// floatField reads a number Firestore may have stored as either float64 or int64
+func floatField(data map[string]interface{}, key string) float64 {
+	switch value := data[key].(type) {
+	case float64:
+		return value
+	case int64:
+		return float64(value)
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"rio-go-model/internal/model"
+
+	"google.golang.org/api/iterator"
+)
+
+// CreateCostEntry appends one provider call to the cost ledger
+func (s *StoryDatabase) CreateCostEntry(ctx context.Context, entry *model.CostEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = getUTCTimestamp()
+	}
+	_, _, err := s.client.Collection(s.costLedger).Add(ctx, entry.ToMap())
+	if err != nil {
+		return fmt.Errorf("error creating cost entry: %v", err)
+	}
+	return nil
+}
+
+// CostReport aggregates ledger entries created in [from, to) by "user", "theme", "day" or "provider"
+func (s *StoryDatabase) CostReport(ctx context.Context, groupBy string, from, to time.Time) ([]model.CostReportRow, error) {
+	keyOf, ok := costReportKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cost report grouping: %s", groupBy)
+	}
+
+	iter := s.client.Collection(s.costLedger).
+		Where("created_at", ">=", from).
+		Where("created_at", "<", to).
+		Documents(ctx)
+	defer iter.Stop()
+
+	rows := make(map[string]*model.CostReportRow)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading cost ledger: %v", err)
+		}
+		data := doc.Data()
+		key := keyOf(data)
+		row, exists := rows[key]
+		if !exists {
+			row = &model.CostReportRow{Key: key, Units: make(map[string]int64)}
+			rows[key] = row
+		}
+		row.Calls++
+		unitType, _ := data["unit_type"].(string)
+		units, _ := data["units"].(int64)
+		row.Units[unitType] += units
+		cost, _ := data["estimated_cost"].(float64)
+		row.EstimatedCost += cost
+	}
+
+	report := make([]model.CostReportRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Key < report[j].Key })
+	return report, nil
+}
+
+// costReportKeys maps a report grouping to the ledger field it groups on
+var costReportKeys = map[string]func(map[string]interface{}) string{
+	"user":     func(d map[string]interface{}) string { return stringField(d, "email") },
+	"theme":    func(d map[string]interface{}) string { return stringField(d, "theme") },
+	"provider": func(d map[string]interface{}) string { return stringField(d, "provider") },
+	"day": func(d map[string]interface{}) string {
+		createdAt, _ := d["created_at"].(time.Time)
+		return createdAt.UTC().Format("2006-01-02")
+	},
+}
+
+// stringField reads a string field, returning "unknown" when it is missing or empty
+func stringField(data map[string]interface{}, key string) string {
+	if value, ok := data[key].(string); ok && value != "" {
+		return value
+	}
+	return "unknown"
+}
@@ -8,17 +8,18 @@ import (
 
 	// "path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"rio-go-model/configs"
 	"rio-go-model/internal/model"
 	"rio-go-model/internal/util"
 
-	"rio-go-model/internal/services/tts"
-
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
-	// "rio-go-model/configs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // getUTCTimestamp returns the current time in UTC
@@ -26,20 +27,6 @@ func getUTCTimestamp() time.Time {
 	return time.Now().UTC()
 }
 
-// getNextMonthResetTime returns the 2nd day of the next month at 00:00:00 UTC
-func getNextMonthResetTime() time.Time {
-	now := time.Now().UTC()
-
-	// Get the first day of next month
-	nextMonth := now.AddDate(0, 1, 0) // Add 1 month
-	firstDayOfNextMonth := time.Date(nextMonth.Year(), nextMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
-
-	// Add 1 day to get the 2nd day of next month
-	secondDayOfNextMonth := firstDayOfNextMonth.AddDate(0, 0, 1)
-
-	return secondDayOfNextMonth
-}
-
 type APITriggerOptions struct {
 	DisplayName  string  `json:"budgetDisplayName"`
 	Threshold    float64 `json:"alertThresholdExceeded"`
@@ -53,16 +40,34 @@ type APITriggerOptions struct {
 
 // StoryDatabase represents a Firestore database service for stories
 type StoryDatabase struct {
-	client        *firestore.Client
-	CollectionV2  string
-	MdCollection1 string
-	MdCollection2 string
-	MdCollection3 string
-	userProfiles  string
-	tcDocuments   string
-	storyFeedback string
-	apiTrigger    string
-	appHelper     *AppHelper
+	client         *firestore.Client
+	CollectionV2   string
+	MdCollection1  string
+	MdCollection2  string
+	MdCollection3  string
+	userProfiles   string
+	tcDocuments    string
+	storyFeedback  string
+	apiTrigger     string
+	styleSheets    string
+	costLedger     string
+	usageCounters  string
+	quotaOverrides string
+	budgetPolicies string
+	jobLeases      string
+	jobRuns        string
+	promptRegistry string
+	translations   string
+	mediaCache     string
+	appHelper      *AppHelper
+
+	budgetPolicyMu       sync.Mutex
+	budgetPolicyCache    *configs.BudgetPolicy
+	budgetPolicyCachedAt time.Time
+
+	promptRegistryMu       sync.Mutex
+	promptRegistryCache    *configs.PromptRegistry
+	promptRegistryCachedAt time.Time
 	// configs           *configs.ServiceAccount
 }
 
@@ -74,15 +79,25 @@ type AppHelper struct {
 // NewStoryDatabase creates a new story database service
 func NewStoryDatabase() *StoryDatabase {
 	return &StoryDatabase{
-		CollectionV2:  "riostories_v2",
-		MdCollection1: "riostories_topics_metadata_1",
-		MdCollection2: "riostories_topics_metadata_2",
-		MdCollection3: "riostories_topics_metadata_3",
-		userProfiles:  "user_profiles",
-		tcDocuments:   "tc_documents",
-		storyFeedback: "story_feedback",
-		apiTrigger:    "api_trigger",
-		appHelper:     &AppHelper{},
+		CollectionV2:   "riostories_v2",
+		MdCollection1:  "riostories_topics_metadata_1",
+		MdCollection2:  "riostories_topics_metadata_2",
+		MdCollection3:  "riostories_topics_metadata_3",
+		userProfiles:   "user_profiles",
+		tcDocuments:    "tc_documents",
+		storyFeedback:  "story_feedback",
+		apiTrigger:     "api_trigger",
+		styleSheets:    "style_sheets",
+		costLedger:     "cost_ledger",
+		usageCounters:  "usage_counters",
+		quotaOverrides: "quota_overrides",
+		budgetPolicies: "budget_policy",
+		jobLeases:      "scheduler_leases",
+		jobRuns:        "scheduler_runs",
+		promptRegistry: "prompt_registry",
+		translations:   "story_translations",
+		mediaCache:     "media_cache",
+		appHelper:      &AppHelper{},
 	}
 }
 
@@ -155,7 +170,7 @@ func (s *StoryDatabase) CreateAPITrigger(ctx context.Context, api_model string,
 			"suspend":    true,
 			"created_at": getUTCTimestamp(),
 			"updated_at": getUTCTimestamp(),
-			"reset_at":   getNextMonthResetTime(),
+			"reset_at":   s.nextResetTime(ctx),
 			"api_model":  api_model,
 		}
 	} else {
@@ -201,13 +216,23 @@ func (s *StoryDatabase) CreateAPITrigger(ctx context.Context, api_model string,
 //	CreateAPITriggerWithOptions(ctx, "audio", &APITriggerOptions{BudgetAmount: &budget, CostAmount: &cost, Tag: &tag})  // With all parameters
 func (s *StoryDatabase) CreateAPIAudioTrigger(ctx context.Context, api_model string, options *APITriggerOptions) (string, error) {
 	log.Printf("Creating API Trigger for %s with options", api_model)
+	policy := s.GetBudgetPolicy(ctx)
+	resetAt := policy.NextReset(getUTCTimestamp())
+	decision := policy.Evaluate(api_model, configs.BudgetUsage{
+		CostAmount:   options.CostAmount,
+		BudgetAmount: options.BudgetAmount,
+		Threshold:    options.Threshold,
+		ResetAt:      resetAt,
+	}, getUTCTimestamp())
+
 	// Initialize userData with required fields
 	userData := map[string]interface{}{
 		"created_at":   getUTCTimestamp(),
 		"updated_at":   getUTCTimestamp(),
-		"reset_at":     getNextMonthResetTime(),
+		"reset_at":     resetAt,
 		"api_model":    api_model,
-		"tag":          tts.Chirp3HD.String(),
+		"tag":          decision.Voice,
+		"tier":         decision.Tier,
 		"budgetAmount": options.BudgetAmount,
 		"costAmount":   options.CostAmount,
 		"threshold":    options.Threshold,
@@ -215,10 +240,6 @@ func (s *StoryDatabase) CreateAPIAudioTrigger(ctx context.Context, api_model str
 		"currency":     options.Currency,
 	}
 
-	if options.Threshold > 0.25 {
-		userData["tag"] = tts.Standard.String()
-	}
-
 	_, err := s.client.Collection(s.apiTrigger).Doc(api_model).Set(ctx, userData)
 	if err != nil {
 		return "", fmt.Errorf("error creating api model: %v", err)
@@ -227,77 +248,35 @@ func (s *StoryDatabase) CreateAPIAudioTrigger(ctx context.Context, api_model str
 	return "Document written successfully", nil
 }
 
-// Create Trigger Document on a user
+// SuspendAudioAPI reports whether Google TTS has reached the fallback tier of the budget policy,
+// along with the voice type of its current tier. Usage past its reset time is cleared.
 func (s *StoryDatabase) SuspendAudioAPI(ctx context.Context, api_model string) (bool, string, error) {
 	log.Printf("Reading API Trigger for %s", api_model)
-	data, err := s.client.Collection(s.apiTrigger).Doc(api_model).Get(ctx)
+	decision, err := s.EvaluateBudget(ctx, api_model)
 	if err != nil {
-		return false, "", fmt.Errorf("error reading api model: %v", err)
+		return false, "", err
 	}
-	curr_time := getUTCTimestamp()
-	reset_at := data.Data()["reset_at"].(time.Time)
-
-	// Handle both int64 and float64 types for costAmount
-	threshold, ok := data.Data()["threshold"].(float64)
-	if !ok {
-		if intVal, intOk := data.Data()["threshold"].(int64); intOk {
-			threshold = float64(intVal)
-		} else {
-			threshold = 0
-		}
-	}
-	tag, ok := data.Data()["tag"].(string)
-	if !ok {
-		if intVal, intOk := data.Data()["tag"].(int64); intOk {
-			tag = string(intVal)
-		} else {
-			tag = ""
-		}
-	}
-	if curr_time.After(reset_at) {
-		s.CreateAPITrigger(ctx, "audio", tts.Chirp3HD.String(), &APITriggerOptions{BudgetAmount: 0, CostAmount: 0, Threshold: 0, DisplayName: "", Currency: ""})
+	if decision.Expired {
+		s.resetAudioTrigger(ctx, api_model)
 	}
-	if threshold >= 0.88 {
-		return true, tag, nil
+	return decision.Action == configs.BudgetActionFallback, decision.Voice, nil
+}
+
+// resetAudioTrigger clears the recorded audio spend for the new budget period
+func (s *StoryDatabase) resetAudioTrigger(ctx context.Context, api_model string) {
+	if _, err := s.CreateAPIAudioTrigger(ctx, api_model, &APITriggerOptions{}); err != nil {
+		log.Printf("Error resetting API Trigger for %s: %v", api_model, err)
 	}
-	return false, tag, nil
 }
 
+// SuspendGeminiAPI reports whether Gemini has reached the fallback tier of the budget policy
 func (s *StoryDatabase) SuspendGeminiAPI(ctx context.Context, api_model string) (bool, error) {
 	log.Printf("Reading API Trigger for %s", api_model)
-	data, err := s.client.Collection(s.apiTrigger).Doc(api_model).Get(ctx)
+	decision, err := s.EvaluateBudget(ctx, api_model)
 	if err != nil {
-		return false, fmt.Errorf("error reading api model: %v", err)
-	}
-	curr_time := getUTCTimestamp()
-	reset_at := data.Data()["reset_at"].(time.Time)
-
-	// Handle both int64 and float64 types for costAmount
-	costAmount, ok := data.Data()["costAmount"].(float64)
-	if !ok {
-		if intVal, intOk := data.Data()["costAmount"].(int64); intOk {
-			costAmount = float64(intVal)
-		} else {
-			costAmount = 0
-		}
-	}
-
-	// Handle both int64 and float64 types for budgetAmount
-	budgetAmount, ok := data.Data()["budgetAmount"].(float64)
-	if !ok {
-		if intVal, intOk := data.Data()["budgetAmount"].(int64); intOk {
-			budgetAmount = float64(intVal)
-		} else {
-			budgetAmount = 0
-		}
+		return false, err
 	}
-
-	thresholdPercCal := (costAmount / budgetAmount) * 100
-	//If the threshold percentage is greater than 90% and the current time is before the reset time, return true
-	if thresholdPercCal >= 60 && curr_time.Before(reset_at) {
-		return true, nil
-	}
-	return false, nil
+	return decision.Action == configs.BudgetActionFallback, nil
 }
 
 // Create TC Document on a user
@@ -326,6 +305,10 @@ func (s *StoryDatabase) CreateStoryFeedback(ctx context.Context, data *model.Sto
 		"storyId":    data.StoryId,
 		"email":      data.Email,
 	}
+	// Copy the prompt version of the story so feedback can be compared across prompt versions
+	for key, value := range s.storyPromptFields(ctx, data.StoryId) {
+		userData[key] = value
+	}
 	_, err := s.client.Collection(s.storyFeedback).Doc(data.StoryId).Set(ctx, userData)
 	if err != nil {
 		return "", fmt.Errorf("error creating user profile: %v", err)
@@ -871,6 +854,32 @@ func (s *StoryDatabase) GetStoryV2(ctx context.Context, storyID string) (map[str
 	return doc.Data(), nil
 }
 
+// GetStyleSheet reads the style sheet of a series, returning nil if none exists yet
+func (s *StoryDatabase) GetStyleSheet(ctx context.Context, seriesID string) (map[string]interface{}, error) {
+	doc, err := s.client.Collection(s.styleSheets).Doc(seriesID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting style sheet: %v", err)
+	}
+	return doc.Data(), nil
+}
+
+// CreateStyleSheet stores the style sheet of a series unless one already exists.
+// It returns the sheet that is now stored, so concurrent stories of a series agree on one sheet.
+func (s *StoryDatabase) CreateStyleSheet(ctx context.Context, seriesID string, sheetData map[string]interface{}) (map[string]interface{}, error) {
+	sheetData["created_at"] = getUTCTimestamp()
+	_, err := s.client.Collection(s.styleSheets).Doc(seriesID).Create(ctx, sheetData)
+	if status.Code(err) == codes.AlreadyExists {
+		return s.GetStyleSheet(ctx, seriesID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating style sheet: %v", err)
+	}
+	return sheetData, nil
+}
+
 // ListStoriesV2 lists stories v2 with filtering
 func (s *StoryDatabase) ListStoriesV2(ctx context.Context, limit int, theme, title string) (map[string]interface{}, error) {
 	log.Printf("Listing stories v2 with limit: %d, theme: %s, title: %s", limit, theme, title)
@@ -920,6 +929,9 @@ func (s *StoryDatabase) ListStoriesByThemeID(ctx context.Context, themeID string
 	return result, nil
 }
 
+// DeleteField removes a field when given as its value to UpdateStory
+var DeleteField interface{} = firestore.Delete
+
 // UpdateStory updates an existing story
 func (s *StoryDatabase) UpdateStory(ctx context.Context, storyID string, storyData map[string]interface{}) error {
 	// Convert map to firestore updates
@@ -982,7 +994,7 @@ func (s *StoryDatabase) UpdateAPITokens(ctx context.Context, api_model string, t
 		userData = map[string]interface{}{
 			"created_at":   getUTCTimestamp(),
 			"updated_at":   getUTCTimestamp(),
-			"reset_at":     getNextMonthResetTime(),
+			"reset_at":     s.nextResetTime(ctx),
 			"api_model":    api_model,
 			"budgetAmount": 0,
 			"costAmount":   0,
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetMediaCacheEntry reads the media cache entry of a content hash, returning nil on a miss
+func (s *StoryDatabase) GetMediaCacheEntry(ctx context.Context, key string) (map[string]interface{}, error) {
+	doc, err := s.client.Collection(s.mediaCache).Doc(key).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting media cache entry: %v", err)
+	}
+	return doc.Data(), nil
+}
+
+// SetMediaCacheEntry stores the media cache entry of a content hash
+func (s *StoryDatabase) SetMediaCacheEntry(ctx context.Context, key string, entry map[string]interface{}) error {
+	if _, err := s.client.Collection(s.mediaCache).Doc(key).Set(ctx, entry); err != nil {
+		return fmt.Errorf("error setting media cache entry: %v", err)
+	}
+	return nil
+}
+
+// ExpireMediaCacheEntries deletes the media cache entries last used before cutoff, judging by their
+// last hit or, without one, their creation. It returns how many were deleted and the blob paths of
+// the files of the entries kept.
+func (s *StoryDatabase) ExpireMediaCacheEntries(ctx context.Context, cutoff time.Time) (int, map[string]bool, error) {
+	iter := s.client.Collection(s.mediaCache).Documents(ctx)
+	defer iter.Stop()
+	expired := 0
+	live := make(map[string]bool)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return expired, live, nil
+		}
+		if err != nil {
+			return expired, nil, fmt.Errorf("error reading media cache: %v", err)
+		}
+		data := doc.Data()
+		lastUsed, _ := data["created_at"].(time.Time)
+		if lastHit, ok := data["last_hit_at"].(time.Time); ok && lastHit.After(lastUsed) {
+			lastUsed = lastHit
+		}
+		if lastUsed.Before(cutoff) {
+			if _, err := doc.Ref.Delete(ctx); err != nil {
+				return expired, nil, fmt.Errorf("error deleting media cache entry %s: %v", doc.Ref.ID, err)
+			}
+			expired++
+			continue
+		}
+		files, _ := data["files"].(map[string]interface{})
+		for _, value := range files {
+			if blobPath, ok := value.(string); ok {
+				live[blobPath] = true
+			}
+		}
+	}
+}
+
+// RecordMediaCacheHit counts a hit on the media cache entry of a content hash
+func (s *StoryDatabase) RecordMediaCacheHit(ctx context.Context, key string) error {
+	_, err := s.client.Collection(s.mediaCache).Doc(key).Update(ctx, []firestore.Update{
+		{Path: "hits", Value: firestore.Increment(1)},
+		{Path: "last_hit_at", Value: getUTCTimestamp()},
+	})
+	if err != nil {
+		return fmt.Errorf("error recording media cache hit: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"rio-go-model/configs"
+
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// promptRegistryDoc is the document holding the registry set by an admin
+const promptRegistryDoc = "active"
+
+// promptRegistryTTL is how long a registry read from Firestore is reused
+const promptRegistryTTL = time.Minute
+
+// PromptVersionStats counts the feedback given to stories written with one prompt version
+type PromptVersionStats struct {
+	Template   string  `json:"template"`
+	Version    string  `json:"version"`
+	Experiment string  `json:"experiment,omitempty"`
+	Feedback   int     `json:"feedback"`
+	Likes      int     `json:"likes"`
+	LikeRate   float64 `json:"like_rate"`
+}
+
+// GetPromptRegistry returns the registry stored in Firestore, or the configured one when none is stored.
+// Reads are cached for a minute; a failed read falls back to the configured registry.
+func (s *StoryDatabase) GetPromptRegistry(ctx context.Context) *configs.PromptRegistry {
+	s.promptRegistryMu.Lock()
+	defer s.promptRegistryMu.Unlock()
+	if s.promptRegistryCache != nil && time.Since(s.promptRegistryCachedAt) < promptRegistryTTL {
+		return s.promptRegistryCache
+	}
+
+	registry := configs.GetSettings().PromptRegistry
+	stored, err := s.getStoredPromptRegistry(ctx)
+	if err != nil {
+		log.Printf("Warning: using configured prompt registry: %v", err)
+	} else if stored != nil {
+		registry = stored
+	}
+	s.promptRegistryCache = registry
+	s.promptRegistryCachedAt = time.Now()
+	return registry
+}
+
+// SetPromptRegistry stores a registry that overrides the configured one
+func (s *StoryDatabase) SetPromptRegistry(ctx context.Context, registry *configs.PromptRegistry, updatedBy string) error {
+	if err := registry.Validate(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(registry)
+	if err != nil {
+		return fmt.Errorf("error encoding prompt registry: %v", err)
+	}
+	_, err = s.client.Collection(s.promptRegistry).Doc(promptRegistryDoc).Set(ctx, map[string]interface{}{
+		"registry":   string(raw),
+		"updated_by": updatedBy,
+		"updated_at": getUTCTimestamp(),
+	})
+	if err != nil {
+		return fmt.Errorf("error setting prompt registry: %v", err)
+	}
+	s.resetPromptRegistryCache()
+	return nil
+}
+
+// DeletePromptRegistry removes the stored registry so the configured one applies again
+func (s *StoryDatabase) DeletePromptRegistry(ctx context.Context) error {
+	_, err := s.client.Collection(s.promptRegistry).Doc(promptRegistryDoc).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting prompt registry: %v", err)
+	}
+	s.resetPromptRegistryCache()
+	return nil
+}
+
+// GetPromptVersionStats joins story feedback with the prompt version recorded on each story,
+// optionally limited to one experiment
+func (s *StoryDatabase) GetPromptVersionStats(ctx context.Context, experiment string) ([]PromptVersionStats, error) {
+	query := s.client.Collection(s.storyFeedback).Query
+	if experiment != "" {
+		query = query.Where("prompt_experiment", "==", experiment)
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+	stats := make(map[string]*PromptVersionStats)
+	var order []string
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading story feedback: %v", err)
+		}
+		data := doc.Data()
+		version := stringValue(data, "prompt_version")
+		if version == "" {
+			continue
+		}
+		template := stringValue(data, "prompt_template")
+		exp := stringValue(data, "prompt_experiment")
+		key := template + "/" + version + "/" + exp
+		entry, ok := stats[key]
+		if !ok {
+			entry = &PromptVersionStats{Template: template, Version: version, Experiment: exp}
+			stats[key] = entry
+			order = append(order, key)
+		}
+		entry.Feedback++
+		if like, _ := data["like"].(bool); like {
+			entry.Likes++
+		}
+	}
+	result := make([]PromptVersionStats, 0, len(order))
+	for _, key := range order {
+		entry := stats[key]
+		entry.LikeRate = float64(entry.Likes) / float64(entry.Feedback)
+		result = append(result, *entry)
+	}
+	return result, nil
+}
+
+// storyPromptFields returns the prompt fields recorded on a story, so feedback can be grouped by prompt version.
+// storyID may be the document ID or the story_id field.
+func (s *StoryDatabase) storyPromptFields(ctx context.Context, storyID string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if storyID == "" {
+		return fields
+	}
+	var data map[string]interface{}
+	doc, err := s.client.Collection(s.CollectionV2).Doc(storyID).Get(ctx)
+	if err == nil {
+		data = doc.Data()
+	} else {
+		docs, err := s.client.Collection(s.CollectionV2).Where("story_id", "==", storyID).Limit(1).Documents(ctx).GetAll()
+		if err != nil || len(docs) == 0 {
+			return fields
+		}
+		data = docs[0].Data()
+	}
+	for _, key := range []string{"prompt_template", "prompt_version", "prompt_experiment"} {
+		if value, ok := data[key]; ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// getStoredPromptRegistry returns the registry stored in Firestore, or nil when there is none
+func (s *StoryDatabase) getStoredPromptRegistry(ctx context.Context) (*configs.PromptRegistry, error) {
+	if s.client == nil {
+		return nil, nil
+	}
+	doc, err := s.client.Collection(s.promptRegistry).Doc(promptRegistryDoc).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading prompt registry: %v", err)
+	}
+	raw, _ := doc.Data()["registry"].(string)
+	return configs.ParsePromptRegistry([]byte(raw))
+}
+
+func (s *StoryDatabase) resetPromptRegistryCache() {
+	s.promptRegistryMu.Lock()
+	s.promptRegistryCache = nil
+	s.promptRegistryMu.Unlock()
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/internal/model"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// quotaOverrideFields are the plan limit fields an admin override may set
+var quotaOverrideFields = []string{"story_requests_per_day", "story_requests_per_month", "regenerations_per_day"}
+
+// ConsumeQuota checks a user's limit of the given kind and counts one use, in a single transaction.
+// It returns a *model.QuotaExceededError when the limit is already reached.
+func (s *StoryDatabase) ConsumeQuota(ctx context.Context, email, kind string) (*model.QuotaUsage, error) {
+	var usage *model.QuotaUsage
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		usage, err = s.readQuotaUsage(ctx, tx, email)
+		if err != nil {
+			return err
+		}
+		if exceeded := quotaExceeded(usage, kind); exceeded != nil {
+			return exceeded
+		}
+
+		switch kind {
+		case model.QuotaStoryRequests:
+			usage.DayStoryRequests++
+			usage.MonthStoryRequests++
+		case model.QuotaRegenerations:
+			usage.DayRegenerations++
+		default:
+			return fmt.Errorf("unknown quota kind: %s", kind)
+		}
+		return tx.Set(s.client.Collection(s.usageCounters).Doc(email), map[string]interface{}{
+			"email":                email,
+			"day":                  usage.Day,
+			"day_story_requests":   usage.DayStoryRequests,
+			"month":                usage.Month,
+			"month_story_requests": usage.MonthStoryRequests,
+			"day_regenerations":    usage.DayRegenerations,
+			"updated_at":           getUTCTimestamp(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// RefundQuota gives back one use of kind counted by ConsumeQuota today, for work that was not delivered
+func (s *StoryDatabase) RefundQuota(ctx context.Context, email, kind string) error {
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		ref := s.client.Collection(s.usageCounters).Doc(email)
+		counters, err := getOptionalDoc(tx, ref)
+		if err != nil {
+			return err
+		}
+		now := getUTCTimestamp()
+		var updates []firestore.Update
+		switch kind {
+		case model.QuotaStoryRequests:
+			if day, _ := counters["day"].(string); day == now.Format("2006-01-02") && intField(counters, "day_story_requests") > 0 {
+				updates = append(updates, firestore.Update{Path: "day_story_requests", Value: firestore.Increment(-1)})
+			}
+			if month, _ := counters["month"].(string); month == now.Format("2006-01") && intField(counters, "month_story_requests") > 0 {
+				updates = append(updates, firestore.Update{Path: "month_story_requests", Value: firestore.Increment(-1)})
+			}
+		case model.QuotaRegenerations:
+			if day, _ := counters["day"].(string); day == now.Format("2006-01-02") && intField(counters, "day_regenerations") > 0 {
+				updates = append(updates, firestore.Update{Path: "day_regenerations", Value: firestore.Increment(-1)})
+			}
+		default:
+			return fmt.Errorf("unknown quota kind: %s", kind)
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Update(ref, append(updates, firestore.Update{Path: "updated_at", Value: now}))
+	})
+	if err != nil {
+		return fmt.Errorf("error refunding %s quota: %v", kind, err)
+	}
+	return nil
+}
+
+// GetQuotaUsage returns a user's current usage and effective limits
+func (s *StoryDatabase) GetQuotaUsage(ctx context.Context, email string) (*model.QuotaUsage, error) {
+	var usage *model.QuotaUsage
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		usage, err = s.readQuotaUsage(ctx, tx, email)
+		return err
+	}, firestore.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// SetQuotaOverride stores admin overrides of a user's plan and limits
+func (s *StoryDatabase) SetQuotaOverride(ctx context.Context, email string, override map[string]interface{}) error {
+	override["email"] = email
+	override["updated_at"] = getUTCTimestamp()
+	_, err := s.client.Collection(s.quotaOverrides).Doc(email).Set(ctx, override)
+	if err != nil {
+		return fmt.Errorf("error setting quota override: %v", err)
+	}
+	return nil
+}
+
+// DeleteQuotaOverride removes the admin overrides of a user
+func (s *StoryDatabase) DeleteQuotaOverride(ctx context.Context, email string) error {
+	_, err := s.client.Collection(s.quotaOverrides).Doc(email).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting quota override: %v", err)
+	}
+	return nil
+}
+
+// readQuotaUsage reads the plan, overrides and counters of a user inside a transaction.
+// Counters of a past day or month are returned as zero.
+func (s *StoryDatabase) readQuotaUsage(ctx context.Context, tx *firestore.Transaction, email string) (*model.QuotaUsage, error) {
+	profile, err := getOptionalDoc(tx, s.client.Collection(s.userProfiles).Doc(email))
+	if err != nil {
+		return nil, fmt.Errorf("error reading user profile: %v", err)
+	}
+	override, err := getOptionalDoc(tx, s.client.Collection(s.quotaOverrides).Doc(email))
+	if err != nil {
+		return nil, fmt.Errorf("error reading quota override: %v", err)
+	}
+	counters, err := getOptionalDoc(tx, s.client.Collection(s.usageCounters).Doc(email))
+	if err != nil {
+		return nil, fmt.Errorf("error reading usage counters: %v", err)
+	}
+
+	now := getUTCTimestamp()
+	usage := &model.QuotaUsage{
+		Email: email,
+		Plan:  configs.DefaultPlan,
+		Day:   now.Format("2006-01-02"),
+		Month: now.Format("2006-01"),
+	}
+	if plan, ok := profile["plan"].(string); ok && plan != "" {
+		usage.Plan = plan
+	}
+	if plan, ok := override["plan"].(string); ok && plan != "" {
+		usage.Plan = plan
+	}
+	usage.Limits = configs.GetSettings().GetPlanLimits(usage.Plan)
+	for _, field := range quotaOverrideFields {
+		value, ok := override[field].(int64)
+		if !ok {
+			continue
+		}
+		usage.Overridden = true
+		switch field {
+		case "story_requests_per_day":
+			usage.Limits.StoryRequestsPerDay = int(value)
+		case "story_requests_per_month":
+			usage.Limits.StoryRequestsPerMonth = int(value)
+		case "regenerations_per_day":
+			usage.Limits.RegenerationsPerDay = int(value)
+		}
+	}
+
+	if day, _ := counters["day"].(string); day == usage.Day {
+		usage.DayStoryRequests = intField(counters, "day_story_requests")
+		usage.DayRegenerations = intField(counters, "day_regenerations")
+	}
+	if month, _ := counters["month"].(string); month == usage.Month {
+		usage.MonthStoryRequests = intField(counters, "month_story_requests")
+	}
+	return usage, nil
+}
+
+// quotaExceeded returns the limit that one more use of kind would break, or nil
+func quotaExceeded(usage *model.QuotaUsage, kind string) *model.QuotaExceededError {
+	now := getUTCTimestamp()
+	nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	limits := usage.Limits
+
+	switch kind {
+	case model.QuotaStoryRequests:
+		if limits.StoryRequestsPerMonth >= 0 && usage.MonthStoryRequests >= limits.StoryRequestsPerMonth {
+			return &model.QuotaExceededError{Limit: "story_requests_per_month", Max: limits.StoryRequestsPerMonth, ResetAt: nextMonth}
+		}
+		if limits.StoryRequestsPerDay >= 0 && usage.DayStoryRequests >= limits.StoryRequestsPerDay {
+			return &model.QuotaExceededError{Limit: "story_requests_per_day", Max: limits.StoryRequestsPerDay, ResetAt: nextDay}
+		}
+	case model.QuotaRegenerations:
+		if limits.RegenerationsPerDay >= 0 && usage.DayRegenerations >= limits.RegenerationsPerDay {
+			return &model.QuotaExceededError{Limit: "regenerations_per_day", Max: limits.RegenerationsPerDay, ResetAt: nextDay}
+		}
+	}
+	return nil
+}
+
+// getOptionalDoc reads a document in a transaction, returning nil data when it does not exist
+func getOptionalDoc(tx *firestore.Transaction, ref *firestore.DocumentRef) (map[string]interface{}, error) {
+	doc, err := tx.Get(ref)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Data(), nil
+}
+
+// intField reads an integer field stored by Firestore as int64
+func intField(data map[string]interface{}, key string) int {
+	value, _ := data[key].(int64)
+	return int(value)
+}
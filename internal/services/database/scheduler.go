@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/util"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// AcquireJobLease takes the lease of a scheduled job for holder, in a transaction.
+// It succeeds when nobody holds the lease, it has expired or holder already owns it.
+func (s *StoryDatabase) AcquireJobLease(ctx context.Context, job, holder string, ttl time.Duration) (bool, error) {
+	ref := s.client.Collection(s.jobLeases).Doc(job)
+	acquired := false
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		acquired = false
+		lease, err := getOptionalDoc(tx, ref)
+		if err != nil {
+			return err
+		}
+		now := getUTCTimestamp()
+		currentHolder, _ := lease["holder"].(string)
+		expiresAt, _ := lease["expires_at"].(time.Time)
+		if currentHolder != "" && currentHolder != holder && now.Before(expiresAt) {
+			return nil
+		}
+		acquired = true
+		return tx.Set(ref, map[string]interface{}{
+			"job":         job,
+			"holder":      holder,
+			"acquired_at": now,
+			"expires_at":  now.Add(ttl),
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("error acquiring lease of %s: %v", job, err)
+	}
+	return acquired, nil
+}
+
+// ReleaseJobLease gives up the lease of a job if holder still owns it
+func (s *StoryDatabase) ReleaseJobLease(ctx context.Context, job, holder string) error {
+	ref := s.client.Collection(s.jobLeases).Doc(job)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		lease, err := getOptionalDoc(tx, ref)
+		if err != nil {
+			return err
+		}
+		if currentHolder, _ := lease["holder"].(string); currentHolder != holder {
+			return nil
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing lease of %s: %v", job, err)
+	}
+	return nil
+}
+
+// SaveJobRun stores the latest run of a job
+func (s *StoryDatabase) SaveJobRun(ctx context.Context, run *model.JobRun) error {
+	_, err := s.client.Collection(s.jobRuns).Doc(run.Job).Set(ctx, run.ToMap())
+	if err != nil {
+		return fmt.Errorf("error saving job run: %v", err)
+	}
+	return nil
+}
+
+// GetJobRuns returns the latest run of every job, keyed by job name
+func (s *StoryDatabase) GetJobRuns(ctx context.Context) (map[string]*model.JobRun, error) {
+	docs, err := s.client.Collection(s.jobRuns).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading job runs: %v", err)
+	}
+	runs := make(map[string]*model.JobRun, len(docs))
+	for _, doc := range docs {
+		run := &model.JobRun{}
+		run.FromMap(doc.Data())
+		runs[doc.Ref.ID] = run
+	}
+	return runs, nil
+}
+
+// ResetExpiredAPITriggers clears the recorded spend of every provider whose reset_at has passed.
+// It returns the providers that were reset.
+func (s *StoryDatabase) ResetExpiredAPITriggers(ctx context.Context) ([]string, error) {
+	docs, err := s.client.Collection(s.apiTrigger).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading api triggers: %v", err)
+	}
+	now := getUTCTimestamp()
+	nextReset := s.nextResetTime(ctx)
+	var reset []string
+	for _, doc := range docs {
+		resetAt, ok := doc.Data()["reset_at"].(time.Time)
+		if !ok || now.Before(resetAt) {
+			continue
+		}
+		updates := []firestore.Update{
+			{Path: "budgetAmount", Value: 0},
+			{Path: "costAmount", Value: 0},
+			{Path: "threshold", Value: 0},
+			{Path: "tokensUsed", Value: 0},
+			{Path: "reset_at", Value: nextReset},
+			{Path: "updated_at", Value: now},
+		}
+		if doc.Ref.ID == "audio" {
+			decision := s.GetBudgetPolicy(ctx).Evaluate(doc.Ref.ID, configs.BudgetUsage{ResetAt: nextReset}, now)
+			updates = append(updates, firestore.Update{Path: "tag", Value: decision.Voice}, firestore.Update{Path: "tier", Value: decision.Tier})
+		}
+		if _, err := doc.Ref.Update(ctx, updates); err != nil {
+			return reset, fmt.Errorf("error resetting api trigger %s: %v", doc.Ref.ID, err)
+		}
+		log.Printf("Reset API Trigger for %s until %s", doc.Ref.ID, nextReset.Format(time.RFC3339))
+		reset = append(reset, doc.Ref.ID)
+	}
+	return reset, nil
+}
+
+// MostCommonProfileKeys groups user profiles by the settings stories are generated for
+// and returns the limit most shared combinations
+func (s *StoryDatabase) MostCommonProfileKeys(ctx context.Context, limit int) ([]model.ProfileKey, error) {
+	iter := s.client.Collection(s.userProfiles).Documents(ctx)
+	defer iter.Stop()
+	counts := make(map[string]*model.ProfileKey)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading user profiles: %v", err)
+		}
+		data := doc.Data()
+		key := model.ProfileKey{
+			Country:     stringValue(data, "country"),
+			City:        stringValue(data, "city"),
+			Religions:   sortedStrings(util.SafeStringSlice(data["religions"])),
+			Preferences: sortedStrings(util.SafeStringSlice(data["preferences"])),
+			Language:    stringValue(data, "language"),
+		}
+		if key.Language == "" || len(key.Preferences) == 0 {
+			continue
+		}
+		id := strings.Join([]string{key.Country, key.City, key.Language, strings.Join(key.Religions, ","), strings.Join(key.Preferences, ",")}, "|")
+		if existing, ok := counts[id]; ok {
+			existing.Users++
+			continue
+		}
+		key.Users = 1
+		counts[id] = &key
+	}
+
+	keys := make([]model.ProfileKey, 0, len(counts))
+	for _, key := range counts {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Users != keys[j].Users {
+			return keys[i].Users > keys[j].Users
+		}
+		return keys[i].Country+keys[i].City < keys[j].Country+keys[j].City
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
+
+// ListStoriesMissingMedia returns up to limit stories without an image or audio, with their document ID under "id".
+// An equality query on "" skips documents that lack the field altogether, so the stories are scanned for
+// both fields and checked here, and only the ones missing media are read in full.
+func (s *StoryDatabase) ListStoriesMissingMedia(ctx context.Context, limit int) ([]map[string]interface{}, error) {
+	iter := s.client.Collection(s.CollectionV2).Select("image_url", "audio_url").Documents(ctx)
+	defer iter.Stop()
+	var refs []*firestore.DocumentRef
+	for len(refs) < limit {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing stories without media: %v", err)
+		}
+		data := doc.Data()
+		if stringValue(data, "image_url") == "" || stringValue(data, "audio_url") == "" {
+			refs = append(refs, doc.Ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	docs, err := s.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stories without media: %v", err)
+	}
+	stories := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		data := doc.Data()
+		data["id"] = doc.Ref.ID
+		stories = append(stories, data)
+	}
+	return stories, nil
+}
+
+// storyBlobFields are the story and translation fields holding a blob path
+var storyBlobFields = []string{"image_url", "image_thumb_url", "image_medium_url", "audio_url", "timing_url", "captions_url", "soundscape_audio_url"}
+
+// ReferencedBlobs returns the blob path of every media file a story or story translation points to
+func (s *StoryDatabase) ReferencedBlobs(ctx context.Context) (map[string]bool, error) {
+	blobs := make(map[string]bool)
+	for _, collection := range []string{s.CollectionV2, s.translations} {
+		fields := append([]string{"pages", "paragraphs"}, storyBlobFields...)
+		iter := s.client.Collection(collection).Select(fields...).Documents(ctx)
+		err := collectBlobs(iter, blobs)
+		iter.Stop()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
+
+// collectBlobs adds the blob paths of every document of iter to blobs
+func collectBlobs(iter *firestore.DocumentIterator, blobs map[string]bool) error {
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading stories: %v", err)
+		}
+		data := doc.Data()
+		for _, field := range storyBlobFields {
+			if path := stringValue(data, field); path != "" {
+				blobs[path] = true
+			}
+		}
+		pages, _ := data["pages"].([]interface{})
+		for _, page := range pages {
+			if pageData, ok := page.(map[string]interface{}); ok {
+				for _, field := range []string{"image_url", "image_thumb_url", "image_medium_url"} {
+					if path := stringValue(pageData, field); path != "" {
+						blobs[path] = true
+					}
+				}
+			}
+		}
+		// Each side of a bilingual paragraph has its own audio
+		paragraphs, _ := data["paragraphs"].([]interface{})
+		for _, paragraph := range paragraphs {
+			paragraphData, _ := paragraph.(map[string]interface{})
+			for _, side := range []string{"original", "translation"} {
+				if segment, ok := paragraphData[side].(map[string]interface{}); ok {
+					if path := stringValue(segment, "audio_url"); path != "" {
+						blobs[path] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// stringValue reads a string field, returning "" when it is missing
+func stringValue(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// sortedStrings returns a sorted copy of values
+func sortedStrings(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -85,6 +87,14 @@ func (s *StorageService) GenerateSignedURL(blobPath string, expiration time.Dura
 		return "", fmt.Errorf("storage service not initialized")
 	}
 
+	// The storage emulator cannot sign URLs but serves objects directly
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(host, "/"), s.bucketName, blobPath), nil
+	}
+
 	opts := &storage.SignedURLOptions{
 		Scheme:  storage.SigningSchemeV4,          // Use V4 signing scheme.
 		Method:  "GET",                            // The URL allows a GET request.
@@ -133,6 +143,30 @@ func (s *StorageService) UploadFile(fileData []byte, prefix, extension string) (
 	return filename, nil
 }
 
+// UploadFileAtPath uploads a file to a caller-chosen blob path, taking the content type from its extension
+func (s *StorageService) UploadFileAtPath(fileData []byte, path string) (string, error) {
+	if s.bucket == nil {
+		return "", fmt.Errorf("storage service not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	writer := s.bucket.Object(path).NewWriter(ctx)
+	writer.ContentType = getContentType(path[strings.LastIndex(path, ".")+1:])
+
+	if _, err := writer.Write(fileData); err != nil {
+		writer.Close()
+		return "", fmt.Errorf("failed to write file data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize upload of %s: %v", path, err)
+	}
+
+	log.Printf("Successfully uploaded file: %s", path)
+	return path, nil
+}
+
 // UploadBase64 uploads base64 encoded data to cloud storage
 func (s *StorageService) UploadBase64(base64Data, prefix, extension string) (string, error) {
 	// Decode base64 data
@@ -203,6 +237,50 @@ func (s *StorageService) readFile(folder, filename string) ([]byte, error) {
 	return data, nil
 }
 
+// ReadBlob reads the blob at a full path, such as one returned by UploadFileAtPath
+func (s *StorageService) ReadBlob(path string) ([]byte, error) {
+	if s.bucket == nil {
+		return nil, fmt.Errorf("storage service not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reader, err := s.bucket.Object(path).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reader for %s: %v", path, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return data, nil
+}
+
+// TouchBlob records the current time in the metadata of the blob at path, which moves its update time.
+// It returns false when there is no blob at path.
+func (s *StorageService) TouchBlob(path string) (bool, error) {
+	if s.bucket == nil {
+		return false, fmt.Errorf("storage service not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := s.bucket.Object(path).Update(ctx, storage.ObjectAttrsToUpdate{
+		Metadata: map[string]string{"last_used_at": time.Now().UTC().Format(time.RFC3339)},
+	})
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to touch %s: %v", path, err)
+	}
+	return true, nil
+}
+
 // DeleteFile deletes a file from cloud storage
 func (s *StorageService) DeleteFile(filename string) error {
 	if s.bucket == nil {
@@ -252,6 +330,35 @@ func (s *StorageService) ListFiles(folder string) ([]string, error) {
 	return files, nil
 }
 
+// BlobInfo is the name, creation and last update time of a stored file
+type BlobInfo struct {
+	Name    string
+	Created time.Time
+	// Updated is when the file or its metadata last changed, as TouchBlob does
+	Updated time.Time
+}
+
+// ListBlobs lists every file under prefix, including nested folders
+func (s *StorageService) ListBlobs(ctx context.Context, prefix string) ([]BlobInfo, error) {
+	if s.bucket == nil {
+		return nil, fmt.Errorf("storage service not initialized")
+	}
+
+	var blobs []BlobInfo
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files under %s: %v", prefix, err)
+		}
+		blobs = append(blobs, BlobInfo{Name: obj.Name, Created: obj.Created, Updated: obj.Updated})
+	}
+	return blobs, nil
+}
+
 // HealthCheck checks if storage service is accessible
 func (s *StorageService) HealthCheck(ctx context.Context) error {
 	if s.bucket == nil {
@@ -296,6 +403,10 @@ func getContentType(extension string) string {
 		return "audio/wav"
 	case "ogg":
 		return "audio/ogg"
+	case "json":
+		return "application/json"
+	case "vtt":
+		return "text/vtt"
 	case "pdf":
 		return "application/pdf"
 	case "txt":
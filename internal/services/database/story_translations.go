@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// translationDocID is the ID of the translation of a story into a language
+func translationDocID(storyID, language string) string {
+	return storyID + "_" + language
+}
+
+// GetStoryTranslation reads the translation of a story into a language, returning nil if none exists yet
+func (s *StoryDatabase) GetStoryTranslation(ctx context.Context, storyID, language string) (map[string]interface{}, error) {
+	doc, err := s.client.Collection(s.translations).Doc(translationDocID(storyID, language)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting story translation: %v", err)
+	}
+	data := doc.Data()
+	data["id"] = doc.Ref.ID
+	return data, nil
+}
+
+// CreateStoryTranslation stores the translation of a story and links it from the story's translations map.
+// It returns the translation that is now stored, so concurrent requests agree on one translation;
+// created is false when another request stored it first.
+func (s *StoryDatabase) CreateStoryTranslation(ctx context.Context, storyID, language string, translationData map[string]interface{}) (stored map[string]interface{}, created bool, err error) {
+	docID := translationDocID(storyID, language)
+	translationData["source_story_id"] = storyID
+	translationData["language"] = language
+	translationData["created_at"] = getUTCTimestamp()
+	_, err = s.client.Collection(s.translations).Doc(docID).Create(ctx, translationData)
+	if status.Code(err) == codes.AlreadyExists {
+		stored, err = s.GetStoryTranslation(ctx, storyID, language)
+		return stored, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating story translation: %v", err)
+	}
+	translationData["id"] = docID
+
+	_, err = s.client.Collection(s.CollectionV2).Doc(storyID).Update(ctx, []firestore.Update{
+		{FieldPath: firestore.FieldPath{"translations", language}, Value: docID},
+		{Path: "updated_at", Value: getUTCTimestamp()},
+	})
+	// The translation already points back at its story, so a failed link is not fatal
+	if err != nil {
+		log.Printf("Warning: failed to link translation %s from story %s: %v", docID, storyID, err)
+	}
+	return translationData, true, nil
+}
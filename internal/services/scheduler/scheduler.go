@@ -0,0 +1,359 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// ConcurrencyPolicy decides what happens when a job is due while its previous run is still going
+type ConcurrencyPolicy string
+
+const (
+	Forbid  ConcurrencyPolicy = "forbid"  // skip the new run
+	Replace ConcurrencyPolicy = "replace" // cancel the running one and start the new run
+	Allow   ConcurrencyPolicy = "allow"   // let the runs overlap
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// tickInterval is how often due jobs are checked
+const tickInterval = 20 * time.Second
+
+// leaseMargin keeps a lease a little longer than the job may run
+const leaseMargin = time.Minute
+
+var (
+	// ErrUnknownJob is returned for a job name that was never registered
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrJobRunning is returned when a forbid job is still running on this instance
+	ErrJobRunning = errors.New("job is already running")
+	// ErrLeaseHeld is returned when another instance holds the job's lease
+	ErrLeaseHeld = errors.New("job lease is held by another instance")
+)
+
+// Job is a unit of scheduled work
+type Job struct {
+	Name        string
+	Schedule    string
+	Timeout     time.Duration
+	Concurrency ConcurrencyPolicy
+	// Run does the work. The summary it returns is kept with the run status.
+	Run func(ctx context.Context) (string, error)
+}
+
+// Store shares job leases and run status between instances
+type Store interface {
+	AcquireJobLease(ctx context.Context, job, holder string, ttl time.Duration) (bool, error)
+	ReleaseJobLease(ctx context.Context, job, holder string) error
+	SaveJobRun(ctx context.Context, run *model.JobRun) error
+	GetJobRuns(ctx context.Context) (map[string]*model.JobRun, error)
+}
+
+// JobStatus describes a registered job and its last run on any instance
+type JobStatus struct {
+	Name        string        `json:"name"`
+	Schedule    string        `json:"schedule"`
+	Timeout     string        `json:"timeout"`
+	Concurrency string        `json:"concurrency"`
+	NextRun     time.Time     `json:"next_run"`
+	RunningHere int           `json:"running_here"`
+	LastRun     *model.JobRun `json:"last_run,omitempty"`
+}
+
+// entry is a registered job with its local run state
+type entry struct {
+	job      Job
+	schedule *Schedule
+	next     time.Time
+	seq      int64
+	running  map[int64]context.CancelFunc
+}
+
+// Scheduler runs registered jobs on their cron schedules. A lease in the store makes sure
+// only one instance runs a job at a time.
+type Scheduler struct {
+	store    Store
+	instance string
+	enabled  bool
+	logger   *util.CustomLogger
+
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	names   []string
+	baseCtx context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler. Jobs only run on their schedule once Start is called
+// and SCHEDULER_ENABLED is set; they can always be run by hand.
+func NewScheduler(store Store) *Scheduler {
+	host, _ := os.Hostname()
+	settings := configs.GetSettings()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:    store,
+		instance: fmt.Sprintf("%s-%s", host, uuid.New().String()[:8]),
+		enabled:  settings.SchedulerEnabled,
+		logger:   util.GetLogger("scheduler", settings),
+		jobs:     make(map[string]*entry),
+		baseCtx:  ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register adds a job. It fails on a duplicate name, an invalid schedule or a missing timeout.
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return err
+	}
+	if job.Timeout <= 0 {
+		return fmt.Errorf("job %s: timeout is required", job.Name)
+	}
+	switch job.Concurrency {
+	case "":
+		job.Concurrency = Forbid
+	case Forbid, Replace, Allow:
+	default:
+		return fmt.Errorf("job %s: unknown concurrency policy %q", job.Name, job.Concurrency)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.jobs[job.Name] = &entry{
+		job:      job,
+		schedule: schedule,
+		next:     schedule.Next(time.Now()),
+		running:  make(map[int64]context.CancelFunc),
+	}
+	s.names = append(s.names, job.Name)
+	return nil
+}
+
+// Start begins running jobs on their schedules in the background
+func (s *Scheduler) Start() {
+	if !s.enabled {
+		s.logger.Infof("Scheduler disabled; jobs only run when triggered by an admin")
+		return
+	}
+	s.logger.Infof("Scheduler started on instance %s with %d jobs", s.instance, len(s.names))
+	s.wg.Add(1)
+	util.GoroutineWithRecovery(func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.baseCtx.Done():
+				return
+			case now := <-ticker.C:
+				s.runDue(now)
+			}
+		}
+	})
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RunNow starts a job outside its schedule. The job runs in the background.
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	e, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+	return s.start(e, TriggerManual)
+}
+
+// Status returns every registered job with its last run
+func (s *Scheduler) Status(ctx context.Context) ([]JobStatus, error) {
+	runs, err := s.store.GetJobRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, 0, len(s.names))
+	for _, name := range s.names {
+		e := s.jobs[name]
+		status := JobStatus{
+			Name:        name,
+			Schedule:    e.schedule.String(),
+			Timeout:     e.job.Timeout.String(),
+			Concurrency: string(e.job.Concurrency),
+			RunningHere: len(e.running),
+			LastRun:     runs[name],
+		}
+		if s.enabled {
+			status.NextRun = e.next
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+// runDue starts every job whose next run time has passed
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []*entry
+	for _, name := range s.names {
+		e := s.jobs[name]
+		if !e.next.IsZero() && !now.Before(e.next) {
+			due = append(due, e)
+			e.next = e.schedule.Next(now)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		if err := s.start(e, TriggerSchedule); err != nil {
+			s.logger.Infof("Skipping scheduled run of %s: %v", e.job.Name, err)
+		}
+	}
+}
+
+// start applies the concurrency policy, takes the lease and runs the job in the background.
+// The run is registered under the same lock as the policy check, before the lease is taken, since
+// the lease lets this instance in again and would not stop two local runs of a forbid job.
+func (s *Scheduler) start(e *entry, trigger string) error {
+	runCtx, cancel := context.WithCancel(s.baseCtx)
+	s.mu.Lock()
+	if len(e.running) > 0 {
+		switch e.job.Concurrency {
+		case Forbid:
+			s.mu.Unlock()
+			cancel()
+			return ErrJobRunning
+		case Replace:
+			for _, cancelRun := range e.running {
+				cancelRun()
+			}
+		}
+	}
+	e.seq++
+	id := e.seq
+	e.running[id] = cancel
+	s.mu.Unlock()
+
+	leaseCtx, cancelLease := context.WithTimeout(s.baseCtx, 10*time.Second)
+	acquired, err := s.store.AcquireJobLease(leaseCtx, e.job.Name, s.instance, e.job.Timeout+leaseMargin)
+	cancelLease()
+	if err != nil || !acquired {
+		// The lease was not taken, so only the reserved slot is given back
+		s.mu.Lock()
+		delete(e.running, id)
+		s.mu.Unlock()
+		cancel()
+		if err != nil {
+			return fmt.Errorf("error acquiring lease: %v", err)
+		}
+		return ErrLeaseHeld
+	}
+
+	s.wg.Add(1)
+	util.GoroutineWithRecovery(func() {
+		defer s.wg.Done()
+		defer s.finish(e, id)
+		timeoutCtx, cancelTimeout := context.WithTimeout(runCtx, e.job.Timeout)
+		defer cancelTimeout()
+		s.execute(timeoutCtx, e.job, trigger)
+	})
+	return nil
+}
+
+// execute runs the job and records its status before and after
+func (s *Scheduler) execute(ctx context.Context, job Job, trigger string) {
+	run := &model.JobRun{
+		Job:       job.Name,
+		Status:    model.JobRunning,
+		Instance:  s.instance,
+		Trigger:   trigger,
+		StartedAt: time.Now().UTC(),
+	}
+	s.saveRun(run)
+	s.logger.Infof("Job %s started (%s)", job.Name, trigger)
+
+	var summary string
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		summary, err = job.Run(ctx)
+	}()
+
+	run.FinishedAt = time.Now().UTC()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Summary = summary
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		run.Status = model.JobTimedOut
+		run.Error = fmt.Sprintf("timed out after %s", job.Timeout)
+	case err != nil:
+		run.Status = model.JobFailed
+		run.Error = err.Error()
+	default:
+		run.Status = model.JobSucceeded
+	}
+	s.saveRun(run)
+	if run.Error != "" {
+		s.logger.Errorf("Job %s %s: %s", job.Name, run.Status, run.Error)
+	} else {
+		s.logger.Infof("Job %s succeeded in %dms: %s", job.Name, run.DurationMs, summary)
+	}
+}
+
+// finish forgets a local run and releases the lease once no run of the job is left here
+func (s *Scheduler) finish(e *entry, id int64) {
+	s.mu.Lock()
+	if cancel, ok := e.running[id]; ok {
+		cancel()
+		delete(e.running, id)
+	}
+	idle := len(e.running) == 0
+	s.mu.Unlock()
+	if !idle {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.store.ReleaseJobLease(ctx, e.job.Name, s.instance); err != nil {
+		s.logger.Warnf("Failed to release lease of %s: %v", e.job.Name, err)
+	}
+}
+
+// saveRun stores the run status, logging rather than failing the job on error
+func (s *Scheduler) saveRun(run *model.JobRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.store.SaveJobRun(ctx, run); err != nil {
+		s.logger.Warnf("Failed to save status of %s: %v", run.Job, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/mediacache"
 	"rio-go-model/internal/services/database"
 	"rio-go-model/internal/util"
 
@@ -20,6 +21,16 @@ func NewAdminRepo(db *database.StoryDatabase, storage *database.StorageService)
 	return &AdminRepo{db: db, storage: storage, logger: util.GetLogger("admin_repo", configs.GetSettings())}
 }
 
+// deleteStoryFile deletes a file of a deleted story. Cached files can be shared with other stories,
+// so they are left to the orphan cleanup job.
+func (a *AdminRepo) deleteStoryFile(kind, path string) {
+	if path == "" || mediacache.Shared(path) {
+		return
+	}
+	a.logger.Infof("Deleting %s: %s", kind, path)
+	a.storage.DeleteFile(path)
+}
+
 func (a *AdminRepo) DeleteStoryByID(ctx context.Context, storyID string) error {
 	a.logger.Infof("Deleting story by ID: %s", storyID)
 	doc, err := a.db.GetClient().Collection(a.db.CollectionV2).Doc(storyID).Get(ctx)
@@ -32,13 +43,25 @@ func (a *AdminRepo) DeleteStoryByID(ctx context.Context, storyID string) error {
 	storyData := doc.Data()
 	audioUrl := storyData["audio_url"].(string)
 	imageUrl := storyData["image_url"].(string)
-	if audioUrl != "" {
-		a.logger.Infof("Deleting audio file: %s", audioUrl)
-		a.storage.DeleteFile(audioUrl)
+	a.deleteStoryFile("audio file", audioUrl)
+	a.deleteStoryFile("image file", imageUrl)
+	for _, key := range []string{"image_thumb_url", "image_medium_url"} {
+		if renditionUrl, ok := storyData[key].(string); ok {
+			a.deleteStoryFile("image rendition", renditionUrl)
+		}
 	}
-	if imageUrl != "" {
-		a.logger.Infof("Deleting image file: %s", imageUrl)
-		a.storage.DeleteFile(imageUrl)
+	if pages, ok := storyData["pages"].([]interface{}); ok {
+		for _, page := range pages {
+			pageData, ok := page.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, key := range []string{"image_url", "image_thumb_url", "image_medium_url"} {
+				if pageImageUrl, ok := pageData[key].(string); ok {
+					a.deleteStoryFile("page image file", pageImageUrl)
+				}
+			}
+		}
 	}
 	a.db.DeleteStory(ctx, storyID)
 	theme_id := storyData["theme_id"].(string)
@@ -0,0 +1,134 @@
+package translator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"rio-go-model/configs"
+)
+
+// Store keeps translations by content hash
+type Store interface {
+	Get(key string) (string, bool)
+	Set(key, translation string)
+}
+
+// CacheKey is the content hash a translation of text is stored under
+func CacheKey(text, source, target string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + target + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryStore is a Store holding at most a fixed number of translations, dropping the oldest first
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]string
+	order   []string
+	next    int
+}
+
+// NewMemoryStore creates a store of at most size translations; a size below 1 stores nothing
+func NewMemoryStore(size int) *MemoryStore {
+	size = max(size, 0)
+	return &MemoryStore{
+		entries: make(map[string]string, size),
+		order:   make([]string, size),
+	}
+}
+
+// Get returns the translation stored under key
+func (s *MemoryStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	translation, ok := s.entries[key]
+	return translation, ok
+}
+
+// Set stores a translation, dropping the oldest one when the store is full
+func (s *MemoryStore) Set(key, translation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.order) == 0 {
+		return
+	}
+	if _, ok := s.entries[key]; !ok {
+		delete(s.entries, s.order[s.next])
+		s.order[s.next] = key
+		s.next = (s.next + 1) % len(s.order)
+	}
+	s.entries[key] = translation
+}
+
+// Cached is a Translator that serves texts it has translated before from a Store
+// and sends only the rest, each distinct text once, to the Translator it wraps
+type Cached struct {
+	next  Translator
+	store Store
+}
+
+// NewCached wraps next with a cache in store
+func NewCached(next Translator, store Store) *Cached {
+	return &Cached{next: next, store: store}
+}
+
+// Translate translates texts, calling the wrapped Translator only for texts not in the store
+func (c *Cached) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	result := make([]string, len(texts))
+	keys := make([]string, len(texts))
+	var missing []string
+	pending := make(map[string][]int)
+	for i, text := range texts {
+		keys[i] = CacheKey(text, source, target)
+		if translation, ok := c.store.Get(keys[i]); ok {
+			result[i] = translation
+			continue
+		}
+		if _, ok := pending[keys[i]]; !ok {
+			missing = append(missing, text)
+		}
+		pending[keys[i]] = append(pending[keys[i]], i)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	translated, err := c.next.Translate(ctx, missing, source, target)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(missing) {
+		return nil, fmt.Errorf("got %d translations for %d texts", len(translated), len(missing))
+	}
+	for i, text := range missing {
+		key := CacheKey(text, source, target)
+		c.store.Set(key, translated[i])
+		for _, j := range pending[key] {
+			result[j] = translated[i]
+		}
+	}
+	return result, nil
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   Translator
+)
+
+// Shared returns the process-wide Google translator with a TranslationCacheSize cache, creating it on first use.
+// A failed start is retried on the next call.
+func Shared(ctx context.Context) (Translator, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared != nil {
+		return shared, nil
+	}
+	google, err := NewGoogleTranslator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shared = NewCached(google, NewMemoryStore(configs.GetSettings().TranslationCacheSize))
+	return shared, nil
+}
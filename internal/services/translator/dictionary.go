@@ -0,0 +1,74 @@
+package translator
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// wordPattern matches a word in any script, including its combining marks
+var wordPattern = regexp.MustCompile(`[\p{L}\p{M}\p{N}']+`)
+
+// Dictionary is an offline Translator for tests and fake mode.
+// A text with an entry of its own is replaced whole; otherwise each word with an entry is replaced
+// and everything else, including spacing and line breaks, is kept as it is.
+type Dictionary struct {
+	mu sync.RWMutex
+	// entries maps "source>target" to the phrases of that language pair
+	entries map[string]map[string]string
+}
+
+// NewDictionary creates an empty dictionary, which returns every text unchanged
+func NewDictionary() *Dictionary {
+	return &Dictionary{entries: make(map[string]map[string]string)}
+}
+
+// Add adds the translation of a phrase from source to target
+func (d *Dictionary) Add(source, target, phrase, translation string) *Dictionary {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pair := source + ">" + target
+	if d.entries[pair] == nil {
+		d.entries[pair] = make(map[string]string)
+	}
+	d.entries[pair][strings.ToLower(phrase)] = translation
+	return d
+}
+
+// Translate looks every text up in the dictionary. An empty source uses the entries of every source.
+func (d *Dictionary) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	lookup := func(phrase string) (string, bool) {
+		phrase = strings.ToLower(phrase)
+		for pair, entries := range d.entries {
+			from, to, _ := strings.Cut(pair, ">")
+			if to != target || (source != "" && from != source) {
+				continue
+			}
+			if translation, ok := entries[phrase]; ok {
+				return translation, true
+			}
+		}
+		return "", false
+	}
+
+	result := make([]string, len(texts))
+	for i, text := range texts {
+		if translation, ok := lookup(strings.TrimSpace(text)); ok {
+			result[i] = translation
+			continue
+		}
+		result[i] = wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+			if translation, ok := lookup(word); ok {
+				return translation
+			}
+			return word
+		})
+	}
+	return result, nil
+}
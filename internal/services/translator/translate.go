@@ -1,40 +1,121 @@
+// Package translator translates text between languages.
+// Languages are ISO 639-1 codes such as "en" or "te"; an empty source asks the backend to detect it.
 package translator
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"time"
 
 	"cloud.google.com/go/translate"
 	"golang.org/x/text/language"
 )
 
-type Translator struct {
+// Translator translates a batch of texts from one language to another.
+// The result has one translation per text, in the same order.
+type Translator interface {
+	Translate(ctx context.Context, texts []string, source, target string) ([]string, error)
+}
+
+// TranslateOne translates a single text with t
+func TranslateOne(ctx context.Context, t Translator, text, source, target string) (string, error) {
+	translated, err := t.Translate(ctx, []string{text}, source, target)
+	if err != nil {
+		return "", err
+	}
+	return translated[0], nil
+}
+
+// Limits of a single Google Translate request
+const (
+	maxBatchTexts = 128
+	maxBatchRunes = 30000
+)
+
+// GoogleTranslator translates with the Google Cloud Translation API
+type GoogleTranslator struct {
 	client *translate.Client
 	logger *log.Logger
 }
 
-func NewTranslator() *Translator {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// NewGoogleTranslator creates a Cloud Translation client with the default credentials
+func NewGoogleTranslator(ctx context.Context) (*GoogleTranslator, error) {
 	client, err := translate.NewClient(ctx)
 	if err != nil {
-		log.Fatalf("Failed to create translate client: %v", err)
+		return nil, fmt.Errorf("failed to create translate client: %v", err)
 	}
-	return &Translator{
+	return &GoogleTranslator{
 		client: client,
 		logger: log.New(log.Writer(), "Translator: ", log.LstdFlags),
-	}
+	}, nil
 }
 
-func (t *Translator) Translate(text string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	resp, err := t.client.Translate(ctx, []string{text}, language.English, nil)
+// Translate translates texts as plain text, so line breaks are kept.
+// Large batches are split into several requests.
+func (t *GoogleTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	targetTag, err := language.Parse(target)
 	if err != nil {
-		t.logger.Printf("client.Translate: %v", err)
-		return "", err
+		return nil, fmt.Errorf("invalid target language %q: %v", target, err)
+	}
+	options := &translate.Options{Format: translate.Text}
+	if source != "" {
+		if options.Source, err = language.Parse(source); err != nil {
+			return nil, fmt.Errorf("invalid source language %q: %v", source, err)
+		}
+	}
+
+	result := make([]string, 0, len(texts))
+	for _, batch := range batches(texts, maxBatchTexts, maxBatchRunes) {
+		resp, err := t.client.Translate(ctx, batch, targetTag, options)
+		if err != nil {
+			t.logger.Printf("client.Translate: %v", err)
+			return nil, err
+		}
+		if len(resp) != len(batch) {
+			return nil, fmt.Errorf("got %d translations for %d texts", len(resp), len(batch))
+		}
+		for _, translation := range resp {
+			result = append(result, translation.Text)
+		}
+	}
+	t.logger.Printf("Translated %d texts from %q to %q", len(texts), source, target)
+	return result, nil
+}
+
+// Close releases the client
+func (t *GoogleTranslator) Close() error {
+	return t.client.Close()
+}
+
+// batches splits texts into consecutive groups of at most maxTexts texts and, where possible, maxRunes runes.
+// A text longer than maxRunes gets a batch of its own.
+func batches(texts []string, maxTexts, maxRunes int) [][]string {
+	var result [][]string
+	start, runes := 0, 0
+	for i, text := range texts {
+		n := len([]rune(text))
+		if i > start && (i-start == maxTexts || runes+n > maxRunes) {
+			result = append(result, texts[start:i])
+			start, runes = i, 0
+		}
+		runes += n
+	}
+	if start < len(texts) {
+		result = append(result, texts[start:])
 	}
-	t.logger.Printf("Translated text: %s", resp[0].Text)
-	return resp[0].Text, nil
+	return result
+}
+
+// unavailable fails every translation with the error that kept the real translator from starting
+type unavailable struct {
+	err error
+}
+
+// Unavailable returns a Translator whose every call fails with err
+func Unavailable(err error) Translator {
+	return unavailable{err: err}
+}
+
+func (u unavailable) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	return nil, fmt.Errorf("translator unavailable: %v", u.err)
 }
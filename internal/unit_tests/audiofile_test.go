@@ -0,0 +1,69 @@
+package unittests
+
+import (
+	"testing"
+	"time"
+
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/fake"
+)
+
+// TestAudiofile_JoinMP3 checks that joined MP3 chunks drop their tags and VBR header frames
+// and that the duration is measured from the frames
+func TestAudiofile_JoinMP3(t *testing.T) {
+	chunk := fake.SilentMP3(time.Second)
+	single, err := audiofile.Probe(chunk)
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if single.MIMEType != "audio/mpeg" || single.Extension != "mp3" {
+		t.Errorf("got %q/%q, want audio/mpeg/mp3", single.MIMEType, single.Extension)
+	}
+
+	// An ID3v2 tag of 20 bytes and an Info frame, as every TTS response starts with
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x14"), make([]byte, 20)...)
+	info := append([]byte{}, chunk[:417]...)
+	copy(info[21:], "Info")
+	tagged := append(append(id3, info...), chunk...)
+
+	joined, err := audiofile.Join([][]byte{tagged, tagged})
+	if err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	if len(joined) != 2*len(chunk) {
+		t.Errorf("joined MP3 is %d bytes, want %d", len(joined), 2*len(chunk))
+	}
+	probed, err := audiofile.Probe(joined)
+	if err != nil {
+		t.Fatalf("probe of joined MP3 failed: %v", err)
+	}
+	if probed.Duration != 2*single.Duration {
+		t.Errorf("joined duration is %v, want %v", probed.Duration, 2*single.Duration)
+	}
+}
+
+// TestAudiofile_JoinWAV checks that WAV chunks are merged under one header
+func TestAudiofile_JoinWAV(t *testing.T) {
+	wav := fake.SilentWAV(time.Second, fake.WAVSampleRate)
+	joined, err := audiofile.Join([][]byte{wav, wav, wav})
+	if err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	if want := 44 + 3*fake.WAVSampleRate*2; len(joined) != want {
+		t.Errorf("joined WAV is %d bytes, want %d", len(joined), want)
+	}
+	info, err := audiofile.Probe(joined)
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if info.MIMEType != "audio/wav" || info.Duration != 3*time.Second {
+		t.Errorf("got %q lasting %v, want audio/wav lasting 3s", info.MIMEType, info.Duration)
+	}
+
+	if _, err := audiofile.Join([][]byte{wav, fake.SilentMP3(time.Second)}); err == nil {
+		t.Error("joining WAV and MP3 chunks should fail")
+	}
+	if _, err := audiofile.Probe([]byte("not audio at all")); err == nil {
+		t.Error("probing text should fail")
+	}
+}
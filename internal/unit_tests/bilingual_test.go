@@ -0,0 +1,37 @@
+package unittests
+
+import (
+	"testing"
+
+	"rio-go-model/internal/helpers"
+)
+
+// TestBilingual_SegmentAndValidate checks paragraph segmentation and the bilingual language check
+func TestBilingual_SegmentAndValidate(t *testing.T) {
+	paragraphs := helpers.SegmentParagraphs("  Once upon a time.\n\n\nA tree grew tall.  \r\nThe end.\n")
+	want := []string{"Once upon a time.", "A tree grew tall.", "The end."}
+	if len(paragraphs) != len(want) {
+		t.Fatalf("SegmentParagraphs() = %q, want %q", paragraphs, want)
+	}
+	for i := range want {
+		if paragraphs[i] != want[i] {
+			t.Errorf("paragraph %d = %q, want %q", i, paragraphs[i], want[i])
+		}
+	}
+
+	requests := []struct {
+		language, bilingual string
+		ok                  bool
+	}{
+		{"Telugu", "English", true},
+		{"English", "", true},
+		{"English", "English", false},
+		{"English", "Klingon", false},
+	}
+	for _, r := range requests {
+		err := (&helpers.MetadataRequest{Language: r.language, BilingualLanguage: r.bilingual}).Validate()
+		if (err == nil) != r.ok {
+			t.Errorf("Validate(%s with %q) = %v, want ok=%v", r.language, r.bilingual, err, r.ok)
+		}
+	}
+}
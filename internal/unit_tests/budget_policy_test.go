@@ -0,0 +1,78 @@
+package unittests
+
+import (
+	"testing"
+	"time"
+
+	"rio-go-model/configs"
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/util"
+)
+
+// TestBudgetPolicy_DefaultTiers checks the default policy keeps the historical thresholds
+func TestBudgetPolicy_DefaultTiers(t *testing.T) {
+	policy := configs.GetSettings().BudgetPolicy
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	resetAt := policy.NextReset(now)
+
+	tests := []struct {
+		name     string
+		provider string
+		usage    configs.BudgetUsage
+		tier     string
+		action   string
+		voice    string
+	}{
+		{"gemini below 60%", "gemini", configs.BudgetUsage{CostAmount: 59, BudgetAmount: 100, ResetAt: resetAt}, "normal", configs.BudgetActionAllow, ""},
+		{"gemini at 60%", "gemini", configs.BudgetUsage{CostAmount: 60, BudgetAmount: 100, ResetAt: resetAt}, "fallback", configs.BudgetActionFallback, ""},
+		{"gemini after reset", "gemini", configs.BudgetUsage{CostAmount: 95, BudgetAmount: 100, ResetAt: now.Add(-time.Hour)}, "normal", configs.BudgetActionAllow, ""},
+		{"audio at 25%", "audio", configs.BudgetUsage{Threshold: 0.25, ResetAt: resetAt}, "premium", configs.BudgetActionAllow, "Chirp3"},
+		{"audio at 50%", "audio", configs.BudgetUsage{Threshold: 0.5, ResetAt: resetAt}, "standard", configs.BudgetActionDowngrade, "Standard"},
+		{"audio at 88%", "audio", configs.BudgetUsage{Threshold: 0.88, ResetAt: resetAt}, "fallback", configs.BudgetActionFallback, "Standard"},
+		{"unknown provider", "other", configs.BudgetUsage{Threshold: 1}, "unconfigured", configs.BudgetActionAllow, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.provider, tt.usage, now)
+			if decision.Tier != tt.tier || decision.Action != tt.action || decision.Voice != tt.voice {
+				t.Errorf("Evaluate() = %s/%s/%q, want %s/%s/%q", decision.Tier, decision.Action, decision.Voice, tt.tier, tt.action, tt.voice)
+			}
+		})
+	}
+
+	if want := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC); !resetAt.Equal(want) {
+		t.Errorf("NextReset() = %v, want %v", resetAt, want)
+	}
+	if !policy.IgnoreAlert("gemini", 89, 100) || policy.IgnoreAlert("gemini", 90, 100) {
+		t.Errorf("IgnoreAlert() should ignore gemini alerts below 90%%")
+	}
+}
+
+// TestBudgetPolicy_TeluguAtFallback checks that a language without a fallback narrator still gets a
+// voice type Google TTS can read it with once audio spend reaches the fallback tier
+func TestBudgetPolicy_TeluguAtFallback(t *testing.T) {
+	if languages.Lookup("Telugu").FallbackAudio() {
+		t.Fatal("Telugu has a fallback narrator, this test no longer covers the Google TTS path")
+	}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	usage := configs.BudgetUsage{Threshold: 0.88, ResetAt: now.Add(time.Hour)}
+
+	decision := configs.GetSettings().BudgetPolicy.Evaluate("audio", usage, now)
+	if decision.Action != configs.BudgetActionFallback {
+		t.Fatalf("Evaluate() action = %s, want %s", decision.Action, configs.BudgetActionFallback)
+	}
+	if len(util.GetVoiceList(decision.Voice)) == 0 {
+		t.Errorf("fallback voice %q has no voices to read Telugu with", decision.Voice)
+	}
+
+	// A policy whose fallback tier names no voice keeps the voice of the tier before it
+	policy, err := configs.ParseBudgetPolicy([]byte(`{"reset_day": 2, "providers": {"audio": {"metric": "threshold", "tiers": [
+		{"name": "standard", "until": 0.5, "action": "downgrade", "voice": "Standard"},
+		{"name": "fallback", "action": "fallback"}]}}}`))
+	if err != nil {
+		t.Fatalf("ParseBudgetPolicy() failed: %v", err)
+	}
+	if decision := policy.Evaluate("audio", usage, now); decision.Voice != "Standard" {
+		t.Errorf("fallback tier voice = %q, want Standard from the tier before it", decision.Voice)
+	}
+}
@@ -0,0 +1,87 @@
+package unittests
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/cassette"
+)
+
+// TestCassette_RecordReplay records calls against a test server, checks secrets are removed and
+// replays them, error responses included, after the server is gone
+func TestCassette_RecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, `{"error": "model overloaded"}`, http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("echo: " + string(body)))
+	}))
+
+	recorder, err := cassette.Load(dir, "provider", configs.CassetteModeRecord)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	client := recorder.Client(0)
+	post := func(client *http.Client, path, body string) (int, string, error) {
+		req, _ := http.NewRequest("POST", server.URL+path+"?key=secret-key", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer hf_secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data), nil
+	}
+	if _, _, err := post(client, "/story", "once upon a time"); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+	if _, _, err := post(client, "/fail", "again"); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+	if _, err := cassette.Do(recorder, "tts.Synthesize", map[string]string{"text": "hi"}, func() ([]byte, error) {
+		return []byte{0xff, 0xfb}, nil
+	}); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	server.Close()
+
+	saved, _ := os.ReadFile(filepath.Join(dir, "provider.json"))
+	for _, secret := range []string{"hf_secret", "secret-key"} {
+		if strings.Contains(string(saved), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	replayer, err := cassette.Load(dir, "provider", configs.CassetteModeReplay)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	client = replayer.Client(0)
+	if status, body, err := post(client, "/story", "once upon a time"); err != nil || status != http.StatusOK || body != "echo: once upon a time" {
+		t.Errorf("replay = %d %q %v", status, body, err)
+	}
+	if status, body, _ := post(client, "/fail", "again"); status != http.StatusServiceUnavailable || !strings.Contains(body, "overloaded") {
+		t.Errorf("replayed error = %d %q", status, body)
+	}
+	if _, _, err := post(client, "/story", "a different story"); !errors.Is(err, cassette.ErrNotRecorded) {
+		t.Errorf("unrecorded request error = %v, want ErrNotRecorded", err)
+	}
+	audio, err := cassette.Do(replayer, "tts.Synthesize", map[string]string{"text": "hi"}, func() ([]byte, error) {
+		t.Errorf("replay called the provider")
+		return nil, nil
+	})
+	if err != nil || len(audio) != 2 || audio[0] != 0xff {
+		t.Errorf("replayed Do = %v, %v", audio, err)
+	}
+}
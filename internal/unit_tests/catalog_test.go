@@ -0,0 +1,84 @@
+package unittests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rio-go-model/configs/catalog"
+)
+
+// TestCatalog_LoadDir checks the shipped catalog files load, render and match the built-in lists
+func TestCatalog_LoadDir(t *testing.T) {
+	c, _, err := catalog.LoadDir("../../configs/catalog/files")
+	if err != nil {
+		t.Fatalf("LoadDir returned error: %v", err)
+	}
+	builtin := catalog.Builtin()
+	for name, l := range builtin.Languages {
+		loaded, ok := c.Languages[name]
+		if !ok {
+			t.Fatalf("catalog files have no %s", name)
+		}
+		if len(loaded.Topics.Chill) != len(l.Topics.Chill) {
+			t.Errorf("%s chill topics = %d, want %d", name, len(loaded.Topics.Chill), len(l.Topics.Chill))
+		}
+	}
+
+	_, prompt, ok, err := c.Language("English").RenderPrompt(catalog.PlanetProtector, catalog.StoryVars{Topic: "coral reefs", Country: "India", City: "Chennai"})
+	if err != nil || !ok {
+		t.Fatalf("RenderPrompt() = %v, %v", ok, err)
+	}
+	for _, want := range []string{"coral reefs", "India", "Chennai"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("rendered prompt does not mention %q", want)
+		}
+	}
+	if c.Language("Klingon") != c.Language("English") {
+		t.Errorf("unknown languages should fall back to English")
+	}
+}
+
+// TestCatalog_Invalid checks that broken catalogs are rejected
+func TestCatalog_Invalid(t *testing.T) {
+	base := `language: English
+topics:
+  planet_protector: [rivers]
+  mindful:
+    Hindu: [Gita]
+  chill: [clouds]
+preferences:
+  HAPPY: Keep it happy.
+`
+	cases := map[string]string{
+		"missing placeholder": base + `prompts:
+  planet_protector: {system: s, prompt: "A story about {{.Topic}}"}
+  mindful: {system: s, prompt: "{{.Topic}} {{.Religion}}"}
+  chill: {system: s, prompt: "{{.Topic}}"}
+`,
+		"bad template": base + `topic_prompts:
+  planet_protector: "{{.Items"
+  mindful: "{{.Items}} {{.Religion}} {{.Count}}"
+  chill: "{{.Items}} {{.Count}}"
+`,
+	}
+	for name, content := range cases {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "english.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := catalog.LoadDir(dir); err == nil {
+			t.Errorf("%s: LoadDir should fail", name)
+		}
+	}
+
+	// Telugu must offer the same religions as English
+	dir := t.TempDir()
+	telugu := strings.Replace(strings.Replace(base, "English", "Telugu", 1), "Hindu", "Christian", 1)
+	os.WriteFile(filepath.Join(dir, "english.yaml"), []byte(base), 0o644)
+	os.WriteFile(filepath.Join(dir, "telugu.yaml"), []byte(telugu), 0o644)
+	if _, _, err := catalog.LoadDir(dir); err == nil || !strings.Contains(err.Error(), "religions") {
+		t.Errorf("mismatched religions: LoadDir error = %v", err)
+	}
+}
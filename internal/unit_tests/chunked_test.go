@@ -0,0 +1,69 @@
+package unittests
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rio-go-model/internal/helpers/chunked"
+)
+
+// TestChunked_OrderAndRetry checks that chunks come back in order, within the concurrency limit,
+// and that a failed chunk is retried on its own
+func TestChunked_OrderAndRetry(t *testing.T) {
+	var inFlight, most, failures int32
+	options := chunked.Options{Provider: "test-order", Concurrency: 2, Attempts: 2, Timeout: 5 * time.Second}
+	audio, err := chunked.Synthesize(context.Background(), options, 6, func(ctx context.Context, i int) ([]byte, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for m := atomic.LoadInt32(&most); n > m && !atomic.CompareAndSwapInt32(&most, m, n); m = atomic.LoadInt32(&most) {
+		}
+		time.Sleep(time.Duration(6-i) * time.Millisecond)
+		if i == 3 && atomic.AddInt32(&failures, 1) == 1 {
+			return nil, fmt.Errorf("transient")
+		}
+		return []byte{byte(i)}, nil
+	})
+	if err != nil {
+		t.Fatalf("Synthesize failed: %v", err)
+	}
+	for i, chunk := range audio {
+		if len(chunk) != 1 || chunk[0] != byte(i) {
+			t.Errorf("chunk %d holds %v", i, chunk)
+		}
+	}
+	if most > 2 {
+		t.Errorf("%d chunks ran at once, want at most 2", most)
+	}
+	for _, stats := range chunked.Snapshot() {
+		if stats.Provider == "test-order" && (stats.Chunks != 7 || stats.Retries != 1) {
+			t.Errorf("got %d chunk calls and %d retries, want 7 and 1", stats.Chunks, stats.Retries)
+		}
+	}
+}
+
+// TestChunked_Failure checks that a chunk failing every attempt, or the timeout, fails the synthesis
+func TestChunked_Failure(t *testing.T) {
+	options := chunked.Options{Provider: "test-failure", Concurrency: 2, Attempts: 3}
+	_, err := chunked.Synthesize(context.Background(), options, 4, func(ctx context.Context, i int) ([]byte, error) {
+		if i == 2 {
+			return nil, fmt.Errorf("bad input")
+		}
+		return []byte{1}, nil
+	})
+	if err == nil {
+		t.Error("a chunk that always fails did not fail the synthesis")
+	}
+
+	options.Timeout = 20 * time.Millisecond
+	started := time.Now()
+	_, err = chunked.Synthesize(context.Background(), options, 4, func(ctx context.Context, i int) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err == nil || time.Since(started) > time.Second {
+		t.Errorf("timeout did not stop the synthesis: %v after %v", err, time.Since(started))
+	}
+}
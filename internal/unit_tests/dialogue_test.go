@@ -0,0 +1,62 @@
+package unittests
+
+import (
+	"testing"
+
+	"rio-go-model/internal/helpers/dialogue"
+)
+
+// TestDialogue_SplitAndAttribute checks that quotes are separated from narration and attributed
+// from their dialogue tags, leaving pronouns unattributed
+func TestDialogue_SplitAndAttribute(t *testing.T) {
+	lines := dialogue.Split(`Pip looked up. "Is it raining?" asked Pip. Mira said, “Only a little.” "Come inside," she smiled.`)
+	dialogue.Attribute(lines)
+
+	want := []dialogue.Line{
+		{Speaker: dialogue.Narrator, Text: "Pip looked up."},
+		{Speaker: "Pip", Text: "Is it raining?", Quoted: true},
+		{Speaker: dialogue.Narrator, Text: "asked Pip. Mira said,"},
+		{Speaker: "Mira", Text: "Only a little.", Quoted: true},
+		{Speaker: "", Text: "Come inside,", Quoted: true},
+		{Speaker: dialogue.Narrator, Text: "she smiled."},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+
+	speakers := dialogue.ParseSpeakers("1: Pip\n2. **Mira**\nnot an answer")
+	if speakers[1] != "Pip" || speakers[2] != "Mira" || len(speakers) != 2 {
+		t.Errorf("ParseSpeakers = %v", speakers)
+	}
+}
+
+// TestDialogue_Cast checks that characters get distinct voices that stay the same between stories
+func TestDialogue_Cast(t *testing.T) {
+	voices := []string{"voice-a", "voice-b", "voice-c", "voice-d"}
+	lines := []dialogue.Line{
+		{Speaker: dialogue.Narrator},
+		{Speaker: "Pip", Quoted: true},
+		{Speaker: "Mira", Quoted: true},
+	}
+	cast := dialogue.Cast(lines, voices, "voice-a")
+	if cast[dialogue.Narrator] != "voice-a" {
+		t.Errorf("narrator voice = %q, want voice-a", cast[dialogue.Narrator])
+	}
+	seen := map[string]bool{}
+	for speaker, voice := range cast {
+		if seen[voice] {
+			t.Errorf("%s shares voice %q", speaker, voice)
+		}
+		seen[voice] = true
+	}
+
+	again := dialogue.Cast(lines[:2], voices, "voice-a")
+	if again["Pip"] != cast["Pip"] {
+		t.Errorf("Pip got %q in one story and %q in another", cast["Pip"], again["Pip"])
+	}
+}
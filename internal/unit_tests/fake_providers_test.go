@@ -0,0 +1,38 @@
+package unittests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"rio-go-model/internal/helpers/fake"
+	"rio-go-model/internal/helpers/imaging"
+)
+
+// TestFakeMedia checks that fake images pass validation and fake audio is well formed and deterministic
+func TestFakeMedia(t *testing.T) {
+	images := fake.Images{}
+	png := images.Generate("a brave turtle", 42)
+	if _, err := imaging.Process(png); err != nil {
+		t.Fatalf("placeholder image rejected: %v", err)
+	}
+	if !bytes.Equal(png, images.Generate("a brave turtle", 42)) {
+		t.Error("placeholder image is not deterministic")
+	}
+	if bytes.Equal(png, images.Generate("a sleepy moon", 42)) {
+		t.Error("different prompts gave the same image")
+	}
+
+	mp3 := fake.SilentMP3(2 * time.Second)
+	if len(mp3) == 0 || mp3[0] != 0xFF || mp3[1]&0xE0 != 0xE0 {
+		t.Errorf("silent MP3 does not start with a frame sync")
+	}
+
+	wav := fake.SilentWAV(time.Second, fake.WAVSampleRate)
+	if string(wav[:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		t.Errorf("silent WAV has no RIFF header")
+	}
+	if want := 44 + fake.WAVSampleRate*2; len(wav) != want {
+		t.Errorf("silent WAV is %d bytes, want %d", len(wav), want)
+	}
+}
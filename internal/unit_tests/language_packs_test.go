@@ -0,0 +1,47 @@
+package unittests
+
+import (
+	"strings"
+	"testing"
+
+	"rio-go-model/configs"
+	"rio-go-model/configs/catalog"
+	"rio-go-model/configs/languages"
+	"rio-go-model/internal/util"
+)
+
+// TestLanguagePacks checks the registered packs, language validation and the TTS locales they map to
+func TestLanguagePacks(t *testing.T) {
+	for _, name := range []string{"English", "Telugu"} {
+		if err := languages.Validate(name); err != nil {
+			t.Errorf("Validate(%q) returned error: %v", name, err)
+		}
+	}
+	err := languages.Validate("Hindi")
+	if err == nil || !strings.Contains(err.Error(), "English, Telugu") {
+		t.Errorf("Validate(Hindi) = %v, want an error listing the supported languages", err)
+	}
+	if languages.Lookup("Hindi").Name() != languages.Default {
+		t.Errorf("unsupported languages should fall back to %s", languages.Default)
+	}
+
+	locales := []struct{ language, theme, want string }{
+		{"Telugu", "1", "te-IN"},
+		{"English", "2", "en-IN"},
+		{"English", "1", "en-US"},
+	}
+	for _, l := range locales {
+		if got := util.LanguageMapper(l.language, l.theme); got != l.want {
+			t.Errorf("LanguageMapper(%q, %q) = %q, want %q", l.language, l.theme, got, l.want)
+		}
+	}
+
+	if err := catalog.Builtin().Validate(); err != nil {
+		t.Errorf("built-in catalog is invalid: %v", err)
+	}
+	_, err = configs.ParsePromptRegistry([]byte(`{"templates": [{"name": "chill", "language": "Hindi", "version": "v2",
+		"system": "You are a calm storyteller", "prompt": "Tell a gentle story about {{.Topic}}."}]}`))
+	if err == nil {
+		t.Errorf("a template in an unsupported language should be rejected")
+	}
+}
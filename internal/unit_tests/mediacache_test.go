@@ -0,0 +1,150 @@
+package unittests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"rio-go-model/internal/helpers/mediacache"
+)
+
+// memoryStorage is a blob store held in a map
+type memoryStorage map[string][]byte
+
+func (m memoryStorage) UploadFileAtPath(fileData []byte, path string) (string, error) {
+	m[path] = fileData
+	return path, nil
+}
+
+func (m memoryStorage) TouchBlob(path string) (bool, error) {
+	_, ok := m[path]
+	return ok, nil
+}
+
+func (m memoryStorage) ReadBlob(path string) ([]byte, error) {
+	data, ok := m[path]
+	if !ok {
+		return nil, fmt.Errorf("no blob at %s", path)
+	}
+	return data, nil
+}
+
+// TestMediaCache_Key checks that every part, and where one part ends, changes the key
+func TestMediaCache_Key(t *testing.T) {
+	key := mediacache.Key("google-tts", "Chirp3-HD", "en-US", "Once upon a time")
+	if key != mediacache.Key("google-tts", "Chirp3-HD", "en-US", "Once upon a time") {
+		t.Error("the same parts gave different keys")
+	}
+	for _, other := range []string{
+		mediacache.Key("google-tts", "Standard", "en-US", "Once upon a time"),
+		mediacache.Key("google-tts", "Chirp3-HD", "en-IN", "Once upon a time"),
+		mediacache.Key("google-tts", "Chirp3-HD", "en-US", "Once upon a time."),
+		mediacache.Key("google-tts", "Chirp3-HD", "en-USOnce upon a time"),
+	} {
+		if other == key {
+			t.Errorf("different parts gave the same key %s", key)
+		}
+	}
+}
+
+// TestMediaCache_PutGet checks a miss, a hit after Put at a content-addressed path, and that an
+// index entry whose file is gone counts as a miss
+func TestMediaCache_PutGet(t *testing.T) {
+	ctx := context.Background()
+	storage := memoryStorage{}
+	cache := mediacache.New(mediacache.NewMemoryIndex(time.Hour), storage, time.Hour)
+	key := mediacache.Key("google-tts", "story")
+
+	if entry, _, err := cache.Get(ctx, key); entry != nil || err != nil {
+		t.Fatalf("empty cache returned %v, %v", entry, err)
+	}
+	err := cache.Put(ctx, key, mediacache.KindAudio, "google-tts", map[string][]byte{"audio.mp3": []byte("mp3")}, map[string]string{"voice": "Chirp3-HD"})
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	path := mediacache.Path(mediacache.KindAudio, "audio.mp3", []byte("mp3"))
+	if _, ok := storage[path]; !ok {
+		t.Errorf("audio not stored at its content-addressed path %s: %v", path, storage)
+	}
+	entry, files, err := cache.Get(ctx, key)
+	if err != nil || entry == nil || string(files["audio.mp3"]) != "mp3" || entry.Meta["voice"] != "Chirp3-HD" {
+		t.Errorf("Get after Put returned %v, %q, %v", entry, files, err)
+	}
+
+	delete(storage, path)
+	if entry, _, err := cache.Get(ctx, key); entry != nil || err == nil {
+		t.Errorf("entry with a missing file returned %v, %v", entry, err)
+	}
+}
+
+// TestMediaCache_ExtensionlessFile checks that a cached file stored without an extension is read back
+// under its own name
+func TestMediaCache_ExtensionlessFile(t *testing.T) {
+	ctx := context.Background()
+	cache := mediacache.New(mediacache.NewMemoryIndex(time.Hour), memoryStorage{}, time.Hour)
+	key := mediacache.Key("flux", "prompt")
+	if err := cache.Put(ctx, key, mediacache.KindImage, "flux", map[string][]byte{"image": []byte("png")}, nil); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	_, files, err := cache.Get(ctx, key)
+	if err != nil || string(files["image"]) != "png" {
+		t.Fatalf("Get returned %q, %v", files, err)
+	}
+	for name, want := range map[string]string{"image": "image", "audio.mp3": "audio", "timing.json": "timing"} {
+		if got := mediacache.BaseName(name); got != want {
+			t.Errorf("BaseName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestMediaCache_StoreShared checks that a story storing the bytes of a cached file gets the cached
+// path back instead of a copy, and that the path is recognized as shared
+func TestMediaCache_StoreShared(t *testing.T) {
+	ctx := context.Background()
+	storage := memoryStorage{}
+	cache := mediacache.New(mediacache.NewMemoryIndex(time.Hour), storage, time.Hour)
+	key := mediacache.Key("flux", "a fox")
+	if err := cache.Put(ctx, key, mediacache.KindImage, "flux", map[string][]byte{"image.png": []byte("png")}, nil); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	entry, files, _ := cache.Get(ctx, key)
+
+	path, err := mediacache.Store(storage, mediacache.KindImage, "original.png", files["image.png"])
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if path != entry.Files["image.png"] || len(storage) != 1 {
+		t.Errorf("Store put the cached image at %s with %d files stored, want the cached path %s", path, len(storage), entry.Files["image.png"])
+	}
+	if !mediacache.Shared(path) || mediacache.Shared("images/abc/original.png") {
+		t.Error("Shared() does not tell cached files from story files")
+	}
+}
+
+// TestMediaCache_Expiry checks that an entry unused for the TTL misses and that a hit keeps it alive
+func TestMediaCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	index := mediacache.NewMemoryIndex(time.Hour)
+	cache := mediacache.New(index, memoryStorage{}, time.Hour)
+	stale := &mediacache.Entry{Key: "stale", LastUsedAt: time.Now().Add(-2 * time.Hour)}
+	fresh := &mediacache.Entry{Key: "fresh", LastUsedAt: time.Now().Add(-30 * time.Minute)}
+	index.Put(ctx, stale)
+	index.Put(ctx, fresh)
+
+	if entry, _, err := cache.Get(ctx, "stale"); entry != nil || err != nil {
+		t.Errorf("expired entry returned %v, %v, want a miss", entry, err)
+	}
+	if entry, _, _ := cache.Get(ctx, "fresh"); entry == nil {
+		t.Fatal("live entry missed")
+	}
+	if time.Since(fresh.LastUsedAt) > time.Minute {
+		t.Error("a hit did not update the last use of the entry")
+	}
+
+	// Adding an entry drops the expired ones from memory
+	index.Put(ctx, &mediacache.Entry{Key: "new", LastUsedAt: time.Now()})
+	if entry, _ := index.Get(ctx, "stale"); entry != nil {
+		t.Error("expired entry is still held in memory")
+	}
+}
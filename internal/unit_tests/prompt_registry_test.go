@@ -0,0 +1,65 @@
+package unittests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"rio-go-model/configs"
+)
+
+// TestPromptRegistry_Choose checks template rendering and stable weighted experiment assignment
+func TestPromptRegistry_Choose(t *testing.T) {
+	registry, err := configs.ParsePromptRegistry([]byte(`{
+		"templates": [{"name": "chill", "language": "English", "version": "v2",
+			"system": "You are a calm storyteller", "prompt": "Tell a gentle story about {{.Topic}}."}],
+		"experiments": [{"name": "chill-v2", "template": "chill", "unit": "user",
+			"variants": [{"version": "v1", "weight": 1}, {"version": "v2", "weight": 1}]}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePromptRegistry returned error: %v", err)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		choice := registry.Choose(configs.PromptChill, "English", email, "story-a")
+		if again := registry.Choose(configs.PromptChill, "English", email, "story-b"); again.Template.Version != choice.Template.Version {
+			t.Fatalf("user %s was assigned %s and then %s", email, choice.Template.Version, again.Template.Version)
+		}
+		if choice.Experiment != "chill-v2" {
+			t.Errorf("choice experiment = %q, want chill-v2", choice.Experiment)
+		}
+		counts[choice.Template.Version]++
+	}
+	if counts["v1"] < 60 || counts["v2"] < 60 {
+		t.Errorf("assignment is not close to 50/50: %v", counts)
+	}
+
+	// Telugu has no v2, so it stays on the built-in prompt
+	for i := 0; i < 20; i++ {
+		if choice := registry.Choose(configs.PromptChill, "Telugu", fmt.Sprintf("user%d@example.com", i), ""); choice.Template.Version != configs.BuiltinPromptVersion {
+			t.Errorf("Telugu choice = %s, want %s", choice.Template.Version, configs.BuiltinPromptVersion)
+		}
+	}
+
+	_, prompt, err := registry.Resolve(configs.PromptChill, "English", "v2").Render(configs.PromptVars{Topic: "the sleepy moon"})
+	if err != nil || prompt != "Tell a gentle story about the sleepy moon." {
+		t.Errorf("Render() = %q, %v", prompt, err)
+	}
+	if _, prompt, _ := registry.Default(configs.PromptChill, "English").Render(configs.PromptVars{Topic: "gratitude"}); !strings.Contains(prompt, "gratitude") {
+		t.Errorf("built-in prompt does not mention the topic")
+	}
+
+	invalid := []string{
+		`{"defaults": {"chill": "v3"}}`,
+		`{"templates": [{"name": "chill", "language": "English", "version": "v1", "prompt": "x"}]}`,
+		`{"templates": [{"name": "chill", "language": "English", "version": "v2", "prompt": "{{.Missing}}"}]}`,
+		`{"experiments": [{"name": "e", "template": "chill", "unit": "user", "variants": [{"version": "v1", "weight": 1}, {"version": "v9", "weight": 1}]}]}`,
+	}
+	for _, raw := range invalid {
+		if _, err := configs.ParsePromptRegistry([]byte(raw)); err == nil {
+			t.Errorf("ParsePromptRegistry(%s) should fail", raw)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package unittests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rio-go-model/internal/helpers/fake"
+	"rio-go-model/internal/helpers/readalong"
+)
+
+// TestReadAlong_SplitAndRebase checks that chunks fit the byte limit and that the timepoints of a
+// later chunk are moved along by the audio before it
+func TestReadAlong_SplitAndRebase(t *testing.T) {
+	text := strings.Repeat("Pip & Mira <sang> softly. ", 40)
+	chunks := readalong.Split(text, 600)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the text split", len(chunks))
+	}
+	next := 0
+	for i, chunk := range chunks {
+		if len(chunk.SSML) > 600 {
+			t.Errorf("chunk %d is %d bytes, over the limit", i, len(chunk.SSML))
+		}
+		if strings.Contains(chunk.SSML, "<sang>") {
+			t.Errorf("chunk %d does not escape the text", i)
+		}
+		for _, word := range chunk.Words {
+			if word.Index != next {
+				t.Fatalf("word index %d, want %d", word.Index, next)
+			}
+			next++
+		}
+	}
+
+	var builder readalong.Builder
+	first := []readalong.Timepoint{{Mark: "w0", Seconds: 0.1}, {Mark: "w1", Seconds: 0.5}}
+	builder.AddChunk(readalong.Chunk{Words: []readalong.Word{{Index: 0, Text: "Once"}, {Index: 1, Text: "upon"}}}, first, time.Second)
+	second := []readalong.Timepoint{{Mark: "w2", Seconds: 0.2}}
+	builder.AddChunk(readalong.Chunk{Words: []readalong.Word{{Index: 2, Text: "a"}, {Index: 3, Text: "time"}}}, second, 2*time.Second)
+	timing := builder.Timing()
+
+	if timing.Duration != 3 || len(timing.Words) != 3 {
+		t.Fatalf("got %v seconds and %d words, want 3 seconds and 3 words", timing.Duration, len(timing.Words))
+	}
+	if word := timing.Words[1]; word.Start != 0.5 || word.End != 1 {
+		t.Errorf("upon spans %v-%v, want 0.5-1", word.Start, word.End)
+	}
+	if word := timing.Words[2]; word.Start != 1.2 || word.End != 3 {
+		t.Errorf("a spans %v-%v, want 1.2-3", word.Start, word.End)
+	}
+	if vtt := string(timing.WebVTT()); !strings.HasPrefix(vtt, "WEBVTT\n") || !strings.Contains(vtt, "00:00:01.200 --> 00:00:03.000\na\n") {
+		t.Errorf("unexpected WebVTT:\n%s", vtt)
+	}
+}
+
+// TestReadAlong_FakeSpeech checks that the fake provider times every word in order
+func TestReadAlong_FakeSpeech(t *testing.T) {
+	_, timing, _, err := fake.NewSpeech().GenerateReadAlongAdapter("Once upon a time there was a sleepy moon.", "English", "", "")
+	if err != nil {
+		t.Fatalf("read-along failed: %v", err)
+	}
+	if len(timing.Words) != 9 {
+		t.Fatalf("got %d words, want 9", len(timing.Words))
+	}
+	for i := 1; i < len(timing.Words); i++ {
+		if timing.Words[i].Start <= timing.Words[i-1].Start {
+			t.Errorf("word %d starts at %v, not after the word before", i, timing.Words[i].Start)
+		}
+	}
+}
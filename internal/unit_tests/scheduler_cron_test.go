@@ -0,0 +1,119 @@
+package unittests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rio-go-model/internal/model"
+	"rio-go-model/internal/services/scheduler"
+)
+
+// TestParseSchedule_Next checks the next run time of common job schedules
+func TestParseSchedule_Next(t *testing.T) {
+	from := time.Date(2025, 3, 10, 2, 30, 0, 0, time.UTC) // a Monday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 2 * * *", time.Date(2025, 3, 11, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 10, 2, 45, 0, 0, time.UTC)},
+		{"0 5 * * 0", time.Date(2025, 3, 16, 5, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 9 1-5 * 1-5", time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 3, 10, 3, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := scheduler.ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) returned error: %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 0 * 13 *", "*/0 * * * *"} {
+		if _, err := scheduler.ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", spec)
+		}
+	}
+}
+
+// reentrantLeaseStore grants the lease to its holder again, like the Firestore store, after a delay
+type reentrantLeaseStore struct {
+	mu     sync.Mutex
+	holder string
+}
+
+func (s *reentrantLeaseStore) AcquireJobLease(ctx context.Context, job, holder string, ttl time.Duration) (bool, error) {
+	time.Sleep(20 * time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.holder != "" && s.holder != holder {
+		return false, nil
+	}
+	s.holder = holder
+	return true, nil
+}
+
+func (s *reentrantLeaseStore) ReleaseJobLease(ctx context.Context, job, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.holder == holder {
+		s.holder = ""
+	}
+	return nil
+}
+
+func (s *reentrantLeaseStore) SaveJobRun(ctx context.Context, run *model.JobRun) error { return nil }
+
+func (s *reentrantLeaseStore) GetJobRuns(ctx context.Context) (map[string]*model.JobRun, error) {
+	return nil, nil
+}
+
+// TestScheduler_ForbidRunsOnce checks that runs of a forbid job started together on one instance
+// run it once, even though the lease lets the instance in again
+func TestScheduler_ForbidRunsOnce(t *testing.T) {
+	// The scheduler's logger writes to logs/ under the working directory
+	t.Chdir(t.TempDir())
+	s := scheduler.NewScheduler(&reentrantLeaseStore{})
+	var runs int32
+	release := make(chan struct{})
+	err := s.Register(scheduler.Job{
+		Name:     "forbid",
+		Schedule: "@hourly",
+		Timeout:  time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return "", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var started int32
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.RunNow("forbid") == nil {
+				atomic.AddInt32(&started, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	s.Stop()
+
+	if started != 1 || runs != 1 {
+		t.Errorf("started %d runs and %d ran, want 1", started, runs)
+	}
+}
@@ -0,0 +1,40 @@
+package unittests
+
+import (
+	"strings"
+	"testing"
+
+	"rio-go-model/internal/helpers/scoring"
+)
+
+// TestScoring checks the quality and safety scorers on clean and problematic text
+func TestScoring(t *testing.T) {
+	paragraph := strings.Repeat("Pip the little bird looked at the river and smiled. ", 8)
+	story := paragraph + "\n\n" + paragraph
+	if result := scoring.StoryQuality(story, "English"); !result.Passed {
+		t.Errorf("StoryQuality() = %s", result)
+	}
+	if result := scoring.StoryQuality(story, "Telugu"); result.Passed {
+		t.Errorf("an English story should fail the Telugu script check")
+	}
+	if result := scoring.StoryQuality(story+" {{.Topic}}", "English"); result.Passed {
+		t.Errorf("a story with template markers should fail")
+	}
+
+	if result := scoring.Safety(story, "English"); !result.Passed {
+		t.Errorf("Safety() = %s", result)
+	}
+	// Whole words only: "skill" must not match "kill"
+	if result := scoring.Safety("Mira showed great skill.", "English"); !result.Passed {
+		t.Errorf("Safety() flagged a word inside another word: %s", result)
+	}
+	for _, text := range []string{"The hunter had a gun.", "Visit https://example.com", "రాజు రక్తం చూశాడు"} {
+		if result := scoring.Safety(text, "Telugu"); result.Passed {
+			t.Errorf("Safety(%q) should fail", text)
+		}
+	}
+
+	if result := scoring.TopicsQuality([]string{"The Brave Turtle", "the brave turtle"}, 3, "English"); result.Passed || len(result.Issues) != 2 {
+		t.Errorf("TopicsQuality() issues = %v, want count and duplicate", result.Issues)
+	}
+}
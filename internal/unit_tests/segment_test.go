@@ -0,0 +1,46 @@
+package unittests
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"rio-go-model/internal/util/segment"
+)
+
+// TestSegment_Sentences checks punctuation, quotes, abbreviations, decimals, ellipses and dandas
+func TestSegment_Sentences(t *testing.T) {
+	tests := map[string][]string{
+		`"Look out!" said Pip. Mr. Fox ran away... Did it rain? It cost 3.5 coins.`: {
+			`"Look out!" said Pip.`, "Mr. Fox ran away...", "Did it rain?", "It cost 3.5 coins.",
+		},
+		"రాముడు వచ్చాడు। సీత నవ్వింది॥ అది ఏమిటి?": {
+			"రాముడు వచ్చాడు।", "సీత నవ్వింది॥", "అది ఏమిటి?",
+		},
+		"A title without a stop\n\nWait… what? So do I.": {
+			"A title without a stop", "Wait… what?", "So do I.",
+		},
+	}
+	for text, want := range tests {
+		if got := segment.Sentences(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("Sentences(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+// TestSegment_Chunks checks that chunks fit the byte limit without cutting a character
+func TestSegment_Chunks(t *testing.T) {
+	text := "ఒకప్పుడు ఒక చిన్న కుందేలు ఉండేది. అది అడవిలో ఆడుకుంది! సూపర్కాలిఫ్రాజిలిస్టిక్"
+	chunks := segment.Chunks(text, 40)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the text split", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 40 {
+			t.Errorf("chunk %d is %d bytes", i, len(chunk))
+		}
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %d is not valid UTF-8: %q", i, chunk)
+		}
+	}
+}
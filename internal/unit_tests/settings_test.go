@@ -0,0 +1,37 @@
+package unittests
+
+import (
+	"testing"
+
+	"rio-go-model/configs"
+)
+
+// TestReadSettings_RejectsInvalidEnv checks that a mistyped or out-of-range environment value fails
+// when the settings are loaded, instead of silently running with another mode
+func TestReadSettings_RejectsInvalidEnv(t *testing.T) {
+	if _, err := configs.ReadSettings(); err != nil {
+		t.Fatalf("default settings rejected: %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"PROVIDER_MODE", "fkae"},
+		{"CASSETTE_MODE", "replya"},
+		{"TTS_CONCURRENCY", "0"},
+		{"TRANSLATION_CACHE_SIZE", "-1"},
+		{"MEDIA_CACHE", "redis"},
+		{"MEDIA_CACHE_TTL_DAYS", "0"},
+		{"SOUNDSCAPE_VOLUME", "150"},
+		{"DEFAULT_STORY_TO_GENERATE", "-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := configs.ReadSettings(); err == nil {
+				t.Errorf("%s=%s was accepted", tt.key, tt.value)
+			}
+		})
+	}
+}
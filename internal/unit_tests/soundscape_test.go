@@ -0,0 +1,75 @@
+package unittests
+
+import (
+	"testing"
+	"time"
+
+	"rio-go-model/internal/helpers/audiofile"
+	"rio-go-model/internal/helpers/fake"
+	"rio-go-model/internal/helpers/soundscape"
+)
+
+// TestSoundscape_Mix checks that the loop plays on past the narration, fades in from silence and
+// stays under the requested volume
+func TestSoundscape_Mix(t *testing.T) {
+	narration := fake.SilentWAV(3*time.Second, fake.WAVSampleRate)
+	for _, name := range soundscape.Names() {
+		mixed, err := soundscape.Mix(narration, name, 0.2)
+		if err != nil {
+			t.Fatalf("mixing %s failed: %v", name, err)
+		}
+		info, err := audiofile.Probe(mixed)
+		if err != nil {
+			t.Fatalf("probe of %s mix failed: %v", name, err)
+		}
+		if info.Duration != 7*time.Second {
+			t.Errorf("%s mix lasts %v, want 7s", name, info.Duration)
+		}
+		pcm, err := audiofile.DecodePCM(mixed)
+		if err != nil {
+			t.Fatalf("decoding %s mix failed: %v", name, err)
+		}
+		if pcm.SampleRate != fake.WAVSampleRate || pcm.Channels != 1 {
+			t.Errorf("%s mix is %d Hz with %d channels, want the narration's format", name, pcm.SampleRate, pcm.Channels)
+		}
+		if pcm.Samples[0] != 0 {
+			t.Errorf("%s mix starts at %d, want a fade in from 0", name, pcm.Samples[0])
+		}
+		var peak int16
+		for _, sample := range pcm.Samples {
+			if sample < 0 {
+				sample = -sample
+			}
+			if sample > peak {
+				peak = sample
+			}
+		}
+		if peak == 0 || float64(peak) > 0.2*32767 {
+			t.Errorf("%s mix peaks at %d, want audible but under %v", name, peak, 0.2*32767)
+		}
+	}
+
+	if _, err := soundscape.Mix(fake.SilentMP3(time.Second), "rain", 0.2); err == nil {
+		t.Error("mixing MP3 narration succeeded, want an error")
+	}
+	if _, err := soundscape.Mix(narration, "thunder", 0.2); err == nil {
+		t.Error("mixing an unknown loop succeeded, want an error")
+	}
+}
+
+// TestSoundscape_ForTheme checks the theme matching on the theme ids StoryHelper receives
+func TestSoundscape_ForTheme(t *testing.T) {
+	want := map[string]string{"1": "forest", "2": "ocean", "3": "lullaby"}
+	for theme, loop := range want {
+		if name, ok := soundscape.ForTheme(theme); !ok || name != loop {
+			t.Errorf("ForTheme(%q) = %q, %v, want %s", theme, name, ok, loop)
+		}
+	}
+	if _, ok := soundscape.ForTheme("mindful"); ok {
+		t.Error("a theme name matched a soundscape, want only theme ids")
+	}
+	// Every bundled loop is played under some theme
+	if names := soundscape.Names(); len(names) != len(want) {
+		t.Errorf("Names() = %v, want one loop per theme", names)
+	}
+}
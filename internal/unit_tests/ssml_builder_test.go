@@ -0,0 +1,50 @@
+package unittests
+
+import (
+	"strings"
+	"testing"
+
+	"rio-go-model/internal/util"
+)
+
+// TestSSMLBuilder_English checks escaping, dialogue prosody and the bedtime slow-down
+func TestSSMLBuilder_English(t *testing.T) {
+	ssml := util.BuildSSMLFromStory("Pip & Mira <hid>. \"Look out!\"\n\nThey fell asleep.")
+	for _, want := range []string{
+		"Pip &amp; Mira &lt;hid&gt;.",
+		`<prosody rate="105%" pitch="+2st" volume="loud">"Look out!"</prosody>`,
+		`<break time="1200ms"/>`,
+		`<prosody rate="85%" pitch="-1st" volume="soft">They fell asleep.</prosody>`,
+	} {
+		if !strings.Contains(ssml, want) {
+			t.Errorf("SSML does not contain %s:\n%s", want, ssml)
+		}
+	}
+
+	if _, ok := util.SSMLBuilderFor("English"); !ok {
+		t.Error("English has no SSML builder")
+	}
+}
+
+// TestSSMLBuilder_Chunks checks that chunks stay within the byte limit and that a sentence too long
+// for any chunk is cut between words
+func TestSSMLBuilder_Chunks(t *testing.T) {
+	story := strings.Repeat("The brave little turtle walked on. ", 200) + strings.Repeat("longword ", 300) + "end."
+	chunks := util.BuildSSMLChunks(util.NewEnglishSSMLBuilder(), story, 1000)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the story split", len(chunks))
+	}
+	words := 0
+	for i, chunk := range chunks {
+		if len(chunk) > 1000 {
+			t.Errorf("chunk %d is %d bytes", i, len(chunk))
+		}
+		if !strings.HasPrefix(chunk, "<speak>") || !strings.HasSuffix(chunk, "</speak>") {
+			t.Errorf("chunk %d is not a speak document", i)
+		}
+		words += strings.Count(chunk, "longword")
+	}
+	if words != 300 {
+		t.Errorf("chunks hold %d of the 300 long-sentence words", words)
+	}
+}
@@ -0,0 +1,77 @@
+package unittests
+
+import (
+	"context"
+	"testing"
+
+	"rio-go-model/internal/services/translator"
+)
+
+// countingTranslator records the texts sent to the translator it wraps
+type countingTranslator struct {
+	next  translator.Translator
+	texts []string
+}
+
+func (c *countingTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	c.texts = append(c.texts, texts...)
+	return c.next.Translate(ctx, texts, source, target)
+}
+
+// TestTranslator_CachedDictionary checks dictionary lookups and that the cache only sends new texts, once each
+func TestTranslator_CachedDictionary(t *testing.T) {
+	dictionary := translator.NewDictionary().
+		Add("en", "te", "The moon", "చందమామ").
+		Add("en", "te", "tree", "చెట్టు")
+	counting := &countingTranslator{next: dictionary}
+	cached := translator.NewCached(counting, translator.NewMemoryStore(10))
+	ctx := context.Background()
+
+	got, err := cached.Translate(ctx, []string{"The moon", "a tree\nand a tree", "The moon"}, "en", "te")
+	if err != nil {
+		t.Fatalf("Translate returned error: %v", err)
+	}
+	want := []string{"చందమామ", "a చెట్టు\nand a చెట్టు", "చందమామ"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("translation %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if len(counting.texts) != 2 {
+		t.Errorf("sent %d texts to the translator, want 2 distinct texts", len(counting.texts))
+	}
+
+	if _, err := cached.Translate(ctx, []string{"The moon", "tree"}, "en", "te"); err != nil {
+		t.Fatalf("Translate returned error: %v", err)
+	}
+	if len(counting.texts) != 3 || counting.texts[2] != "tree" {
+		t.Errorf("cached texts were sent again: %q", counting.texts)
+	}
+	if got, _ := translator.TranslateOne(ctx, cached, "tree", "en", "hi"); got != "tree" {
+		t.Errorf("texts without an entry for the pair should be unchanged, got %q", got)
+	}
+}
+
+// TestTranslator_MemoryStoreEviction checks the store drops its oldest translation when full
+func TestTranslator_MemoryStoreEviction(t *testing.T) {
+	store := translator.NewMemoryStore(2)
+	store.Set("a", "1")
+	store.Set("b", "2")
+	store.Set("a", "3")
+	store.Set("c", "4")
+	if _, ok := store.Get("a"); ok {
+		t.Errorf("oldest translation was not dropped")
+	}
+	if got, _ := store.Get("c"); got != "4" {
+		t.Errorf("Get(c) = %q, want 4", got)
+	}
+}
+
+// TestTranslator_MemoryStoreNegativeSize checks that a negative TRANSLATION_CACHE_SIZE stores nothing instead of panicking
+func TestTranslator_MemoryStoreNegativeSize(t *testing.T) {
+	store := translator.NewMemoryStore(-1)
+	store.Set("a", "1")
+	if _, ok := store.Get("a"); ok {
+		t.Errorf("a store of negative size kept a translation")
+	}
+}
@@ -0,0 +1,32 @@
+package unittests
+
+import (
+	"testing"
+
+	"rio-go-model/internal/util"
+)
+
+// TestTransliterateTelugu checks ISO 15919 romanization of vowel signs, viramas, conjuncts and modifiers
+func TestTransliterateTelugu(t *testing.T) {
+	cases := map[string]string{
+		"తెలుగు": "telugu",
+		"రాముడు": "rāmuḍu",
+		"సంతోషం": "saṁtōṣaṁ",
+		"దుఃఖం":  "duḥkhaṁ",
+		"కృష్ణ":  "kr̥ṣṇa",
+		"ఇక్కడ ఒక చిన్న కొలను ఉంది!": "ikkaḍa oka cinna kolanu uṁdi!",
+		"౧౨ ఏళ్ళు": "12 ēḷḷu",
+	}
+	for telugu, want := range cases {
+		if got := util.TransliterateTelugu(telugu); got != want {
+			t.Errorf("TransliterateTelugu(%q) = %q, want %q", telugu, got, want)
+		}
+	}
+
+	if got, ok := util.Romanize("తెలుగు", "Telugu"); !ok || got != "telugu" {
+		t.Errorf("Romanize(Telugu) = %q, %v", got, ok)
+	}
+	if _, ok := util.Romanize("Hello", "English"); ok {
+		t.Errorf("English is already in Roman script and should not be romanized")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"rio-go-model/configs"
+	"rio-go-model/internal/helpers/google/audio"
 	"rio-go-model/internal/services"
 	"rio-go-model/internal/services/database"
 	"rio-go-model/internal/util"
@@ -93,6 +94,24 @@ func TestGetVoiceList_Voices(t *testing.T) {
 	}
 }
 
+// TestPickVoice_Pinned checks that a picked voice is kept when it is passed back as the voice,
+// so every bilingual paragraph of a language reads in it
+func TestPickVoice_Pinned(t *testing.T) {
+	tts := &audio.GoogleTTS{Logger: log.Default()}
+	for _, language := range []string{"English", "Telugu"} {
+		name, err := tts.PickVoice(language, "1", "Standard")
+		if err != nil {
+			t.Fatalf("PickVoice(%s) returned error: %v", language, err)
+		}
+		for i := 0; i < 5; i++ {
+			again, err := tts.PickVoice(language, "1", name)
+			if err != nil || again != name {
+				t.Errorf("PickVoice(%s, %q) = %q, %v; want the pinned voice", language, name, again, err)
+			}
+		}
+	}
+}
+
 // TestSuspendAudioAPI_Database tests the SuspendAudioAPI function
 // Note: This requires a valid database connection and API trigger data
 // This test will be skipped if database credentials are not available
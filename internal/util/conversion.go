@@ -1,14 +1,10 @@
 package util
 
-// LanguageMapper maps language and country to language code
+import "rio-go-model/configs/languages"
+
+// LanguageMapper maps a language and story theme to the TTS language code of its language pack
 func LanguageMapper(language string, theme string) string {
-	if language == "Telugu" {
-		return "te-IN"
-	}
-	if theme == "2" {
-		return "en-IN"
-	}
-	return "en-US"
+	return languages.Lookup(language).TTSLocale(theme)
 }
 
 // SafeStringSlice converts interface{} to []string safely.
@@ -0,0 +1,169 @@
+// Package segment splits story text into sentences and packs them into chunks for speech synthesis.
+// Sentences keep their punctuation, so questions and exclamations are still read as such, and
+// chunks are cut between sentences, then between words, and never inside a character.
+package segment
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// terminators end a sentence: Latin punctuation, the ellipsis character, the Devanagari danda and
+// double danda used by Telugu and other Indic scripts, and full-width marks
+var terminators = map[rune]bool{
+	'.': true, '!': true, '?': true, '…': true,
+	'।': true, '॥': true,
+	'。': true, '！': true, '？': true,
+}
+
+// closers may follow the terminator and still belong to the sentence
+var closers = map[rune]bool{
+	'"': true, '\'': true, '”': true, '’': true, '»': true, ')': true, ']': true,
+}
+
+// abbreviations end with a period that does not end the sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "prof": true, "sr": true, "jr": true,
+	"mt": true, "vs": true, "etc": true, "e.g": true, "i.e": true,
+}
+
+// Sentences splits text into its sentences, trimmed and with their punctuation. A sentence ends at a
+// run of terminators and any closing quotes after it, unless the next word starts in lower case, as
+// in `"Look out!" said Pip`, or the period belongs to an abbreviation or an initial. A blank line
+// always ends a sentence.
+func Sentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+	emit := func(end int) {
+		if sentence := strings.Join(strings.Fields(string(runes[start:end])), " "); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' && blankLineFollows(runes, i) {
+			emit(i)
+			continue
+		}
+		if !terminators[r] {
+			continue
+		}
+		mark := i
+		end := i + 1
+		for end < len(runes) && terminators[runes[end]] {
+			end++
+		}
+		lonePeriod := r == '.' && end == mark+1
+		for end < len(runes) && closers[runes[end]] {
+			end++
+		}
+		i = end - 1
+		// Inside a word, as in 3.5 or a URL, the mark does not end the sentence
+		if end < len(runes) && !unicode.IsSpace(runes[end]) {
+			continue
+		}
+		if lonePeriod && end == mark+1 && isAbbreviation(runes[start:mark]) {
+			continue
+		}
+		if next := nextLetter(runes, end); next != 0 && unicode.IsLower(next) {
+			continue
+		}
+		emit(end)
+	}
+	emit(len(runes))
+	return sentences
+}
+
+// blankLineFollows reports whether the newline at i is followed by another one with only spaces between
+func blankLineFollows(runes []rune, i int) bool {
+	for j := i + 1; j < len(runes); j++ {
+		if runes[j] == '\n' {
+			return true
+		}
+		if !unicode.IsSpace(runes[j]) {
+			return false
+		}
+	}
+	return false
+}
+
+// isAbbreviation reports whether the text before a period ends with an abbreviation or an initial
+func isAbbreviation(before []rune) bool {
+	j := len(before)
+	for j > 0 && !unicode.IsSpace(before[j-1]) && before[j-1] != '(' && before[j-1] != '"' && before[j-1] != '“' {
+		j--
+	}
+	word := string(before[j:])
+	// A capital letter on its own is an initial, as in J. R. Tolkien, except the pronoun I
+	if utf8.RuneCountInString(word) == 1 && unicode.IsUpper([]rune(word)[0]) && word != "I" {
+		return true
+	}
+	return abbreviations[strings.ToLower(word)]
+}
+
+// nextLetter returns the first letter after position i, skipping spaces and opening quotes, or 0
+func nextLetter(runes []rune, i int) rune {
+	for ; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsLetter(r) {
+			return r
+		}
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			return 0
+		}
+	}
+	return 0
+}
+
+// Chunks packs the sentences of text into chunks of at most maxBytes, joined by spaces. A sentence
+// longer than maxBytes is cut between words, and a word longer than maxBytes between characters.
+func Chunks(text string, maxBytes int) []string {
+	var chunks []string
+	var chunk strings.Builder
+	add := func(piece string) {
+		if chunk.Len() > 0 && chunk.Len()+1+len(piece) > maxBytes {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+		}
+		if chunk.Len() > 0 {
+			chunk.WriteString(" ")
+		}
+		chunk.WriteString(piece)
+	}
+	for _, sentence := range Sentences(text) {
+		if len(sentence) <= maxBytes {
+			add(sentence)
+			continue
+		}
+		for _, word := range strings.Fields(sentence) {
+			for _, piece := range splitRunes(word, maxBytes) {
+				add(piece)
+			}
+		}
+	}
+	if chunk.Len() > 0 {
+		chunks = append(chunks, chunk.String())
+	}
+	return chunks
+}
+
+// splitRunes cuts s into pieces of at most maxBytes on character boundaries
+func splitRunes(s string, maxBytes int) []string {
+	var pieces []string
+	for len(s) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			// maxBytes is smaller than one character, so the character goes whole
+			_, cut = utf8.DecodeRuneInString(s)
+		}
+		pieces = append(pieces, s[:cut])
+		s = s[cut:]
+	}
+	return append(pieces, s)
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"rio-go-model/configs/languages"
+)
+
+// SSMLBuilder turns story text into SSML for narration
+type SSMLBuilder interface {
+	// Fragments returns the story as SSML fragments without the <speak> element. A chunk of
+	// narration may end after any fragment, so no fragment leaves an element open.
+	Fragments(story string) []string
+}
+
+// ssmlBuilders maps an SSML builder name of a language pack to its builder
+var ssmlBuilders = map[string]func() SSMLBuilder{
+	languages.SSMLBuilderEnglish: func() SSMLBuilder { return NewEnglishSSMLBuilder() },
+	languages.SSMLBuilderTelugu:  func() SSMLBuilder { return NewTeluguSSMLBuilder() },
+}
+
+// SSMLBuilderFor returns the SSML builder the language pack names.
+// ok is false for languages narrated from plain text.
+func SSMLBuilderFor(language string) (builder SSMLBuilder, ok bool) {
+	pack, found := languages.Get(language)
+	if !found {
+		return nil, false
+	}
+	newBuilder, ok := ssmlBuilders[pack.SSMLBuilder()]
+	if !ok {
+		return nil, false
+	}
+	return newBuilder(), true
+}
+
+const (
+	ssmlOpen  = "<speak>"
+	ssmlClose = "</speak>"
+)
+
+// BuildSSMLChunks builds the story with builder and packs the fragments into <speak> documents of
+// at most maxBytes each. A fragment too long for any chunk is read as plain text, cut between words.
+func BuildSSMLChunks(builder SSMLBuilder, story string, maxBytes int) []string {
+	room := maxBytes - len(ssmlOpen) - len(ssmlClose)
+	var chunks []string
+	var body strings.Builder
+	flush := func() {
+		if body.Len() > 0 {
+			chunks = append(chunks, ssmlOpen+body.String()+ssmlClose)
+			body.Reset()
+		}
+	}
+	add := func(fragment string) {
+		if body.Len()+len(fragment) > room {
+			flush()
+		}
+		body.WriteString(fragment)
+	}
+	for _, fragment := range builder.Fragments(story) {
+		if len(fragment) <= room {
+			add(fragment)
+			continue
+		}
+		for _, piece := range splitWords(ssmlTag.ReplaceAllString(fragment, ""), room) {
+			add(piece)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// ssmlTag matches an SSML start, end or empty element tag
+var ssmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// splitWords cuts escaped text into pieces of at most maxBytes, between words
+func splitWords(text string, maxBytes int) []string {
+	var pieces []string
+	var piece strings.Builder
+	for _, word := range strings.Fields(text) {
+		if piece.Len() > 0 && piece.Len()+1+len(word) > maxBytes {
+			pieces = append(pieces, piece.String())
+			piece.Reset()
+		}
+		if piece.Len() > 0 {
+			piece.WriteString(" ")
+		}
+		piece.WriteString(word)
+	}
+	if piece.Len() > 0 {
+		pieces = append(pieces, piece.String())
+	}
+	return pieces
+}
+
+// escapeSSML escapes the characters that are markup in SSML text
+func escapeSSML(text string) string {
+	return ssmlEscaper.Replace(text)
+}
+
+var ssmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// storyParagraphs normalizes whitespace and splits a story into its non-empty paragraphs
+func storyParagraphs(story string) []string {
+	story = strings.ReplaceAll(story, "\r\n", "\n")
+	var paragraphs []string
+	for _, paragraph := range regexp.MustCompile(`\n\s*\n`).Split(story, -1) {
+		paragraph = strings.Join(strings.Fields(paragraph), " ")
+		if paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
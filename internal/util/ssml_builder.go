@@ -3,121 +3,82 @@ package util
 import (
 	"regexp"
 	"strings"
-)
-
-// BuildSSMLFromStory converts plain AI-generated story text into SSML.
-// Heuristics applied:
-// 1) Split into paragraphs by blank lines → wrap each in <p> ... </p>
-// 2) Split into sentences (. ! ?) → insert short <break time="700ms"/> between sentences
-// 3) Add longer pause between paragraphs (<break time="2s"/>)
-// 4) Emphasize impactful keywords (e.g., brave, danger, mysterious, hero, magic)
-// 5) XML-escape special characters to keep SSML valid
-func BuildSSMLFromStory(story string) string {
-	story = strings.TrimSpace(story)
-	if story == "" {
-		return "<speak></speak>"
-	}
 
-	// Local helper: XML escape
-	escapeXML := func(s string) string {
-		s = strings.ReplaceAll(s, "&", "&amp;")
-		s = strings.ReplaceAll(s, "<", "&lt;")
-		s = strings.ReplaceAll(s, ">", "&gt;")
-		s = strings.ReplaceAll(s, "\"", "&quot;")
-		s = strings.ReplaceAll(s, "'", "&apos;")
-		return s
-	}
-
-	// Normalize whitespace
-	normalize := func(s string) string {
-		s = strings.ReplaceAll(s, "\r\n", "\n")
-		s = strings.ReplaceAll(s, "\r", "\n")
-		// Collapse spaces within lines
-		spaceRe := regexp.MustCompile(`\s+`)
-		s = spaceRe.ReplaceAllString(s, " ")
-		// Restore paragraph breaks (two or more newlines)
-		paraRe := regexp.MustCompile(`\n{2,}`)
-		s = paraRe.ReplaceAllString(s, "\n\n")
-		return strings.TrimSpace(s)
-	}
-
-	story = normalize(story)
+	"rio-go-model/internal/util/segment"
+)
 
-	// Split paragraphs by blank lines
-	paragraphs := strings.Split(story, "\n\n")
+// EnglishSSMLBuilder creates expressive SSML for English bedtime stories:
+// 1) A sentence per <s> with a short break between sentences and a longer one between paragraphs
+// 2) Quoted dialogue a little higher and quicker than the narration, and louder when it exclaims
+// 3) Exclamations and impactful keywords (brave, danger, magic...) emphasized
+// 4) The last paragraph slowed down and softened, the final sentence most of all, to settle the listener
+type EnglishSSMLBuilder struct {
+	keywords *regexp.Regexp
+}
 
-	// Prepare keyword emphasis (case-insensitive word boundaries)
+// NewEnglishSSMLBuilder creates a new English SSML builder
+func NewEnglishSSMLBuilder() *EnglishSSMLBuilder {
 	keywords := []string{
 		"brave", "hero", "danger", "mysterious", "magic", "magical", "whisper", "silence",
 		"dark", "shadow", "curse", "destiny", "legend", "secret", "forbidden",
 		"victory", "fear", "hope",
 	}
-	// Build a regex that matches any keyword as a whole word, case-insensitive
-	// Example: (?i)\b(brave|hero|danger)\b
-	kwPattern := "(?i)\\b(" + strings.Join(keywords, "|") + ")\\b"
-	kwRe := regexp.MustCompile(kwPattern)
-
-	// Local helper: emphasize keywords in a sentence
-	emphasizeKeywords := func(s string) string {
-		return kwRe.ReplaceAllStringFunc(s, func(m string) string {
-			return "<emphasis level=\"moderate\">" + m + "</emphasis>"
-		})
+	return &EnglishSSMLBuilder{
+		keywords: regexp.MustCompile(`(?i)\b(` + strings.Join(keywords, "|") + `)\b`),
 	}
+}
 
-	// Sentence splitter: split on ., !, ? while keeping punctuation
-	// We first separate sentence end marks with a delimiter, then split
-	endMarkRe := regexp.MustCompile(`([.!?])`)
-
-	var b strings.Builder
-	b.WriteString("<speak>")
+// dialoguePattern matches quoted speech in escaped text
+var dialoguePattern = regexp.MustCompile(`“[^”]*”|"[^"]*"`)
 
-	for pi, p := range paragraphs {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
+// Fragments returns a fragment per sentence, with the breaks between sentences and paragraphs
+func (e *EnglishSSMLBuilder) Fragments(story string) []string {
+	var fragments []string
+	paragraphs := storyParagraphs(story)
+	for pi, paragraph := range paragraphs {
+		if pi > 0 {
+			fragments = append(fragments, `<break time="1200ms"/>`)
 		}
-
-		// Escape paragraph text first so tags we add are the only tags present
-		p = escapeXML(p)
-
-		// Split sentences
-		// Insert a pipe after sentence terminators, then split on the pipe
-		marked := endMarkRe.ReplaceAllString(p, "${1}|")
-		rawSentences := strings.Split(marked, "|")
-
-		// Write paragraph
-		b.WriteString("<p>")
-
-		firstSentence := true
-		for _, s := range rawSentences {
-			s = strings.TrimSpace(s)
-			if s == "" {
-				continue
+		sentences := segment.Sentences(paragraph)
+		lastParagraph := pi == len(paragraphs)-1
+		for si, sentence := range sentences {
+			if si > 0 {
+				fragments = append(fragments, `<break time="400ms"/>`)
 			}
-
-			// Re-normalize spaces in the sentence
-			s = strings.TrimSpace(s)
-
-			// Emphasize keywords (after escaping)
-			s = emphasizeKeywords(s)
-
-			if !firstSentence {
-				// Short pause between sentences
-				b.WriteString(`<break time="700ms"/>`)
+			sentence = e.sentence(escapeSSML(sentence))
+			// Bedtime slow-down: the last paragraph winds down and its last sentence most of all
+			switch {
+			case lastParagraph && si == len(sentences)-1:
+				sentence = `<prosody rate="85%" pitch="-1st" volume="soft">` + sentence + `</prosody>`
+			case lastParagraph:
+				sentence = `<prosody rate="90%" volume="soft">` + sentence + `</prosody>`
 			}
-			firstSentence = false
-
-			b.WriteString(s)
+			fragments = append(fragments, "<s>"+sentence+"</s>")
 		}
+	}
+	return fragments
+}
 
-		b.WriteString("</p>")
-
-		// Longer pause between paragraphs
-		if pi < len(paragraphs)-1 {
-			b.WriteString(`<break time="2s"/>`)
+// sentence adds dialogue prosody and emphasis to one escaped sentence
+func (e *EnglishSSMLBuilder) sentence(text string) string {
+	hasDialogue := dialoguePattern.MatchString(text)
+	text = dialoguePattern.ReplaceAllStringFunc(text, func(quote string) string {
+		if strings.Contains(quote, "!") {
+			return `<prosody rate="105%" pitch="+2st" volume="loud">` + quote + `</prosody>`
 		}
+		return `<prosody rate="105%" pitch="+2st">` + quote + `</prosody>`
+	})
+	hasKeyword := e.keywords.MatchString(text)
+	text = e.keywords.ReplaceAllString(text, `<emphasis level="moderate">$1</emphasis>`)
+	// A whole exclamation is emphasized unless parts of it already are
+	if !hasDialogue && !hasKeyword && strings.HasSuffix(strings.TrimRight(text, `"”’'`), "!") {
+		text = `<emphasis level="moderate">` + text + `</emphasis>`
 	}
+	return text
+}
 
-	b.WriteString("</speak>")
-	return b.String()
+// BuildSSMLFromStory converts plain AI-generated story text into a single SSML document
+// with the English builder. Use BuildSSMLChunks for stories that may exceed the TTS input limit.
+func BuildSSMLFromStory(story string) string {
+	return ssmlOpen + strings.Join(NewEnglishSSMLBuilder().Fragments(story), "") + ssmlClose
 }
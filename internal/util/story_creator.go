@@ -5,8 +5,9 @@ import (
 	"regexp"
 	"strings"
 
-	"rio-go-model/configs/english"
-	"rio-go-model/configs/telugu"
+	"rio-go-model/configs"
+	"rio-go-model/configs/catalog"
+	"rio-go-model/configs/languages"
 )
 
 // AIMessage represents a message in the AI conversation
@@ -24,98 +25,43 @@ type StoryResponse struct {
 // generateFormattedPrompt generates the formatted prompt based on version and parameters
 func GenerateFormattedPrompt(theme, topic string, kwargs map[string]interface{}) (string, string, error) {
 	var formattedPrompt, systemMessage string
-	var promptTemplate *PromptTemplate
 	// Extract parameters
 	country := getStringFromMap(kwargs, "country", "")
 	city := getStringFromMap(kwargs, "city", "")
 	religion := getStringFromMap(kwargs, "religions", "")
 	preference := getStringFromMap(kwargs, "preferences", "")
+	language := getStringFromMap(kwargs, "language", languages.Default)
 	log.Printf("Generated preferences: %v", preference)
 
-	switch theme {
-	case "1":
-		if kwargs["language"] == "Telugu" {
-			cfg := telugu.PlanetProtectorPromptConfig(topic, country, city)
-			promptTemplate = &PromptTemplate{
-				Prompt: cfg.Prompt,
-				System: cfg.System,
-			}
-			formattedPrompt = promptTemplate.Prompt
-			systemMessage = promptTemplate.System
-		} else {
-			cfg := english.PlanetProtectorPromptConfig(topic, country, city)
-			promptTemplate = &PromptTemplate{
-				Prompt: cfg.Prompt,
-				System: cfg.System,
-			}
-			formattedPrompt = promptTemplate.Prompt
-			systemMessage = promptTemplate.System
-		}
-
-	case "2":
-		if kwargs["language"] == "Telugu" {
-			cfg := telugu.MindfulStoriesPromptConfig(topic, religion)
-			promptTemplate = &PromptTemplate{
-				Prompt: cfg.Prompt,
-				System: cfg.System,
-			}
-		} else {
-			cfg := english.MindfulStoriesPromptConfig(topic, religion)
-			promptTemplate = &PromptTemplate{
-				Prompt: cfg.Prompt,
-				System: cfg.System,
-			}
-		}
-		formattedPrompt = promptTemplate.Prompt
-		systemMessage = promptTemplate.System
-
-	case "3":
-		if kwargs["language"] == "Telugu" {
-			cfg := telugu.ChillStoriesPromptConfig(topic)
-			promptTemplate = &PromptTemplate{
-				Prompt: cfg.Prompt,
-				System: cfg.System,
-			}
-		} else {
-			cfg := english.ChillStoriesPromptConfig(topic)
-			promptTemplate = &PromptTemplate{
-				Prompt: cfg.Prompt,
-				System: cfg.System,
-			}
+	// The story helper picks the prompt version; direct callers get the default version
+	promptTemplate, ok := kwargs["prompt_template"].(*configs.PromptTemplate)
+	if !ok {
+		promptTemplate = configs.GetSettings().PromptRegistry.Default(configs.PromptTemplateName(theme), language)
+	}
+	if promptTemplate != nil {
+		var err error
+		systemMessage, formattedPrompt, err = promptTemplate.Render(configs.PromptVars{
+			Topic:    topic,
+			Country:  country,
+			City:     city,
+			Religion: religion,
+			Language: language,
+		})
+		if err != nil {
+			return "", "", err
 		}
-		formattedPrompt = promptTemplate.Prompt
-		systemMessage = promptTemplate.System
 	}
 
 	// Add preference-specific content
-	preference = strings.ToUpper(preference)
-	if kwargs["language"] == "Telugu" {
-		prefContent := telugu.Preferences()[strings.ToUpper(preference)]
-		if prefContent != "" {
-			formattedPrompt += prefContent
-		}
-	} else {
-		log.Printf("Generated preference: %s", preference)
-		prefContent := english.Preferences()[strings.ToUpper(preference)]
+	if prefContent := catalog.Current().Language(language).Preference(preference); prefContent != "" {
+		formattedPrompt += prefContent
 		log.Printf("Generated preference content: %s", prefContent)
-		if prefContent != "" {
-			formattedPrompt += prefContent
-			log.Printf("Generated preference content: %s", formattedPrompt)
-		}
 	}
 
 	log.Printf("Generated prompt 3: %s", formattedPrompt)
 	return formattedPrompt, systemMessage, nil
 }
 
-// Configuration structures
-
-// PromptTemplate represents a prompt template configuration
-type PromptTemplate struct {
-	Prompt string `json:"prompt"`
-	System string `json:"system"`
-}
-
 // Helper functions for map operations
 
 // getStringFromMap safely extracts a string value from a map
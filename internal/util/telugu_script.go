@@ -0,0 +1,60 @@
+package util
+
+// Telugu character tables, shared by the SSML builder and the transliterator.
+// Each character maps to its ISO 15919 romanization.
+
+// teluguVowels are the independent vowels (achulu)
+var teluguVowels = map[rune]string{
+	'అ': "a", 'ఆ': "ā", 'ఇ': "i", 'ఈ': "ī", 'ఉ': "u", 'ఊ': "ū",
+	'ఋ': "r̥", 'ౠ': "r̥̄", 'ఌ': "l̥", 'ౡ': "l̥̄",
+	'ఎ': "e", 'ఏ': "ē", 'ఐ': "ai", 'ఒ': "o", 'ఓ': "ō", 'ఔ': "au",
+}
+
+// teluguVowelSigns are the dependent vowel signs (vothulu) that replace a consonant's inherent a
+var teluguVowelSigns = map[rune]string{
+	'ా': "ā", 'ి': "i", 'ీ': "ī", 'ు': "u", 'ూ': "ū",
+	'ృ': "r̥", 'ౄ': "r̥̄", 'ౢ': "l̥", 'ౣ': "l̥̄",
+	'ె': "e", 'ే': "ē", 'ై': "ai", 'ొ': "o", 'ో': "ō", 'ౌ': "au",
+}
+
+// teluguConsonants are the consonants (vyanjanalu), without their inherent a
+var teluguConsonants = map[rune]string{
+	'క': "k", 'ఖ': "kh", 'గ': "g", 'ఘ': "gh", 'ఙ': "ṅ",
+	'చ': "c", 'ఛ': "ch", 'జ': "j", 'ఝ': "jh", 'ఞ': "ñ",
+	'ట': "ṭ", 'ఠ': "ṭh", 'డ': "ḍ", 'ఢ': "ḍh", 'ణ': "ṇ",
+	'త': "t", 'థ': "th", 'ద': "d", 'ధ': "dh", 'న': "n",
+	'ప': "p", 'ఫ': "ph", 'బ': "b", 'భ': "bh", 'మ': "m",
+	'య': "y", 'ర': "r", 'ల': "l", 'వ': "v", 'శ': "ś",
+	'ష': "ṣ", 'స': "s", 'హ': "h", 'ళ': "ḷ", 'ఱ': "ṟ",
+	// Historic dental affricates and rra
+	'ౘ': "ts", 'ౙ': "dz", 'ౚ': "ṟ",
+}
+
+// teluguModifiers are the signs that nasalize or aspirate the preceding syllable
+var teluguModifiers = map[rune]string{
+	'ం': "ṁ",  // anusvara (sunna)
+	'ః': "ḥ",  // visarga
+	'ఁ': "m̐", // candrabindu (arasunna)
+	'ఽ': "'",  // avagraha
+}
+
+// teluguVirama removes the inherent a of the consonant before it
+const teluguVirama = '్'
+
+// teluguDigits are the Telugu numerals
+var teluguDigits = map[rune]string{
+	'౦': "0", '౧': "1", '౨': "2", '౩': "3", '౪': "4",
+	'౫': "5", '౬': "6", '౭': "7", '౮': "8", '౯': "9",
+}
+
+// teluguStressCharacters returns every Telugu character the SSML builder treats as stress-bearing:
+// vowels, vowel signs, consonants, modifiers, the virama and the digits
+func teluguStressCharacters() map[rune]bool {
+	characters := map[rune]bool{teluguVirama: true}
+	for _, table := range []map[rune]string{teluguVowels, teluguVowelSigns, teluguConsonants, teluguModifiers, teluguDigits} {
+		for r := range table {
+			characters[r] = true
+		}
+	}
+	return characters
+}
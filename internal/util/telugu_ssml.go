@@ -3,6 +3,8 @@ package util
 import (
 	"regexp"
 	"strings"
+
+	"rio-go-model/internal/util/segment"
 )
 
 // TeluguSSMLBuilder creates SSML for Telugu text with proper emotion, pauses, and stress
@@ -18,34 +20,8 @@ type TeluguSSMLBuilder struct {
 // NewTeluguSSMLBuilder creates a new Telugu SSML builder
 func NewTeluguSSMLBuilder() *TeluguSSMLBuilder {
 	return &TeluguSSMLBuilder{
-		vothulu: map[rune]bool{
-			// Telugu vowel signs (vothulu) - all 16 vowel signs
-			'ా': true, 'ి': true, 'ీ': true, 'ు': true, 'ూ': true, 'ె': true, 'ే': true, 'ొ': true, 'ో': true,
-			'ౌ': true, 'అ': true, 'ఆ': true, 'ఇ': true, 'ఈ': true, 'ఉ': true, 'ఊ': true, 'ఎ': true, 'ఏ': true,
-			'ఒ': true, 'ఓ': true, 'ఔ': true, 'ం': true, 'ః': true,
-
-			// Additional Telugu vowel signs and diacritical marks
-			'ృ': true, 'ౄ': true, // R-vowels (ఋ, ౠ)
-			'ౢ': true, 'ౣ': true, // L-vowels (ఌ, ౡ)
-
-			// Telugu diacritical marks and modifiers
-			'్': true,                       // Virama (halant) - removes inherent vowel
-			'ఁ': true,                       // Candrabindu (anusvara)
-			'ౘ': true, 'ౙ': true, 'ౚ': true, // Additional Telugu characters
-
-			// Telugu consonants (vyanjanalu) - all 36 consonants
-			'క': true, 'ఖ': true, 'గ': true, 'ఘ': true, 'ఙ': true,
-			'చ': true, 'ఛ': true, 'జ': true, 'ఝ': true, 'ఞ': true,
-			'ట': true, 'ఠ': true, 'డ': true, 'ఢ': true, 'ణ': true,
-			'త': true, 'థ': true, 'ద': true, 'ధ': true, 'న': true,
-			'ప': true, 'ఫ': true, 'బ': true, 'భ': true, 'మ': true,
-			'య': true, 'ర': true, 'ల': true, 'వ': true, 'శ': true,
-			'ష': true, 'స': true, 'హ': true, 'ళ': true, 'ఱ': true,
-
-			// Telugu numbers (can have stress too)
-			'౦': true, '౧': true, '౨': true, '౩': true, '౪': true,
-			'౫': true, '౬': true, '౭': true, '౮': true, '౯': true,
-		},
+		// Telugu vowels, vowel signs (vothulu), consonants, modifiers and digits all carry stress
+		vothulu: teluguStressCharacters(),
 		emotionalKeywords: map[string]string{
 			// Positive emotions
 			"ఆనందం": "strong", "సంతోషం": "strong", "ఆశ్చర్యం": "moderate", "ఉత్సాహం": "strong",
@@ -136,6 +112,20 @@ func (t *TeluguSSMLBuilder) BuildTeluguSSML(story string) string {
 	return b.String()
 }
 
+// Fragments returns a fragment per sentence with its emotions, pauses and stress, and a pause between paragraphs
+func (t *TeluguSSMLBuilder) Fragments(story string) []string {
+	var fragments []string
+	for pi, paragraph := range storyParagraphs(story) {
+		if pi > 0 {
+			fragments = append(fragments, `<break time="1s"/>`)
+		}
+		for _, sentence := range segment.Sentences(paragraph) {
+			fragments = append(fragments, "<s>"+t.processTextWithEmotions(escapeSSML(sentence))+"</s>")
+		}
+	}
+	return fragments
+}
+
 // processTextWithEmotions processes text to add emotions, pauses, and stress
 func (t *TeluguSSMLBuilder) processTextWithEmotions(text string) string {
 	// Process in order: emotions first, then pauses, then stress
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"strings"
+
+	"rio-go-model/configs/languages"
+)
+
+// TransliterateTelugu converts Telugu script to ISO 15919 Roman text.
+// A consonant keeps its inherent a unless a vowel sign replaces it or a virama removes it;
+// characters outside the Telugu tables, such as spaces and punctuation, are kept as they are.
+func TransliterateTelugu(text string) string {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if consonant, ok := teluguConsonants[r]; ok {
+			b.WriteString(consonant)
+			if i+1 < len(runes) {
+				if sign, ok := teluguVowelSigns[runes[i+1]]; ok {
+					b.WriteString(sign)
+					i++
+					continue
+				}
+				if runes[i+1] == teluguVirama {
+					i++
+					continue
+				}
+			}
+			b.WriteString("a")
+			continue
+		}
+		if romanized, ok := teluguVowels[r]; ok {
+			b.WriteString(romanized)
+		} else if romanized, ok := teluguModifiers[r]; ok {
+			b.WriteString(romanized)
+		} else if romanized, ok := teluguDigits[r]; ok {
+			b.WriteString(romanized)
+		} else if r == teluguVirama || teluguVowelSigns[r] != "" {
+			// A sign without a consonant before it has nothing to modify
+			continue
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// romanizers maps a romanization scheme of a language pack to its transliterator
+var romanizers = map[string]func(string) string{
+	languages.RomanizationTeluguISO15919: TransliterateTelugu,
+}
+
+// Romanize transliterates text of a language into Roman script.
+// ok is false for languages already written in Roman script or without a transliterator.
+func Romanize(text, language string) (romanized string, ok bool) {
+	pack, found := languages.Get(language)
+	if !found {
+		return "", false
+	}
+	romanize, ok := romanizers[pack.Romanization()]
+	if !ok {
+		return "", false
+	}
+	return romanize(text), true
+}